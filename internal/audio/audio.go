@@ -2,12 +2,20 @@ package audio
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gopxl/beep"
 	"github.com/gopxl/beep/effects"
 	"github.com/gopxl/beep/speaker"
+	"github.com/gopxl/beep/vorbis"
 	"github.com/gopxl/beep/wav"
 )
 
@@ -20,13 +28,158 @@ var (
 	quiet            bool
 	verbose          bool
 	logFunc          func(string, ...interface{})
+	masterGain       float64 // Added to every sound's volume
+	masterSilent     bool
 )
 
-// Init configures the audio package
-func Init(quietMode, verboseMode bool, logger func(string, ...interface{})) {
+// Category groups sounds so they can be muted separately
+type Category string
+
+const (
+	CategoryUI       Category = "ui"       // Menu selections and confirmations
+	CategoryProgress Category = "progress" // Download and install loops
+	CategoryStatus   Category = "status"   // Started, finished, up to date, proxy found
+	CategoryError    Category = "error"    // Failures; can't be muted on their own
+)
+
+// Categories lists every sound category
+var Categories = []Category{CategoryUI, CategoryProgress, CategoryStatus, CategoryError}
+
+var (
+	mutedMutex sync.RWMutex
+	muted      = map[Category]bool{}
+)
+
+// MuteFile holds the saved list of muted sound categories for an install
+const MuteFile = ".update-mute"
+
+// ParseCategories parses a comma-separated list of categories to mute. "none"
+// or an empty list mutes nothing. Error sounds can't be muted, so a failure
+// is never silent unless everything is quiet.
+func ParseCategories(list string) ([]Category, error) {
+	list = strings.TrimSpace(list)
+	if list == "" || list == "none" {
+		return nil, nil
+	}
+
+	var categories []Category
+	for _, name := range strings.Split(list, ",") {
+		c := Category(strings.ToLower(strings.TrimSpace(name)))
+		switch c {
+		case CategoryUI, CategoryProgress, CategoryStatus:
+			categories = append(categories, c)
+		case CategoryError:
+			return nil, fmt.Errorf("error sounds can't be muted (use -quiet to silence everything)")
+		default:
+			return nil, fmt.Errorf("unknown sound category %q (available: ui, progress, status)", name)
+		}
+	}
+	return categories, nil
+}
+
+// SetMuted replaces the set of muted categories
+func SetMuted(categories []Category) {
+	mutedMutex.Lock()
+	defer mutedMutex.Unlock()
+	muted = map[Category]bool{}
+	for _, c := range categories {
+		if c != CategoryError {
+			muted[c] = true
+		}
+	}
+}
+
+// enabled reports whether sounds in category c should play
+func enabled(c Category) bool {
+	if quiet {
+		return false
+	}
+	mutedMutex.RLock()
+	defer mutedMutex.RUnlock()
+	return !muted[c]
+}
+
+// SaveMuted writes the muted categories to the mute file in the specified directory
+func SaveMuted(baseDir string, categories []Category) error {
+	names := make([]string, len(categories))
+	for i, c := range categories {
+		names[i] = string(c)
+	}
+	return os.WriteFile(filepath.Join(baseDir, MuteFile), []byte(strings.Join(names, ",")), 0644)
+}
+
+// LoadMuted reads the muted categories from the mute file in the specified directory
+func LoadMuted(baseDir string) ([]Category, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, MuteFile))
+	if err != nil {
+		return nil, err
+	}
+	return ParseCategories(string(data))
+}
+
+// VolumeFile holds the saved master volume for an install
+const VolumeFile = ".update-volume"
+
+// DefaultVolume is full volume, leaving each sound at its own level
+const DefaultVolume = 100
+
+// Init configures the audio package. volume is the master volume from 0 to
+// 100, applied on top of the level each sound is played at.
+func Init(quietMode, verboseMode bool, volume int, logger func(string, ...interface{})) {
 	quiet = quietMode
 	verbose = verboseMode
 	logFunc = logger
+	masterGain, masterSilent = gainForVolume(volume)
+}
+
+// gainForVolume converts a 0-100 volume to an effects.Volume offset (base 2)
+func gainForVolume(volume int) (gain float64, silent bool) {
+	if volume <= 0 {
+		return 0, true
+	}
+	if volume > 100 {
+		volume = 100
+	}
+	return math.Log2(float64(volume) / 100), false
+}
+
+// ValidateVolume checks that volume is between 0 and 100
+func ValidateVolume(volume int) error {
+	if volume < 0 || volume > 100 {
+		return fmt.Errorf("invalid volume %d: must be between 0 and 100", volume)
+	}
+	return nil
+}
+
+// SaveVolume writes the master volume to the volume file in the specified directory
+func SaveVolume(baseDir string, volume int) error {
+	return os.WriteFile(filepath.Join(baseDir, VolumeFile), []byte(strconv.Itoa(volume)), 0644)
+}
+
+// LoadVolume reads the master volume from the volume file in the specified directory
+func LoadVolume(baseDir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, VolumeFile))
+	if err != nil {
+		return 0, err
+	}
+	volume, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", VolumeFile, err)
+	}
+	if err := ValidateVolume(volume); err != nil {
+		return 0, err
+	}
+	return volume, nil
+}
+
+// newVolume wraps s at volume, adjusted by the master volume
+func newVolume(s beep.Streamer, volume float64) *effects.Volume {
+	return &effects.Volume{
+		Streamer: s,
+		Base:     2,
+		Volume:   volume + masterGain,
+		Silent:   masterSilent,
+	}
 }
 
 func log(format string, args ...interface{}) {
@@ -35,23 +188,85 @@ func log(format string, args ...interface{}) {
 	}
 }
 
-func ensureSpeakerInitialized(format beep.Format) {
+// speakerInit opens the audio device; a variable so tests can stand in for it
+var speakerInit = speaker.Init
+
+// speakerInitTimeout bounds how long opening the audio device may take
+const speakerInitTimeout = 3 * time.Second
+
+// ensureSpeakerInitialized opens the audio device on first use and reports
+// whether it's usable. Machines without one (headless VMs, RDP sessions
+// without audio) can make the device panic, fail or hang; any of those turns
+// sound off for the rest of the run instead of stopping the updater.
+func ensureSpeakerInitialized(format beep.Format) bool {
 	speakerOnce.Do(func() {
 		log("Setting up audio...")
-		speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/10))
+		result := make(chan error, 1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					result <- fmt.Errorf("audio device failed: %v", r)
+				}
+			}()
+			result <- speakerInit(format.SampleRate, format.SampleRate.N(time.Second/10))
+		}()
+
+		select {
+		case err := <-result:
+			if err != nil {
+				log("No audio available, continuing without sound: %v", err)
+				return
+			}
+		case <-time.After(speakerInitTimeout):
+			log("Audio device didn't respond, continuing without sound")
+			return
+		}
 		speakerFormat = format
 		speakerReady = true
 	})
+	return speakerReady
+}
+
+// Sound formats recognised by DetectFormat
+const (
+	FormatUnknown = ""
+	FormatWAV     = "wav"
+	FormatOGG     = "ogg"
+)
+
+// DetectFormat identifies sound data by its magic bytes: "RIFF" for WAV and
+// "OggS" for Ogg Vorbis
+func DetectFormat(soundData []byte) string {
+	switch {
+	case bytes.HasPrefix(soundData, []byte("RIFF")):
+		return FormatWAV
+	case bytes.HasPrefix(soundData, []byte("OggS")):
+		return FormatOGG
+	default:
+		return FormatUnknown
+	}
 }
 
-// DecodeSound decodes WAV sound data into a streamer
+// DecodeSound decodes WAV or Ogg Vorbis sound data into a streamer
 func DecodeSound(soundData []byte) (beep.StreamSeekCloser, beep.Format, error) {
 	if len(soundData) == 0 {
 		log("Couldn't play sound (no data)")
 		return nil, beep.Format{}, nil
 	}
 
-	streamer, format, err := wav.Decode(bytes.NewReader(soundData))
+	var (
+		streamer beep.StreamSeekCloser
+		format   beep.Format
+		err      error
+	)
+	switch DetectFormat(soundData) {
+	case FormatWAV:
+		streamer, format, err = wav.Decode(bytes.NewReader(soundData))
+	case FormatOGG:
+		streamer, format, err = vorbis.Decode(io.NopCloser(bytes.NewReader(soundData)))
+	default:
+		err = fmt.Errorf("unrecognized sound format")
+	}
 	if err != nil {
 		log("Sound file couldn't be decoded: %v", err)
 		return nil, beep.Format{}, err
@@ -61,8 +276,8 @@ func DecodeSound(soundData []byte) (beep.StreamSeekCloser, beep.Format, error) {
 }
 
 // Play plays a sound synchronously (blocks until complete)
-func Play(soundData []byte) {
-	if quiet {
+func Play(c Category, soundData []byte) {
+	if !enabled(c) {
 		return
 	}
 
@@ -72,10 +287,12 @@ func Play(soundData []byte) {
 	}
 	defer streamer.Close()
 
-	ensureSpeakerInitialized(format)
+	if !ensureSpeakerInitialized(format) {
+		return
+	}
 
 	done := make(chan bool)
-	speaker.Play(beep.Seq(streamer, beep.Callback(func() {
+	speaker.Play(beep.Seq(newVolume(streamer, 0), beep.Callback(func() {
 		done <- true
 	})))
 
@@ -93,13 +310,13 @@ func StopAll() {
 }
 
 // PlayAsync plays a sound asynchronously at the specified volume (dB)
-func PlayAsync(soundData []byte, volumeDB float64) {
-	PlayAsyncLoop(soundData, volumeDB, false)
+func PlayAsync(c Category, soundData []byte, volumeDB float64) {
+	PlayAsyncLoop(c, soundData, volumeDB, false)
 }
 
 // PlayAsyncLoop plays a sound asynchronously, optionally looping
-func PlayAsyncLoop(soundData []byte, volumeDB float64, loop bool) {
-	if quiet {
+func PlayAsyncLoop(c Category, soundData []byte, volumeDB float64, loop bool) {
+	if !enabled(c) {
 		return
 	}
 
@@ -108,7 +325,10 @@ func PlayAsyncLoop(soundData []byte, volumeDB float64, loop bool) {
 		return
 	}
 
-	ensureSpeakerInitialized(format)
+	if !ensureSpeakerInitialized(format) {
+		streamer.Close()
+		return
+	}
 
 	var finalStreamer beep.Streamer = streamer
 	if loop {
@@ -116,12 +336,7 @@ func PlayAsyncLoop(soundData []byte, volumeDB float64, loop bool) {
 	}
 
 	backgroundMutex.Lock()
-	backgroundVolume = &effects.Volume{
-		Streamer: finalStreamer,
-		Base:     2,
-		Volume:   volumeDB,
-		Silent:   false,
-	}
+	backgroundVolume = newVolume(finalStreamer, volumeDB)
 	backgroundMutex.Unlock()
 
 	speaker.Play(beep.Seq(backgroundVolume, beep.Callback(func() {
@@ -139,8 +354,8 @@ func PlayAsyncLoop(soundData []byte, volumeDB float64, loop bool) {
 }
 
 // PlayWithDucking plays a foreground sound while ducking (lowering) any background audio
-func PlayWithDucking(soundData []byte, foregroundVolumeDB float64) {
-	if quiet {
+func PlayWithDucking(c Category, soundData []byte, foregroundVolumeDB float64) {
+	if !enabled(c) {
 		return
 	}
 
@@ -150,7 +365,9 @@ func PlayWithDucking(soundData []byte, foregroundVolumeDB float64) {
 	}
 	defer streamer.Close()
 
-	ensureSpeakerInitialized(format)
+	if !ensureSpeakerInitialized(format) {
+		return
+	}
 
 	// Lower the background sound
 	backgroundMutex.Lock()
@@ -171,12 +388,7 @@ func PlayWithDucking(soundData []byte, foregroundVolumeDB float64) {
 	}
 	backgroundMutex.Unlock()
 
-	foregroundVolume := &effects.Volume{
-		Streamer: streamer,
-		Base:     2,
-		Volume:   foregroundVolumeDB,
-		Silent:   false,
-	}
+	foregroundVolume := newVolume(streamer, foregroundVolumeDB)
 
 	done := make(chan bool)
 	speaker.Play(beep.Seq(foregroundVolume, beep.Callback(func() {
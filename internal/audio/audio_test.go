@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gopxl/beep"
+)
+
+// TestGainForVolume tests converting the 0-100 volume to a volume offset
+func TestGainForVolume(t *testing.T) {
+	tests := []struct {
+		volume int
+		gain   float64
+		silent bool
+	}{
+		{100, 0, false},
+		{50, -1, false},
+		{25, -2, false},
+		{0, 0, true},
+		{150, 0, false},
+	}
+	for _, tt := range tests {
+		gain, silent := gainForVolume(tt.volume)
+		if math.Abs(gain-tt.gain) > 1e-9 || silent != tt.silent {
+			t.Errorf("gainForVolume(%d) = %v, %v; want %v, %v", tt.volume, gain, silent, tt.gain, tt.silent)
+		}
+	}
+}
+
+// TestSaveLoadVolume tests that the volume setting round-trips
+func TestSaveLoadVolume(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LoadVolume(dir); !os.IsNotExist(err) {
+		t.Errorf("LoadVolume() without a file error = %v, want not exist", err)
+	}
+
+	if err := SaveVolume(dir, 40); err != nil {
+		t.Fatalf("SaveVolume() error = %v", err)
+	}
+	if got, err := LoadVolume(dir); err != nil || got != 40 {
+		t.Errorf("LoadVolume() = %d, %v; want 40", got, err)
+	}
+
+	for _, bad := range []string{"loud", "101", "-1"} {
+		os.WriteFile(filepath.Join(dir, VolumeFile), []byte(bad), 0644)
+		if _, err := LoadVolume(dir); err == nil {
+			t.Errorf("LoadVolume() with %q succeeded, want error", bad)
+		}
+	}
+}
+
+// TestParseCategories tests parsing the list of muted sound categories
+func TestParseCategories(t *testing.T) {
+	got, err := ParseCategories(" UI, progress ")
+	if err != nil || len(got) != 2 || got[0] != CategoryUI || got[1] != CategoryProgress {
+		t.Errorf("ParseCategories() = %v, %v; want [ui progress]", got, err)
+	}
+	for _, empty := range []string{"", "none"} {
+		if got, err := ParseCategories(empty); err != nil || len(got) != 0 {
+			t.Errorf("ParseCategories(%q) = %v, %v; want nothing muted", empty, got, err)
+		}
+	}
+	for _, bad := range []string{"error", "ui,error", "music"} {
+		if _, err := ParseCategories(bad); err == nil {
+			t.Errorf("ParseCategories(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+// TestSetMuted tests that muted categories don't play but errors always do
+func TestSetMuted(t *testing.T) {
+	defer SetMuted(nil)
+
+	SetMuted([]Category{CategoryUI, CategoryError})
+	for _, tt := range []struct {
+		c    Category
+		want bool
+	}{
+		{CategoryUI, false},
+		{CategoryProgress, true},
+		{CategoryStatus, true},
+		{CategoryError, true},
+	} {
+		if got := enabled(tt.c); got != tt.want {
+			t.Errorf("enabled(%s) = %v, want %v", tt.c, got, tt.want)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := SaveMuted(dir, []Category{CategoryStatus, CategoryProgress}); err != nil {
+		t.Fatalf("SaveMuted() error = %v", err)
+	}
+	if got, err := LoadMuted(dir); err != nil || len(got) != 2 || got[0] != CategoryStatus {
+		t.Errorf("LoadMuted() = %v, %v; want [status progress]", got, err)
+	}
+}
+
+// TestDetectFormat tests telling sound formats apart by their headers
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"wav", []byte("RIFF\x24\x08\x00\x00WAVEfmt "), FormatWAV},
+		{"ogg", []byte("OggS\x00\x02\x00\x00"), FormatOGG},
+		{"mp3", []byte("ID3\x03\x00"), FormatUnknown},
+		{"short", []byte("Og"), FormatUnknown},
+		{"empty", nil, FormatUnknown},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.data); got != tt.want {
+			t.Errorf("DetectFormat(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, _, err := DecodeSound([]byte("not a sound")); err == nil {
+		t.Error("DecodeSound() with an unknown format succeeded, want error")
+	}
+}
+
+// TestEnsureSpeakerInitialized_NoDevice tests that a failing audio device
+// turns sound off instead of crashing, and isn't retried
+func TestEnsureSpeakerInitialized_NoDevice(t *testing.T) {
+	originalInit := speakerInit
+	defer func() {
+		speakerInit = originalInit
+		speakerOnce = sync.Once{}
+		speakerReady = false
+	}()
+
+	calls := 0
+	speakerInit = func(beep.SampleRate, int) error {
+		calls++
+		panic("no audio device")
+	}
+	speakerOnce = sync.Once{}
+	speakerReady = false
+
+	format := beep.Format{SampleRate: 44100, NumChannels: 2, Precision: 2}
+	if ensureSpeakerInitialized(format) {
+		t.Error("ensureSpeakerInitialized() = true with a panicking device, want false")
+	}
+	if ensureSpeakerInitialized(format) {
+		t.Error("ensureSpeakerInitialized() second call = true, want false")
+	}
+	if calls != 1 {
+		t.Errorf("device opened %d times, want 1", calls)
+	}
+}
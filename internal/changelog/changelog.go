@@ -1,16 +1,98 @@
 package changelog
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/distantorigin/next-launcher/internal/github"
 	"github.com/distantorigin/next-launcher/internal/manifest"
 )
 
 // BuildConfig holds configuration for building a changelog
 type BuildConfig struct {
-	Channel string
+	Channel       string
+	ReleaseNotes  string          // Shown before the file list when set
+	Commits       []github.Commit // Listed as cliff notes when set
+	CommitAuthors bool            // Add each commit's author and date to its cliff note
+	FlatCommits   bool            // List cliff notes in commit order instead of grouped by type
+}
+
+// CommitComparer compares two commits on GitHub; *github.Client is one
+type CommitComparer interface {
+	CompareCommits(ctx context.Context, base, head string) (*github.Comparison, error)
+}
+
+// CommitsSince returns the commits from base (exclusive) to head, oldest
+// first, for BuildConfig.Commits. An unknown base or one equal to head has
+// nothing to list.
+func CommitsSince(ctx context.Context, src CommitComparer, base, head string) ([]github.Commit, error) {
+	if base == "" || base == head {
+		return nil, nil
+	}
+	comparison, err := src.CompareCommits(ctx, base, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with %s: %w", base, head, err)
+	}
+	return comparison.Commits, nil
+}
+
+// noteGroups are the headings cliff notes are grouped under, in order. A
+// commit whose type isn't listed goes under the last one.
+var noteGroups = []struct {
+	Heading string
+	Types   []string
+}{
+	{Heading: "Features", Types: []string{"feat", "feature"}},
+	{Heading: "Fixes", Types: []string{"fix", "bugfix"}},
+	{Heading: "Other"},
+}
+
+// groupIndex returns the index in noteGroups a commit type belongs to
+func groupIndex(commitType string) int {
+	for i, group := range noteGroups {
+		for _, t := range group.Types {
+			if t == commitType {
+				return i
+			}
+		}
+	}
+	return len(noteGroups) - 1
+}
+
+// writeCliffNotes writes the Changes section for commits, grouped by type
+// unless cfg.FlatCommits is set. Merge commits are left out.
+func writeCliffNotes(changelog *strings.Builder, cfg BuildConfig) {
+	var flat []string
+	grouped := make([][]string, len(noteGroups))
+	for _, commit := range cfg.Commits {
+		note := github.FormatCommitAsCliffNote(commit, cfg.CommitAuthors)
+		if note == "" {
+			continue
+		}
+		flat = append(flat, note)
+		i := groupIndex(github.CommitType(commit))
+		grouped[i] = append(grouped[i], note)
+	}
+	if len(flat) == 0 {
+		return
+	}
+
+	changelog.WriteString("\nChanges:\n")
+	if cfg.FlatCommits {
+		changelog.WriteString(strings.Join(flat, "\n"))
+		changelog.WriteString("\n")
+		return
+	}
+	for i, notes := range grouped {
+		if len(notes) == 0 {
+			continue
+		}
+		changelog.WriteString(fmt.Sprintf("\n%s:\n", noteGroups[i].Heading))
+		changelog.WriteString(strings.Join(notes, "\n"))
+		changelog.WriteString("\n")
+	}
 }
 
 // Build creates a formatted changelog string
@@ -23,6 +105,13 @@ func Build(updates []manifest.FileInfo, deletedFiles []string, cfg BuildConfig)
 	changelog.WriteString(fmt.Sprintf("Update completed: %s\n", time.Now().Format("2006-01-02 15:04:05")))
 	changelog.WriteString(fmt.Sprintf("Total changes: %d files (%d updated, %d deleted)\n", totalChanges, len(updates), len(deletedFiles)))
 
+	if notes := strings.TrimSpace(cfg.ReleaseNotes); notes != "" {
+		changelog.WriteString("\nRelease notes:\n\n")
+		changelog.WriteString(notes)
+		changelog.WriteString("\n")
+	}
+	writeCliffNotes(&changelog, cfg)
+
 	// Add file list
 	changelog.WriteString("\n")
 	changelog.WriteString(strings.Repeat("-", 60))
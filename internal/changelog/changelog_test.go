@@ -1,9 +1,12 @@
 package changelog
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/distantorigin/next-launcher/internal/github"
 	"github.com/distantorigin/next-launcher/internal/manifest"
 )
 
@@ -91,4 +94,141 @@ func TestBuild(t *testing.T) {
 			t.Error("Build() missing deleted section")
 		}
 	})
+
+	commits := []github.Commit{
+		{SHA: "abc123def456789", Commit: github.CommitInner{
+			Message: "feat: add the map plugin",
+			Author:  github.CommitAuthor{Name: "Jane Doe", Date: "2025-01-04T12:00:00Z"},
+		}},
+		{SHA: "fed987cba654321", Commit: github.CommitInner{
+			Message: "Merge pull request #12",
+			Author:  github.CommitAuthor{Name: "Jane Doe", Date: "2025-01-04T13:00:00Z"},
+		}},
+	}
+
+	t.Run("commits with authors", func(t *testing.T) {
+		got := Build(nil, nil, BuildConfig{Channel: "dev", Commits: commits, CommitAuthors: true})
+
+		if !strings.Contains(got, "- [feat] add the map plugin (abc123d by Jane Doe on Jan 4, 2025)") {
+			t.Errorf("Build() missing cliff note with author and date:\n%s", got)
+		}
+		if strings.Contains(got, "Merge pull request") {
+			t.Error("Build() should skip merge commits")
+		}
+	})
+
+	t.Run("commits without authors", func(t *testing.T) {
+		got := Build(nil, nil, BuildConfig{Channel: "stable", Commits: commits})
+
+		if !strings.Contains(got, "- [feat] add the map plugin (abc123d)") {
+			t.Errorf("Build() missing cliff note:\n%s", got)
+		}
+		if strings.Contains(got, "Jane Doe") || strings.Contains(got, "2025") {
+			t.Errorf("Build() should omit author and date when CommitAuthors is false:\n%s", got)
+		}
+	})
+
+	mixed := []github.Commit{
+		{SHA: "1111111aaaaaaa", Commit: github.CommitInner{Message: "fix: stop the map from flickering"}},
+		{SHA: "2222222bbbbbbb", Commit: github.CommitInner{Message: "feat(sounds): add rain ambience"}},
+		{SHA: "3333333ccccccc", Commit: github.CommitInner{Message: "update the readme"}},
+		{SHA: "4444444ddddddd", Commit: github.CommitInner{Message: "Merge branch 'main' into sounds"}},
+		{SHA: "5555555eeeeeee", Commit: github.CommitInner{Message: "chore: bump the version"}},
+		{SHA: "6666666fffffff", Commit: github.CommitInner{Message: "feat: add a who list"}},
+	}
+
+	t.Run("commits grouped by type", func(t *testing.T) {
+		got := Build(nil, nil, BuildConfig{Channel: "dev", Commits: mixed})
+
+		want := "Changes:\n" +
+			"\nFeatures:\n- [feat(sounds)] add rain ambience (2222222)\n- [feat] add a who list (6666666)\n" +
+			"\nFixes:\n- [fix] stop the map from flickering (1111111)\n" +
+			"\nOther:\n- update the readme (3333333)\n- [chore] bump the version (5555555)\n"
+		if !strings.Contains(got, want) {
+			t.Errorf("Build() grouped notes wrong, got:\n%s\nwant to contain:\n%s", got, want)
+		}
+		if strings.Contains(got, "Merge branch") {
+			t.Error("Build() should skip merge commits")
+		}
+	})
+
+	t.Run("flat commits", func(t *testing.T) {
+		got := Build(nil, nil, BuildConfig{Channel: "dev", Commits: mixed, FlatCommits: true})
+
+		want := "Changes:\n" +
+			"- [fix] stop the map from flickering (1111111)\n" +
+			"- [feat(sounds)] add rain ambience (2222222)\n" +
+			"- update the readme (3333333)\n" +
+			"- [chore] bump the version (5555555)\n" +
+			"- [feat] add a who list (6666666)\n"
+		if !strings.Contains(got, want) {
+			t.Errorf("Build() flat notes wrong, got:\n%s\nwant to contain:\n%s", got, want)
+		}
+		if strings.Contains(got, "Features:") {
+			t.Error("Build() should not group notes when FlatCommits is set")
+		}
+	})
+
+	t.Run("release notes", func(t *testing.T) {
+		got := Build([]manifest.FileInfo{{Name: "map.lua"}}, nil, BuildConfig{Channel: "stable", ReleaseNotes: "## Fixes\n- The map no longer flickers\n\n"})
+
+		if !strings.Contains(got, "Release notes:\n\n## Fixes\n- The map no longer flickers\n") {
+			t.Errorf("Build() missing release notes:\n%s", got)
+		}
+		if strings.Index(got, "Release notes:") > strings.Index(got, "Detailed file changes") {
+			t.Error("Build() should show release notes before the file list")
+		}
+	})
+
+	t.Run("no commits", func(t *testing.T) {
+		if got := Build(nil, nil, BuildConfig{Channel: "dev", CommitAuthors: true}); strings.Contains(got, "Changes:") {
+			t.Error("Build() should not have a changes section without commits")
+		}
+	})
+}
+
+// fakeComparer returns commits for one base...head range
+type fakeComparer struct {
+	calls   int
+	commits []github.Commit
+	err     error
+}
+
+func (f *fakeComparer) CompareCommits(ctx context.Context, base, head string) (*github.Comparison, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &github.Comparison{Commits: f.commits}, nil
+}
+
+// TestCommitsSince tests that the commits between the installed and new
+// heads reach the changelog, with their authors on dev
+func TestCommitsSince(t *testing.T) {
+	commits := []github.Commit{{SHA: "abc123def456789", Commit: github.CommitInner{
+		Message: "fix: stop the map from flickering",
+		Author:  github.CommitAuthor{Name: "Jane Doe", Date: "2025-01-04T12:00:00Z"},
+	}}}
+
+	src := &fakeComparer{commits: commits}
+	got, err := CommitsSince(context.Background(), src, "oldhead", "newhead")
+	if err != nil {
+		t.Fatalf("CommitsSince() error = %v", err)
+	}
+	log := Build(nil, nil, BuildConfig{Channel: "dev", Commits: got, CommitAuthors: true})
+	if !strings.Contains(log, "- [fix] stop the map from flickering (abc123d by Jane Doe on Jan 4, 2025)") {
+		t.Errorf("changelog missing the compared commit:\n%s", log)
+	}
+
+	// Nothing to compare: an install from before heads were recorded, or no new commits
+	for _, base := range []string{"", "newhead"} {
+		src := &fakeComparer{commits: commits}
+		if got, err := CommitsSince(context.Background(), src, base, "newhead"); err != nil || got != nil || src.calls != 0 {
+			t.Errorf("CommitsSince(%q) = %v, %v after %d calls; want nothing and no request", base, got, err, src.calls)
+		}
+	}
+
+	if _, err := CommitsSince(context.Background(), &fakeComparer{err: errors.New("HTTP 404")}, "gone", "newhead"); err == nil {
+		t.Error("CommitsSince() should report a failed comparison")
+	}
 }
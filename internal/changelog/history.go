@@ -0,0 +1,104 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/distantorigin/next-launcher/internal/paths"
+)
+
+// HistoryFile keeps the changelog of each update, oldest first
+const HistoryFile = "changelog-history.txt"
+
+// DefaultHistoryEntries is how many updates the history keeps unless configured
+const DefaultHistoryEntries = 50
+
+// entryMarker starts the header line of each entry in the history file
+const entryMarker = "==== "
+
+// Entry is one update's changelog in the history file
+type Entry struct {
+	Header string // e.g. "==== 2025-01-04 12:00:00 | version 1.2.5 ===="
+	Body   string // The changelog Build produced for the update
+}
+
+func (e Entry) String() string {
+	return e.Header + "\n" + e.Body
+}
+
+// AppendHistory adds an update's changelog to the history file in baseDir,
+// then drops the oldest entries beyond maxEntries
+func AppendHistory(baseDir, content, version string, maxEntries int, now time.Time) error {
+	entries, err := LoadHistory(baseDir)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, Entry{
+		Header: fmt.Sprintf("%s%s | version %s ====", entryMarker, now.Format("2006-01-02 15:04:05"), version),
+		Body:   strings.TrimRight(content, "\n") + "\n",
+	})
+	if maxEntries > 0 && len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+
+	var b strings.Builder
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(e.String())
+	}
+	if err := paths.WriteFileAtomic(filepath.Join(baseDir, HistoryFile), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", HistoryFile, err)
+	}
+	return nil
+}
+
+// LoadHistory reads the history file in baseDir, oldest entry first. A
+// missing file is an empty history.
+func LoadHistory(baseDir string) ([]Entry, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, HistoryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", HistoryFile, err)
+	}
+
+	var entries []Entry
+	var body strings.Builder
+	flush := func() {
+		if len(entries) > 0 {
+			entries[len(entries)-1].Body = strings.TrimRight(body.String(), "\n") + "\n"
+		}
+		body.Reset()
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, entryMarker) {
+			flush()
+			entries = append(entries, Entry{Header: line})
+			continue
+		}
+		// Anything before the first header isn't part of an entry
+		if len(entries) > 0 {
+			body.WriteString(line + "\n")
+		}
+	}
+	flush()
+	return entries, nil
+}
+
+// Recent returns the last n entries, newest first
+func Recent(entries []Entry, n int) []Entry {
+	if n > len(entries) {
+		n = len(entries)
+	}
+	recent := make([]Entry, 0, n)
+	for i := len(entries) - 1; i >= len(entries)-n; i-- {
+		recent = append(recent, entries[i])
+	}
+	return recent
+}
@@ -0,0 +1,52 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAppendHistory tests that entries accumulate oldest first, survive a
+// reload, and are capped at maxEntries
+func TestAppendHistory(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 4; i++ {
+		content := strings.Repeat("line\n", i) + "\n"
+		if err := AppendHistory(dir, content, fmt.Sprintf("1.2.%d", i), 3, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("AppendHistory() error = %v", err)
+		}
+	}
+
+	entries, err := LoadHistory(dir)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("LoadHistory() = %d entries, want 3 (capped)", len(entries))
+	}
+	if want := "==== 2025-01-04 14:00:00 | version 1.2.2 ===="; entries[0].Header != want {
+		t.Errorf("oldest header = %q, want %q", entries[0].Header, want)
+	}
+	if want := strings.Repeat("line\n", 4); entries[2].Body != want {
+		t.Errorf("newest body = %q, want %q", entries[2].Body, want)
+	}
+
+	recent := Recent(entries, 2)
+	if len(recent) != 2 || !strings.Contains(recent[0].Header, "1.2.4") || !strings.Contains(recent[1].Header, "1.2.3") {
+		t.Errorf("Recent(2) = %v, want 1.2.4 then 1.2.3", recent)
+	}
+	if got := Recent(entries, 10); len(got) != 3 {
+		t.Errorf("Recent(10) = %d entries, want all 3", len(got))
+	}
+}
+
+// TestLoadHistory_Missing tests that a missing history file is empty
+func TestLoadHistory_Missing(t *testing.T) {
+	entries, err := LoadHistory(t.TempDir())
+	if err != nil || len(entries) != 0 {
+		t.Errorf("LoadHistory() = %v, %v; want no entries", entries, err)
+	}
+}
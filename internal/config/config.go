@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// File holds the updater preferences for an installation
+const File = ".updater-config"
+
+// Config is the contents of the config file. Every field is optional; an
+// unset field leaves the built-in default (or the setting remembered by its
+// own file, such as .update-channel) in place.
+//
+//	{
+//	  "channel": "dev",
+//	  "quiet": false,
+//	  "verbose": true,
+//	  "volume": 60,
+//	  "max_rate": 512,
+//	  "proxy_host": "192.168.1.20",
+//	  "proxiani_port": 5000,
+//	  "mudmixer_port": 7788,
+//	  "install_dir": "D:\\Games\\Miriani-Next",
+//	  "changelog_viewer": "console",
+//	  "changelog_history": 50,
+//	  "changelog_commits": "flat"
+//	}
+type Config struct {
+	Channel      string `json:"channel,omitempty"`       // Channel to use when none has been saved
+	Quiet        *bool  `json:"quiet,omitempty"`         // Default for -quiet
+	Verbose      *bool  `json:"verbose,omitempty"`       // Default for -verbose
+	Volume       *int   `json:"volume,omitempty"`        // Sound volume from 0 to 100
+	MaxRate      *int   `json:"max_rate,omitempty"`      // Download cap in KB/s, 0 for unlimited
+	ProxyHost    string `json:"proxy_host,omitempty"`    // Host Proxiani and MUDMixer run on
+	ProxianiPort int    `json:"proxiani_port,omitempty"` // Port Proxiani listens on
+	MUDMixerPort int    `json:"mudmixer_port,omitempty"` // Port MUDMixer listens on
+	InstallDir   string `json:"install_dir,omitempty"`   // Absolute folder to install to when not installed yet
+
+	ChangelogViewer  string `json:"changelog_viewer,omitempty"`  // "console" or "notepad"
+	ChangelogHistory *int   `json:"changelog_history,omitempty"` // Updates kept in the changelog history, 0 to keep none
+	ChangelogCommits string `json:"changelog_commits,omitempty"` // "grouped" (by type, the default) or "flat" (commit order)
+}
+
+// Load reads the config file in the specified directory. A missing file is
+// an empty Config, not an error.
+func Load(baseDir string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(filepath.Join(baseDir, File))
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read %s: %w", File, err)
+	}
+
+	// Unknown keys are rejected so a misspelled setting isn't silently ignored
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid %s: %w", File, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid %s: %w", File, err)
+	}
+	return cfg, nil
+}
+
+func (c Config) validate() error {
+	if c.Volume != nil && (*c.Volume < 0 || *c.Volume > 100) {
+		return fmt.Errorf("volume %d must be between 0 and 100", *c.Volume)
+	}
+	if c.MaxRate != nil && *c.MaxRate < 0 {
+		return fmt.Errorf("max_rate can't be negative")
+	}
+	if c.ChangelogHistory != nil && *c.ChangelogHistory < 0 {
+		return fmt.Errorf("changelog_history can't be negative")
+	}
+	if c.ChangelogCommits != "" && c.ChangelogCommits != "grouped" && c.ChangelogCommits != "flat" {
+		return fmt.Errorf("changelog_commits %q must be grouped or flat", c.ChangelogCommits)
+	}
+	if c.ChangelogViewer != "" && c.ChangelogViewer != "console" && c.ChangelogViewer != "notepad" {
+		return fmt.Errorf("changelog_viewer %q must be console or notepad", c.ChangelogViewer)
+	}
+	if c.InstallDir != "" && !filepath.IsAbs(c.InstallDir) {
+		return fmt.Errorf("install_dir %q must be an absolute path", c.InstallDir)
+	}
+	for key, port := range map[string]int{"proxiani_port": c.ProxianiPort, "mudmixer_port": c.MUDMixerPort} {
+		if port != 0 && (port < 1 || port > 65535) {
+			return fmt.Errorf("%s %d must be between 1 and 65535", key, port)
+		}
+	}
+	return nil
+}
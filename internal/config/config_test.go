@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoad_Missing tests that a missing config file is an empty config
+func TestLoad_Missing(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg != (Config{}) {
+		t.Errorf("Load() = %+v, want empty config", cfg)
+	}
+}
+
+// TestLoad tests parsing and validation of the config file
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+		check   func(t *testing.T, cfg Config)
+	}{
+		{
+			name:    "all fields",
+			content: `{"channel": "dev", "quiet": true, "verbose": false, "volume": 40, "max_rate": 0, "proxy_host": "10.0.0.2", "proxiani_port": 5000, "mudmixer_port": 7788}`,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Channel != "dev" || cfg.ProxyHost != "10.0.0.2" || cfg.ProxianiPort != 5000 || cfg.MUDMixerPort != 7788 {
+					t.Errorf("unexpected config %+v", cfg)
+				}
+				if cfg.Quiet == nil || !*cfg.Quiet || cfg.Verbose == nil || *cfg.Verbose {
+					t.Errorf("quiet/verbose not loaded: %+v", cfg)
+				}
+				if cfg.Volume == nil || *cfg.Volume != 40 {
+					t.Errorf("volume not loaded: %+v", cfg)
+				}
+				// An explicit zero is kept apart from an unset rate
+				if cfg.MaxRate == nil || *cfg.MaxRate != 0 {
+					t.Errorf("max_rate not loaded: %+v", cfg)
+				}
+			},
+		},
+		{
+			name:    "unset fields stay nil",
+			content: `{"channel": "stable"}`,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Quiet != nil || cfg.Verbose != nil || cfg.Volume != nil || cfg.MaxRate != nil {
+					t.Errorf("unset fields should be nil: %+v", cfg)
+				}
+			},
+		},
+		{name: "unknown key", content: `{"volum": 40}`, wantErr: "unknown field"},
+		{name: "volume out of range", content: `{"volume": 150}`, wantErr: "volume 150"},
+		{name: "negative rate", content: `{"max_rate": -1}`, wantErr: "max_rate"},
+		{name: "bad port", content: `{"mudmixer_port": 70000}`, wantErr: "mudmixer_port"},
+		{name: "negative changelog history", content: `{"changelog_history": -5}`, wantErr: "changelog_history"},
+		{name: "bad changelog commits", content: `{"changelog_commits": "sorted"}`, wantErr: "changelog_commits"},
+		{name: "bad changelog viewer", content: `{"changelog_viewer": "less"}`, wantErr: "changelog_viewer"},
+		{name: "relative install dir", content: `{"install_dir": "Games/Miriani-Next"}`, wantErr: "absolute path"},
+		{name: "not json", content: `channel=dev`, wantErr: "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, File), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
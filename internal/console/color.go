@@ -0,0 +1,106 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const ENABLE_VIRTUAL_TERMINAL_PROCESSING = 0x0004
+
+var (
+	getConsoleMode = kernel32.NewProc("GetConsoleMode")
+	setConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+// ANSI escape sequences for each kind of message
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+var (
+	colorStdout bool
+	colorStderr bool
+)
+
+// EnableColor turns on ANSI escape handling for stdout and stderr. Colors are
+// only used for a handle that is a real console that accepts virtual terminal
+// processing, so output redirected to a file or pipe stays plain. Setting the
+// NO_COLOR environment variable to anything keeps all output plain.
+func EnableColor() {
+	if os.Getenv("NO_COLOR") != "" {
+		DisableColor()
+		return
+	}
+	colorStdout = enableVirtualTerminal(os.Stdout)
+	colorStderr = enableVirtualTerminal(os.Stderr)
+}
+
+// DisableColor turns colored output off again
+func DisableColor() {
+	colorStdout = false
+	colorStderr = false
+}
+
+// enableVirtualTerminal reports whether f is a console that now interprets
+// ANSI escapes. GetConsoleMode fails for files and pipes.
+func enableVirtualTerminal(f *os.File) bool {
+	handle := f.Fd()
+	if handle == 0 || handle == uintptr(syscall.InvalidHandle) {
+		return false
+	}
+
+	var mode uint32
+	if r, _, _ := getConsoleMode.Call(handle, uintptr(unsafe.Pointer(&mode))); r == 0 {
+		return false
+	}
+	if mode&ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	r, _, _ := setConsoleMode.Call(handle, uintptr(mode|ENABLE_VIRTUAL_TERMINAL_PROCESSING))
+	return r != 0
+}
+
+// Error prints a message in red to stderr
+func Error(format string, args ...interface{}) {
+	printColored(os.Stderr, colorStderr, colorRed, format, args...)
+}
+
+// Warn prints a message in yellow
+func Warn(format string, args ...interface{}) {
+	printColored(os.Stdout, colorStdout, colorYellow, format, args...)
+}
+
+// Success prints a message in green
+func Success(format string, args ...interface{}) {
+	printColored(os.Stdout, colorStdout, colorGreen, format, args...)
+}
+
+// Info prints a message in cyan
+func Info(format string, args ...interface{}) {
+	printColored(os.Stdout, colorStdout, colorCyan, format, args...)
+}
+
+// printColored writes one line, wrapped in color when w is a color console.
+// Leading newlines stay outside the escapes so blank lines aren't styled.
+func printColored(w io.Writer, enabled bool, color, format string, args ...interface{}) {
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	if !enabled {
+		fmt.Fprintln(w, message)
+		return
+	}
+	i := 0
+	for i < len(message) && message[i] == '\n' {
+		i++
+	}
+	fmt.Fprintln(w, message[:i]+color+message[i:]+colorReset)
+}
@@ -16,6 +16,8 @@ var (
 	getStdHandle   = kernel32.NewProc("GetStdHandle")
 	showWindowProc = user32.NewProc("ShowWindow")
 	setFocusProc   = user32.NewProc("SetFocus")
+
+	getConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
 )
 
 const (
@@ -128,6 +130,29 @@ func SetTitle(title string) error {
 	return nil
 }
 
+// screenBufferInfo mirrors CONSOLE_SCREEN_BUFFER_INFO
+type screenBufferInfo struct {
+	size              struct{ x, y int16 }
+	cursorPosition    struct{ x, y int16 }
+	attributes        uint16
+	window            struct{ left, top, right, bottom int16 }
+	maximumWindowSize struct{ x, y int16 }
+}
+
+// Size returns the visible width and height of the console window in
+// characters, or 0, 0 if output isn't going to a console
+func Size() (width, height int) {
+	handle, _, _ := getStdHandle.Call(uintptr(STD_OUTPUT_HANDLE))
+	if handle == 0 || handle == uintptr(syscall.InvalidHandle) {
+		return 0, 0
+	}
+	var info screenBufferInfo
+	if ret, _, _ := getConsoleScreenBufferInfo.Call(handle, uintptr(unsafe.Pointer(&info))); ret == 0 {
+		return 0, 0
+	}
+	return int(info.window.right-info.window.left) + 1, int(info.window.bottom-info.window.top) + 1
+}
+
 // GetWindow returns the console window handle (HWND)
 func GetWindow() uintptr {
 	lib, err := syscall.LoadLibrary("kernel32.dll")
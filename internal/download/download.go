@@ -1,13 +1,26 @@
 package download
 
 import (
+	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cavaliergopher/grab/v3"
+
+	"github.com/distantorigin/next-launcher/internal/manifest"
 )
 
 var client = grab.NewClient()
@@ -15,16 +28,35 @@ var client = grab.NewClient()
 // ProgressCallback is called during download with progress info
 type ProgressCallback func(bytesComplete, totalBytes int64, percentage int)
 
+// ErrCancelled is returned when a download is stopped by its context. The
+// partial file is removed, so it is never mistaken for a complete one.
+var ErrCancelled = errors.New("download cancelled")
+
+// Cancelled reports whether ctx stopped a download to targetPath and, if so,
+// removes the partial file and returns ErrCancelled
+func Cancelled(ctx context.Context, targetPath string) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	_ = os.Remove(targetPath)
+	return fmt.Errorf("%w: %w", ErrCancelled, ctx.Err())
+}
+
 // File downloads a file from URL to the target path
-func File(url, targetPath string) error {
+func File(ctx context.Context, url, targetPath string) error {
 	req, err := grab.NewRequest(targetPath, url)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req = req.WithContext(ctx)
 	req.NoResume = true // Always overwrite, never resume
+	LimitRequest(req)
 
 	resp := client.Do(req)
 	if err := resp.Err(); err != nil {
+		if cancelErr := Cancelled(ctx, targetPath); cancelErr != nil {
+			return cancelErr
+		}
 		return fmt.Errorf("download failed: %w", err)
 	}
 
@@ -32,12 +64,14 @@ func File(url, targetPath string) error {
 }
 
 // FileWithProgress downloads a file with progress callback
-func FileWithProgress(url, targetPath string, callback ProgressCallback) error {
+func FileWithProgress(ctx context.Context, url, targetPath string, callback ProgressCallback) error {
 	req, err := grab.NewRequest(targetPath, url)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req = req.WithContext(ctx)
 	req.NoResume = true // Always overwrite, never resume
+	LimitRequest(req)
 
 	resp := client.Do(req)
 
@@ -69,14 +103,202 @@ func FileWithProgress(url, targetPath string, callback ProgressCallback) error {
 done:
 
 	if err := resp.Err(); err != nil {
+		if cancelErr := Cancelled(ctx, targetPath); cancelErr != nil {
+			return cancelErr
+		}
 		return fmt.Errorf("download failed: %w", err)
 	}
 
 	return nil
 }
 
+// ErrSizeMismatch indicates the downloaded file isn't the size the server
+// advertised or the manifest expected
+var ErrSizeMismatch = errors.New("downloaded size mismatch")
+
+// sizeAttempts is how many times FileVerified tries before giving up on a size mismatch
+const sizeAttempts = 3
+
+// FileVerified downloads a file and checks its size on disk against the
+// server's Content-Length and, when expectedSize > 0, the expected size.
+// Size mismatches (including truncated bodies) are retried. Returns the bytes written.
+// If callback is set it receives the bytes written by the current attempt as
+// the download runs, so a retry starts again from zero. When the server sends
+// Last-Modified, the file's modification time is set to it.
+func FileVerified(ctx context.Context, url, targetPath string, expectedSize int64, callback ProgressCallback) (int64, error) {
+	var lastErr error
+	for attempt := 0; attempt < sizeAttempts; attempt++ {
+		req, err := grab.NewRequest(targetPath, url)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create request: %w", err)
+		}
+		req = req.WithContext(ctx)
+		req.NoResume = true // Always overwrite, never resume
+		LimitRequest(req)
+
+		resp := client.Do(req)
+		if callback != nil {
+			reportProgress(resp, callback)
+		}
+		if err := resp.Err(); err != nil {
+			if cancelErr := Cancelled(ctx, targetPath); cancelErr != nil {
+				return 0, cancelErr
+			}
+			// A body shorter than its Content-Length surfaces as one of these
+			if !errors.Is(err, grab.ErrBadLength) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				return 0, fmt.Errorf("download failed: %w", err)
+			}
+			lastErr = fmt.Errorf("%w: %v", ErrSizeMismatch, err)
+			continue
+		}
+
+		if err := CheckSize(targetPath, resp.Size(), expectedSize); err != nil {
+			lastErr = err
+			continue
+		}
+		return resp.BytesComplete(), nil
+	}
+	return 0, lastErr
+}
+
+// RetryAttempts is how many times FileWithRetry tries a download that keeps
+// failing with transient errors
+const RetryAttempts = 3
+
+// retryBackoff is the wait before the first retry; it doubles after each one.
+// A variable so tests don't have to sleep.
+var retryBackoff = time.Second
+
+// RetryCallback is told about each retry before its wait begins: the attempt
+// about to be made, how long until it starts, and the error that caused it
+type RetryCallback func(attempt int, wait time.Duration, err error)
+
+// FileWithRetry is FileVerified, re-issuing the download when it fails with an
+// error worth retrying (see Retryable). Waits double between attempts, with
+// jitter so parallel downloads that failed together don't retry in lockstep.
+// The last attempt's error is returned once RetryAttempts are used up.
+func FileWithRetry(ctx context.Context, url, targetPath string, expectedSize int64, callback ProgressCallback, onRetry RetryCallback) (int64, error) {
+	wait := retryBackoff
+	for attempt := 1; ; attempt++ {
+		n, err := FileVerified(ctx, url, targetPath, expectedSize, callback)
+		if err == nil || attempt == RetryAttempts || !Retryable(err) {
+			return n, err
+		}
+
+		// Wait somewhere between half and all of the current step
+		jittered := wait/2 + rand.N(wait/2+1)
+		if onRetry != nil {
+			onRetry(attempt+1, jittered, err)
+		}
+		select {
+		case <-time.After(jittered):
+		case <-ctx.Done():
+			return 0, fmt.Errorf("%w: %w", ErrCancelled, ctx.Err())
+		}
+		wait *= 2
+	}
+}
+
+// ErrHashMismatch is returned when a downloaded file's content still doesn't
+// match the manifest's git blob SHA after it was downloaded again
+var ErrHashMismatch = errors.New("content does not match the manifest")
+
+// FileWithHash is FileWithRetry followed by a check of the content against
+// hash, the git blob SHA the manifest records (an empty hash skips it). A file
+// that doesn't match is deleted and downloaded once more; if that copy is
+// wrong too it is deleted and ErrHashMismatch returned. onRetry is told about
+// the second download with ErrHashMismatch and no wait. The byte count covers
+// every attempt.
+func FileWithHash(ctx context.Context, url, targetPath string, expectedSize int64, hash string, callback ProgressCallback, onRetry RetryCallback) (int64, error) {
+	var total int64
+	for attempt := 1; ; attempt++ {
+		n, err := FileWithRetry(ctx, url, targetPath, expectedSize, callback, onRetry)
+		total += n
+		if err != nil || hash == "" {
+			return total, err
+		}
+
+		got, err := manifest.GitBlobSHAFile(targetPath)
+		if err == nil && got == hash {
+			return total, nil
+		}
+		_ = os.Remove(targetPath)
+		if err != nil {
+			return total, fmt.Errorf("failed to verify: %w", err)
+		}
+		if attempt == 2 {
+			return total, fmt.Errorf("%w (got %s, want %s)", ErrHashMismatch, got, hash)
+		}
+		if onRetry != nil {
+			onRetry(attempt+1, 0, ErrHashMismatch)
+		}
+	}
+}
+
+// Retryable reports whether a download error is likely transient: a network
+// failure, a dropped connection, or an HTTP 429 or 5xx response. Cancellation,
+// other HTTP errors and size mismatches (already retried by FileVerified) are not.
+func Retryable(err error) bool {
+	if errors.Is(err, ErrCancelled) || errors.Is(err, context.Canceled) || errors.Is(err, ErrSizeMismatch) {
+		return false
+	}
+	var status grab.StatusCodeError
+	if errors.As(err, &status) {
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// reportProgress calls callback with resp's progress every progressInterval
+// until the download finishes, then once more with the final count
+func reportProgress(resp *grab.Response, callback ProgressCallback) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	report := func() {
+		var percentage int
+		if resp.Size() > 0 {
+			percentage = int(resp.Progress() * 100)
+		}
+		callback(resp.BytesComplete(), resp.Size(), percentage)
+	}
+	for {
+		select {
+		case <-ticker.C:
+			report()
+		case <-resp.Done:
+			report()
+			return
+		}
+	}
+}
+
+// progressInterval is how often reportProgress samples a download
+const progressInterval = 100 * time.Millisecond
+
+// CheckSize verifies the file at path is advertised bytes long (if advertised > 0)
+// and expected bytes long (if expected > 0)
+func CheckSize(path string, advertised, expected int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat download: %w", err)
+	}
+
+	if advertised > 0 && info.Size() != advertised {
+		return fmt.Errorf("%w: got %d bytes, server advertised %d", ErrSizeMismatch, info.Size(), advertised)
+	}
+	if expected > 0 && info.Size() != expected {
+		return fmt.Errorf("%w: got %d bytes, expected %d", ErrSizeMismatch, info.Size(), expected)
+	}
+	return nil
+}
+
 // ToTemp downloads a file to a temporary location and returns the path
-func ToTemp(url, prefix string) (string, error) {
+func ToTemp(ctx context.Context, url, prefix string) (string, error) {
 	tempFile, err := os.CreateTemp("", prefix+"*.tmp")
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp file: %w", err)
@@ -86,7 +308,7 @@ func ToTemp(url, prefix string) (string, error) {
 		return "", fmt.Errorf("failed to close temp file: %w", err)
 	}
 
-	if err := File(url, tempPath); err != nil {
+	if err := File(ctx, url, tempPath); err != nil {
 		_ = os.Remove(tempPath) // Best effort cleanup
 		return "", err
 	}
@@ -95,7 +317,7 @@ func ToTemp(url, prefix string) (string, error) {
 }
 
 // ToTempWithProgress downloads with progress to a temp file
-func ToTempWithProgress(url, prefix string, callback ProgressCallback) (string, error) {
+func ToTempWithProgress(ctx context.Context, url, prefix string, callback ProgressCallback) (string, error) {
 	tempFile, err := os.CreateTemp("", prefix+"*.tmp")
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp file: %w", err)
@@ -105,7 +327,7 @@ func ToTempWithProgress(url, prefix string, callback ProgressCallback) (string,
 		return "", fmt.Errorf("failed to close temp file: %w", err)
 	}
 
-	if err := FileWithProgress(url, tempPath, callback); err != nil {
+	if err := FileWithProgress(ctx, url, tempPath, callback); err != nil {
 		_ = os.Remove(tempPath) // Best effort cleanup
 		return "", err
 	}
@@ -113,6 +335,41 @@ func ToTempWithProgress(url, prefix string, callback ProgressCallback) (string,
 	return tempPath, nil
 }
 
+// ProbeResult describes one connectivity probe
+type ProbeResult struct {
+	URL     string
+	Status  int // HTTP status code, 0 if no response was received
+	Latency time.Duration
+	Err     error
+}
+
+// Probe sends a HEAD request to url and reports how long the server took to
+// answer. Any HTTP response below 500 counts as reachable: a 404 still proves
+// DNS, TLS and any proxy in between are working. Servers that reject HEAD are
+// retried with GET.
+func Probe(c *http.Client, url string) ProbeResult {
+	result := ProbeResult{URL: url}
+	start := time.Now()
+
+	resp, err := c.Head(url)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		resp.Body.Close()
+		resp, err = c.Get(url)
+	}
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	if resp.StatusCode >= 500 {
+		result.Err = fmt.Errorf("server returned %s", resp.Status)
+	}
+	return result
+}
+
 // ValidatePath ensures a path doesn't escape the base directory (path traversal protection)
 func ValidatePath(basePath, targetPath string) (string, error) {
 	absBase, err := filepath.Abs(basePath)
@@ -131,3 +388,134 @@ func ValidatePath(basePath, targetPath string) (string, error) {
 
 	return absTarget, nil
 }
+
+// ValidateURL ensures a download URL uses HTTPS and points at one of the allowed hosts.
+// This guards against tampered manifests redirecting downloads to arbitrary servers.
+func ValidateURL(rawURL string, allowedHosts []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid download URL: %w", err)
+	}
+
+	if !strings.EqualFold(u.Scheme, "https") {
+		return fmt.Errorf("refusing non-HTTPS download URL: %s", rawURL)
+	}
+
+	host := u.Hostname()
+	for _, allowed := range allowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("refusing download from untrusted host %q", host)
+}
+
+// ExtractFile writes one archive entry to absPath, whose folder must exist,
+// and gives it the entry's modification time so the install keeps the times
+// the files had in the repository rather than the time of the update
+func ExtractFile(f *zip.File, absPath string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file in archive %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", absPath, err)
+	}
+
+	_, err = io.Copy(out, rc)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", absPath, err)
+	}
+
+	if !f.Modified.IsZero() {
+		if err := os.Chtimes(absPath, f.Modified, f.Modified); err != nil {
+			return fmt.Errorf("failed to set modification time of %s: %w", absPath, err)
+		}
+	}
+	return nil
+}
+
+// ArchiveEmptyDirs returns the directory entries in r that have nothing else
+// under them, relative to stripPrefix (the "repo-branch/" folder GitHub
+// archives wrap everything in) and without a trailing slash. Directories that
+// hold files are created on the way to extracting those files, so only these
+// need creating explicitly. The result is sorted.
+func ArchiveEmptyDirs(r *zip.Reader, stripPrefix string) []string {
+	var dirs []string
+	hasChildren := make(map[string]bool)
+	for _, f := range r.File {
+		name := strings.TrimSuffix(strings.TrimPrefix(f.Name, stripPrefix), "/")
+		if name == "" {
+			continue
+		}
+		if f.FileInfo().IsDir() || strings.HasSuffix(f.Name, "/") {
+			dirs = append(dirs, name)
+		}
+		for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+			hasChildren[parent] = true
+		}
+	}
+
+	var empty []string
+	for _, dir := range dirs {
+		if !hasChildren[dir] {
+			empty = append(empty, dir)
+		}
+	}
+	sort.Strings(empty)
+	return empty
+}
+
+// ValidateArchive checks that the file at path is a complete zip archive by
+// reading every entry, which verifies each one's CRC. A truncated download,
+// or a resumed one stitched onto the wrong content, fails here.
+func ValidateArchive(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// RemoveStale deletes files matching pattern (a filepath.Glob pattern) that
+// were last modified more than maxAge ago, returning how many it removed
+func RemoveStale(pattern string, maxAge time.Duration) int {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() || time.Since(info.ModTime()) <= maxAge {
+			continue
+		}
+		if os.Remove(match) == nil {
+			removed++
+		}
+	}
+	return removed
+}
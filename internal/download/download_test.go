@@ -1,10 +1,23 @@
 package download
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/cavaliergopher/grab/v3"
 )
 
 // TestValidatePath_PreventTraversal tests path traversal protection (SECURITY CRITICAL)
@@ -142,6 +155,66 @@ func TestValidatePath_WithTempDirs(t *testing.T) {
 	}
 }
 
+// TestValidateURL tests the download host allowlist (SECURITY CRITICAL)
+func TestValidateURL(t *testing.T) {
+	allowed := []string{"raw.githubusercontent.com", "github.com"}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "raw content host",
+			url:     "https://raw.githubusercontent.com/owner/repo/main/file.txt",
+			wantErr: false,
+		},
+		{
+			name:    "host is case-insensitive",
+			url:     "https://RAW.GitHubUserContent.com/owner/repo/main/file.txt",
+			wantErr: false,
+		},
+		{
+			name:    "off-host URL",
+			url:     "https://evil.example.com/file.txt",
+			wantErr: true,
+		},
+		{
+			name:    "allowed host as subdomain prefix",
+			url:     "https://raw.githubusercontent.com.evil.example.com/file.txt",
+			wantErr: true,
+		},
+		{
+			name:    "userinfo disguising real host",
+			url:     "https://raw.githubusercontent.com@evil.example.com/file.txt",
+			wantErr: true,
+		},
+		{
+			name:    "plain HTTP",
+			url:     "http://raw.githubusercontent.com/owner/repo/main/file.txt",
+			wantErr: true,
+		},
+		{
+			name:    "empty URL",
+			url:     "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateURL(tt.url, allowed)
+
+			if tt.wantErr && err == nil {
+				t.Errorf("ValidateURL(%q) expected error, got nil", tt.url)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ValidateURL(%q) unexpected error: %v", tt.url, err)
+			}
+		})
+	}
+}
+
 // TestToTemp tests temporary file download
 func TestToTemp(t *testing.T) {
 	// This test would require a mock HTTP server
@@ -163,5 +236,554 @@ func TestFile_CleanupOnError(t *testing.T) {
 	t.Skip("requires HTTP mock server - see testing/mocks.go")
 }
 
+// lyingHandler advertises a Content-Length of 100 but only sends 50 bytes
+// for the first `lies` requests, then serves the full body
+func lyingHandler(t *testing.T, lies int32, requests *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		body := strings.Repeat("x", 100)
+		if n > lies {
+			w.Header().Set("Content-Length", "100")
+			fmt.Fprint(w, body)
+			return
+		}
+
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("response writer does not support hijacking")
+			return
+		}
+		conn, buf, err := hj.Hijack()
+		if err != nil {
+			t.Errorf("Hijack() error = %v", err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\nConnection: close\r\n\r\n%s", body[:50])
+		buf.Flush()
+	}
+}
+
+// TestFileVerified_ContentLengthMismatch tests that a truncated body is caught and retried
+func TestFileVerified_ContentLengthMismatch(t *testing.T) {
+	t.Run("always truncated", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(lyingHandler(t, 100, &requests))
+		defer server.Close()
+
+		target := filepath.Join(t.TempDir(), "file.txt")
+		_, err := FileVerified(context.Background(), server.URL+"/file.txt", target, 0, nil)
+		if !errors.Is(err, ErrSizeMismatch) {
+			t.Fatalf("FileVerified() error = %v, want ErrSizeMismatch", err)
+		}
+		if got := requests.Load(); got != sizeAttempts {
+			t.Errorf("server saw %d requests, want %d", got, sizeAttempts)
+		}
+	})
+
+	t.Run("recovers on retry", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(lyingHandler(t, 1, &requests))
+		defer server.Close()
+
+		target := filepath.Join(t.TempDir(), "file.txt")
+		n, err := FileVerified(context.Background(), server.URL+"/file.txt", target, 0, nil)
+		if err != nil {
+			t.Fatalf("FileVerified() error = %v", err)
+		}
+		if n != 100 {
+			t.Errorf("FileVerified() bytes = %d, want 100", n)
+		}
+	})
+
+	t.Run("expected size mismatch", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(lyingHandler(t, 0, &requests))
+		defer server.Close()
+
+		target := filepath.Join(t.TempDir(), "file.txt")
+		_, err := FileVerified(context.Background(), server.URL+"/file.txt", target, 200, nil)
+		if !errors.Is(err, ErrSizeMismatch) {
+			t.Fatalf("FileVerified() error = %v, want ErrSizeMismatch", err)
+		}
+	})
+}
+
 // Note: Add more tests for File(), FileWithProgress(), ToTemp(), ToTempWithProgress()
 // once HTTP mock server infrastructure is in place
+
+func TestProbe(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch r.URL.Path {
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path    string
+		status  int
+		wantErr bool
+		methods []string
+	}{
+		{"/ok", http.StatusOK, false, []string{"HEAD"}},
+		{"/no-head", http.StatusOK, false, []string{"HEAD", "GET"}},
+		{"/missing", http.StatusNotFound, false, []string{"HEAD"}},
+		{"/broken", http.StatusBadGateway, true, []string{"HEAD"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			methods = nil
+			result := Probe(server.Client(), server.URL+tt.path)
+			if result.Status != tt.status {
+				t.Errorf("Status = %d, want %d", result.Status, tt.status)
+			}
+			if (result.Err != nil) != tt.wantErr {
+				t.Errorf("Err = %v, wantErr %v", result.Err, tt.wantErr)
+			}
+			if strings.Join(methods, ",") != strings.Join(tt.methods, ",") {
+				t.Errorf("methods = %v, want %v", methods, tt.methods)
+			}
+		})
+	}
+
+	// A closed server can't be reached at all
+	url := server.URL
+	server.Close()
+	if result := Probe(http.DefaultClient, url); result.Err == nil || result.Status != 0 {
+		t.Errorf("Probe on closed server = %+v, want error with no status", result)
+	}
+}
+
+func TestArchiveEmptyDirs(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{
+		"repo-main/",
+		"repo-main/plugins/",
+		"repo-main/plugins/a.xml",
+		"repo-main/plugins/scaffold/",
+		"repo-main/empty/",
+		"repo-main/nested/",
+		"repo-main/nested/inner/",
+	} {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+		if !strings.HasSuffix(name, "/") {
+			f.Write([]byte("content"))
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	got := ArchiveEmptyDirs(r, "repo-main/")
+	want := []string{"empty", "nested/inner", "plugins/scaffold"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ArchiveEmptyDirs() = %v, want %v", got, want)
+	}
+}
+
+// TestFileVerified_Cancelled tests that cancelling mid-download reports
+// ErrCancelled and leaves no partial file behind
+func TestFileVerified_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		fmt.Fprint(w, strings.Repeat("x", 50))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	target := filepath.Join(t.TempDir(), "file.txt")
+	go func() {
+		// Cancel once the first half has reached the disk
+		for {
+			if info, err := os.Stat(target); err == nil && info.Size() > 0 {
+				cancel()
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}()
+
+	_, err := FileVerified(ctx, server.URL+"/file.txt", target, 0, nil)
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("FileVerified() error = %v, want ErrCancelled", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FileVerified() error = %v, should wrap context.Canceled", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("partial file should be removed after cancelling")
+	}
+}
+
+// TestFileVerified_Progress tests that the callback ends on the full size
+func TestFileVerified_Progress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		fmt.Fprint(w, strings.Repeat("x", 100))
+	}))
+	defer server.Close()
+
+	var last, total int64
+	calls := 0
+	callback := func(bytesComplete, totalBytes int64, percentage int) {
+		calls++
+		last, total = bytesComplete, totalBytes
+	}
+
+	target := filepath.Join(t.TempDir(), "file.txt")
+	if _, err := FileVerified(context.Background(), server.URL+"/file.txt", target, 100, callback); err != nil {
+		t.Fatalf("FileVerified() error = %v", err)
+	}
+	if calls == 0 {
+		t.Fatal("callback was never called")
+	}
+	if last != 100 || total != 100 {
+		t.Errorf("last progress = %d of %d, want 100 of 100", last, total)
+	}
+}
+
+// TestFileWithRetry tests that transient HTTP errors are retried up to
+// RetryAttempts times and permanent ones are not
+func TestFileWithRetry(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	tests := []struct {
+		name         string
+		failures     int32 // Requests answered with status before succeeding
+		status       int
+		wantRequests int32
+		wantErr      bool
+	}{
+		{name: "recovers from 503", failures: 2, status: http.StatusServiceUnavailable, wantRequests: 3},
+		{name: "recovers from 429", failures: 1, status: http.StatusTooManyRequests, wantRequests: 2},
+		{name: "gives up after RetryAttempts", failures: 100, status: http.StatusBadGateway, wantRequests: RetryAttempts, wantErr: true},
+		{name: "404 is not retried", failures: 100, status: http.StatusNotFound, wantRequests: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				fmt.Fprint(w, "content")
+			}))
+			defer server.Close()
+
+			retries := 0
+			onRetry := func(attempt int, wait time.Duration, err error) { retries++ }
+			target := filepath.Join(t.TempDir(), "file.txt")
+			_, err := FileWithRetry(context.Background(), server.URL+"/file.txt", target, 0, nil, onRetry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FileWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if retries != int(tt.wantRequests)-1 {
+				t.Errorf("onRetry called %d times, want %d", retries, tt.wantRequests-1)
+			}
+		})
+	}
+}
+
+// TestFileWithHash tests that a download whose content doesn't match the
+// manifest hash is deleted and fetched once more before giving up
+func TestFileWithHash(t *testing.T) {
+	want := "ce013625030ba8dba906f756967f9e9ca394464a" // "hello\n"
+
+	tests := []struct {
+		name         string
+		corrupt      int32 // Requests answered with the wrong content first
+		wantRequests int32
+		wantErr      bool
+	}{
+		{name: "matches first time", corrupt: 0, wantRequests: 1},
+		{name: "corrupt first response is downloaded again", corrupt: 1, wantRequests: 2},
+		{name: "still corrupt gives up", corrupt: 100, wantRequests: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tt.corrupt {
+					fmt.Fprint(w, "hellO\n")
+					return
+				}
+				fmt.Fprint(w, "hello\n")
+			}))
+			defer server.Close()
+
+			var mismatches int
+			onRetry := func(attempt int, wait time.Duration, err error) {
+				if errors.Is(err, ErrHashMismatch) {
+					mismatches++
+				}
+			}
+			target := filepath.Join(t.TempDir(), "file.txt")
+			n, err := FileWithHash(context.Background(), server.URL+"/file.txt", target, 0, want, nil, onRetry)
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if n != int64(tt.wantRequests)*6 {
+				t.Errorf("FileWithHash() bytes = %d, want %d", n, tt.wantRequests*6)
+			}
+			if mismatches != int(tt.wantRequests)-1 {
+				t.Errorf("onRetry told of %d mismatches, want %d", mismatches, tt.wantRequests-1)
+			}
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrHashMismatch) {
+					t.Fatalf("FileWithHash() error = %v, want ErrHashMismatch", err)
+				}
+				if _, statErr := os.Stat(target); !os.IsNotExist(statErr) {
+					t.Error("a file that doesn't match should be deleted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FileWithHash() error = %v", err)
+			}
+			if data, _ := os.ReadFile(target); string(data) != "hello\n" {
+				t.Errorf("file content = %q, want the good copy", data)
+			}
+		})
+	}
+}
+
+// TestRetryable tests which download errors are worth another attempt
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("download failed: %w", grab.StatusCodeError(500)), true},
+		{fmt.Errorf("download failed: %w", grab.StatusCodeError(429)), true},
+		{fmt.Errorf("download failed: %w", grab.StatusCodeError(404)), false},
+		{fmt.Errorf("download failed: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), true},
+		{fmt.Errorf("download failed: %w", io.ErrUnexpectedEOF), true},
+		{fmt.Errorf("%w: %w", ErrCancelled, context.Canceled), false},
+		{ErrSizeMismatch, false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestFileVerified_LastModified tests that a downloaded file takes the
+// server's Last-Modified time
+func TestFileVerified_LastModified(t *testing.T) {
+	modified := time.Date(2024, 3, 9, 14, 30, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		fmt.Fprint(w, "content")
+	}))
+	defer server.Close()
+
+	target := filepath.Join(t.TempDir(), "file.txt")
+	if _, err := FileVerified(context.Background(), server.URL+"/file.txt", target, 0, nil); err != nil {
+		t.Fatalf("FileVerified() error = %v", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("mtime = %v, want %v", info.ModTime().UTC(), modified)
+	}
+}
+
+// TestExtractFile tests that an extracted entry keeps its content and the
+// modification time recorded in the archive
+func TestExtractFile(t *testing.T) {
+	modified := time.Date(2023, 11, 5, 8, 15, 30, 0, time.UTC)
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.CreateHeader(&zip.FileHeader{Name: "repo-main/scripts/a.lua", Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	f.Write([]byte("print('hi')"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	target := filepath.Join(t.TempDir(), "a.lua")
+	if err := ExtractFile(r.File[0], target); err != nil {
+		t.Fatalf("ExtractFile() error = %v", err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "print('hi')" {
+		t.Fatalf("content = %q (%v), want the archived content", data, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("mtime = %v, want %v", info.ModTime().UTC(), modified)
+	}
+}
+
+// TestRateLimiter tests that the limiter holds transfers to its rate once
+// the initial one-second burst is spent, and gives up when cancelled
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(1000)
+	ctx := context.Background()
+
+	// The burst is free
+	start := time.Now()
+	if err := l.WaitN(ctx, 1000); err != nil {
+		t.Fatalf("WaitN() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("WaitN() within the burst took %v", elapsed)
+	}
+
+	// The next 200 bytes have to wait about 200ms
+	start = time.Now()
+	if err := l.WaitN(ctx, 200); err != nil {
+		t.Fatalf("WaitN() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("WaitN() past the burst took %v, want about 200ms", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.WaitN(cancelled, 5000); !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitN() on a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+// TestLoadMaxRate tests reading the saved download cap
+func TestLoadMaxRate(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadMaxRate(dir); !os.IsNotExist(err) {
+		t.Errorf("LoadMaxRate() without a file = %v, want not-exist", err)
+	}
+
+	if err := SaveMaxRate(dir, 512); err != nil {
+		t.Fatalf("SaveMaxRate() error = %v", err)
+	}
+	if got, err := LoadMaxRate(dir); err != nil || got != 512 {
+		t.Errorf("LoadMaxRate() = %d, %v, want 512", got, err)
+	}
+
+	for _, bad := range []string{"fast", "-5"} {
+		os.WriteFile(filepath.Join(dir, MaxRateFile), []byte(bad), 0644)
+		if _, err := LoadMaxRate(dir); err == nil {
+			t.Errorf("LoadMaxRate(%q) should fail", bad)
+		}
+	}
+}
+
+// TestValidateArchive tests that complete archives pass and truncated or
+// corrupted ones don't
+func TestValidateArchive(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"repo-main/", "repo-main/a.lua", "repo-main/b.lua"} {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+		if !strings.HasSuffix(name, "/") {
+			f.Write([]byte(strings.Repeat("content of "+name, 50)))
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	data := buf.Bytes()
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.zip")
+	os.WriteFile(valid, data, 0644)
+	if err := ValidateArchive(valid); err != nil {
+		t.Errorf("ValidateArchive(valid) error = %v", err)
+	}
+
+	truncated := filepath.Join(dir, "truncated.zip")
+	os.WriteFile(truncated, data[:len(data)/2], 0644)
+	if err := ValidateArchive(truncated); err == nil {
+		t.Error("ValidateArchive(truncated) should fail")
+	}
+
+	// Flip a byte inside the first file's compressed data, just past its
+	// local header
+	corrupt := append([]byte(nil), data...)
+	corrupt[bytes.Index(data, []byte("repo-main/a.lua"))+len("repo-main/a.lua")+2] ^= 0xff
+	corruptPath := filepath.Join(dir, "corrupt.zip")
+	os.WriteFile(corruptPath, corrupt, 0644)
+	if err := ValidateArchive(corruptPath); err == nil {
+		t.Error("ValidateArchive(corrupt) should fail")
+	}
+}
+
+// TestRemoveStale tests that only matching files older than maxAge are removed
+func TestRemoveStale(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "miriani-update-old.zip")
+	fresh := filepath.Join(dir, "miriani-update-fresh.zip")
+	other := filepath.Join(dir, "other.zip")
+	for _, p := range []string{old, fresh, other} {
+		os.WriteFile(p, []byte("partial"), 0644)
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	os.Chtimes(old, lastWeek, lastWeek)
+	os.Chtimes(other, lastWeek, lastWeek)
+
+	if n := RemoveStale(filepath.Join(dir, "miriani-update-*.zip"), 24*time.Hour); n != 1 {
+		t.Errorf("RemoveStale() removed %d files, want 1", n)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("stale file should be removed")
+	}
+	for _, p := range []string{fresh, other} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(p), err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cavaliergopher/grab/v3"
+)
+
+// MaxRateFile stores the -max-rate setting (KB/s) for an installation
+const MaxRateFile = ".update-max-rate"
+
+// RateLimiter is a token bucket shared by every download, so the cap applies
+// to their combined speed rather than to each one. It satisfies
+// grab.RateLimiter.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	tokens float64 // may go negative: a large read is paid for by waiting
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing bytesPerSecond, with up to one
+// second's worth of burst
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	return &RateLimiter{
+		rate:   float64(bytesPerSecond),
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+// WaitN blocks until n more bytes may be transferred or ctx is cancelled
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limiter caps every download started by this package and by LimitRequest;
+// nil means unlimited
+var (
+	limiterMu sync.Mutex
+	limiter   *RateLimiter
+)
+
+// SetMaxRate caps the combined download speed at kbPerSecond KB/s. Zero or
+// less removes the cap.
+func SetMaxRate(kbPerSecond int) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	if kbPerSecond <= 0 {
+		limiter = nil
+		return
+	}
+	limiter = NewRateLimiter(int64(kbPerSecond) * 1024)
+}
+
+// LimitRequest applies the cap set by SetMaxRate to req, for downloads made
+// with a grab client outside this package
+func LimitRequest(req *grab.Request) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	if limiter != nil {
+		req.RateLimiter = limiter
+	}
+}
+
+// SaveMaxRate writes the download cap (KB/s, 0 for unlimited) to the max rate
+// file in the specified directory
+func SaveMaxRate(baseDir string, kbPerSecond int) error {
+	return os.WriteFile(filepath.Join(baseDir, MaxRateFile), []byte(strconv.Itoa(kbPerSecond)), 0644)
+}
+
+// LoadMaxRate reads the download cap from the max rate file in the specified directory
+func LoadMaxRate(baseDir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, MaxRateFile))
+	if err != nil {
+		return 0, err
+	}
+	kbPerSecond, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", MaxRateFile, err)
+	}
+	if kbPerSecond < 0 {
+		return 0, fmt.Errorf("invalid %s: rate can't be negative", MaxRateFile)
+	}
+	return kbPerSecond, nil
+}
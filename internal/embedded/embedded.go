@@ -136,8 +136,18 @@ func extractFile(f *zip.File, targetPath string) error {
 	if err != nil {
 		return err
 	}
-	defer out.Close()
 
 	_, err = io.Copy(out, rc)
-	return err
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Keep the release's timestamps rather than the time of the install
+	if !f.Modified.IsZero() {
+		return os.Chtimes(targetPath, f.Modified, f.Modified)
+	}
+	return nil
 }
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of event
+type Type string
+
+const (
+	TypePhase   Type = "phase"   // The updater moved to a new phase (check, download, apply, ...)
+	TypeFile    Type = "file"    // A single file finished downloading or was removed
+	TypeWarning Type = "warning" // Something went wrong but the run continues
+	TypeResult  Type = "result"  // The final outcome: success, partial or failure
+)
+
+// Event is one line of the event log
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    Type      `json:"type"`
+	Phase   string    `json:"phase,omitempty"`
+	File    string    `json:"file,omitempty"`
+	Action  string    `json:"action,omitempty"` // For file events: "updated" or "deleted"
+	Message string    `json:"message,omitempty"`
+	Result  string    `json:"result,omitempty"`
+}
+
+// Log writes events as newline-delimited JSON. A nil *Log discards events,
+// so callers don't need to check whether logging is enabled.
+type Log struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	enc *json.Encoder
+}
+
+// Open appends events to the file (or named pipe) at path
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return New(f), nil
+}
+
+// New writes events to w
+func New(w io.WriteCloser) *Log {
+	return &Log{w: w, enc: json.NewEncoder(w)}
+}
+
+// Emit writes an event, filling in the time if unset. Write errors are
+// ignored: the event log must never break an update.
+func (l *Log) Emit(e Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.enc.Encode(e)
+}
+
+// Phase records a phase transition
+func (l *Log) Phase(name string) {
+	l.Emit(Event{Type: TypePhase, Phase: name})
+}
+
+// File records a completed file operation
+func (l *Log) File(name, action string) {
+	l.Emit(Event{Type: TypeFile, File: name, Action: action})
+}
+
+// Warn records a non-fatal problem
+func (l *Log) Warn(format string, args ...interface{}) {
+	l.Emit(Event{Type: TypeWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// Result records the final outcome of the run
+func (l *Log) Result(result, message string) {
+	l.Emit(Event{Type: TypeResult, Result: result, Message: message})
+}
+
+// Close closes the underlying writer
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Close()
+}
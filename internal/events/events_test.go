@@ -0,0 +1,71 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLog tests that events are written as one JSON object per line
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	log, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	log.Phase("download")
+	log.File("scripts/a.lua", "updated")
+	log.Warn("failed to save %s", "channel")
+	log.Result("success", "")
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	defer f.Close()
+
+	var got []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", scanner.Text(), err)
+		}
+		if e.Time.IsZero() {
+			t.Errorf("event %+v has no time", e)
+		}
+		got = append(got, e)
+	}
+
+	want := []Event{
+		{Type: TypePhase, Phase: "download"},
+		{Type: TypeFile, File: "scripts/a.lua", Action: "updated"},
+		{Type: TypeWarning, Message: "failed to save channel"},
+		{Type: TypeResult, Result: "success"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d", len(got), len(want))
+	}
+	for i := range want {
+		got[i].Time = want[i].Time
+		if got[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestNilLog tests that a nil log silently discards events
+func TestNilLog(t *testing.T) {
+	var log *Log
+	log.Phase("check")
+	log.Warn("ignored")
+	if err := log.Close(); err != nil {
+		t.Errorf("Close() on nil log error = %v", err)
+	}
+}
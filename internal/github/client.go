@@ -1,10 +1,15 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,6 +18,7 @@ type Release struct {
 	TagName string `json:"tag_name"`
 	Name    string `json:"name"`
 	ZipURL  string `json:"zipball_url"`
+	Body    string `json:"body"` // Release notes, in Markdown
 }
 
 // Ref represents a GitHub reference
@@ -58,6 +64,13 @@ type CommitInner struct {
 	Author    CommitAuthor `json:"author"`
 	Committer CommitAuthor `json:"committer"`
 	Message   string       `json:"message"`
+	Tree      CommitTree   `json:"tree"`
+}
+
+// CommitTree identifies the root tree of a commit. Its SHA is the same value
+// GetTree returns for that commit, without fetching the tree itself.
+type CommitTree struct {
+	SHA string `json:"sha"`
 }
 
 // CommitAuthor represents commit author information
@@ -90,6 +103,75 @@ type Client struct {
 	owner      string
 	repo       string
 	httpClient *http.Client
+
+	// baseURL is the API root requests are sent to, without a trailing slash
+	baseURL string
+
+	// token, if set, is sent as a bearer token to raise the API rate limit
+	token string
+
+	// retries is how many times a failed request is retried after the first
+	// attempt; backoff is multiplied by the attempt number between tries
+	retries int
+	backoff time.Duration
+
+	// cache holds the last tree per ref and the last tag list along with
+	// their ETags, so repeated calls can use conditional requests. When
+	// cacheFile is set it is loaded from and saved to disk.
+	cacheMu   sync.Mutex
+	cache     responseCache
+	cacheFile string
+}
+
+// responseCache is the ETag cache, in the form it's stored on disk
+type responseCache struct {
+	Trees map[string]cachedTree `json:"trees,omitempty"`
+	Tags  *cachedTags           `json:"tags,omitempty"`
+}
+
+// cachedTree is a tree response along with the ETag GitHub sent for it
+type cachedTree struct {
+	ETag string `json:"etag"`
+	Tree *Tree  `json:"tree"`
+}
+
+// cachedTags is a tag list response along with its ETag
+type cachedTags struct {
+	ETag string `json:"etag"`
+	Refs []Ref  `json:"refs"`
+}
+
+// SetCacheFile persists the ETag cache in path so conditional requests work
+// across runs. A missing or corrupt file is ignored and replaced on the next
+// successful fetch.
+func (c *Client) SetCacheFile(path string) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.cacheFile = path
+
+	var loaded responseCache
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &loaded) == nil {
+		c.cache = loaded
+	}
+}
+
+// saveCache writes the cache to cacheFile, if set. The caller holds cacheMu.
+// Failures are ignored: the cache only saves bandwidth.
+func (c *Client) saveCache() {
+	if c.cacheFile == "" {
+		return
+	}
+	data, err := json.Marshal(c.cache)
+	if err != nil {
+		return
+	}
+	tmp := c.cacheFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, c.cacheFile); err != nil {
+		os.Remove(tmp)
+	}
 }
 
 // NewClient creates a new GitHub API client
@@ -103,9 +185,57 @@ func NewClient(owner, repo string, httpClient *http.Client) *Client {
 		owner:      owner,
 		repo:       repo,
 		httpClient: httpClient,
+		baseURL:    DefaultBaseURL,
+		retries:    DefaultRetries,
+		backoff:    DefaultBackoff,
 	}
 }
 
+// DefaultBaseURL is the GitHub REST API root
+const DefaultBaseURL = "https://api.github.com"
+
+// SetBaseURL points the client at a different API root, such as a test
+// server. An empty URL restores DefaultBaseURL.
+func (c *Client) SetBaseURL(baseURL string) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	c.baseURL = baseURL
+}
+
+// Default retry policy: 3 attempts in total, waiting 1s then 2s
+const (
+	DefaultRetries = 2
+	DefaultBackoff = time.Second
+)
+
+// SetRetryPolicy sets how many times failed requests are retried and the
+// backoff step between attempts. Negative values are treated as zero.
+func (c *Client) SetRetryPolicy(retries int, backoff time.Duration) {
+	if retries < 0 {
+		retries = 0
+	}
+	if backoff < 0 {
+		backoff = 0
+	}
+	c.retries = retries
+	c.backoff = backoff
+}
+
+// SetToken sets a personal access token to authenticate API requests.
+// An empty token leaves requests unauthenticated.
+func (c *Client) SetToken(token string) {
+	c.token = strings.TrimSpace(token)
+}
+
+// Errors for API responses that retrying won't fix
+var (
+	ErrRateLimited  = errors.New("GitHub API rate limit exceeded; set GITHUB_TOKEN or add a .github-token file to raise it")
+	ErrNotFound     = errors.New("not found on GitHub (HTTP 404)")
+	ErrUnauthorized = errors.New("GitHub rejected the access token (HTTP 401)")
+)
+
 // SetHTTPClient sets the HTTP client (useful for testing)
 func (c *Client) SetHTTPClient(client *http.Client) {
 	c.httpClient = client
@@ -117,21 +247,81 @@ func (c *Client) GetHTTPClient() *http.Client {
 }
 
 // retryRequest performs a GET request with retries
-func (c *Client) retryRequest(url string, result interface{}, operation string) error {
+func (c *Client) retryRequest(ctx context.Context, url string, result interface{}, operation string) error {
+	_, _, err := c.retryConditionalRequest(ctx, url, "", result, operation)
+	return err
+}
+
+// retryConditionalRequest performs a GET request with retries, sending
+// If-None-Match when etag is set. It returns the response ETag, and
+// notModified is true when the server answered 304 (result is left untouched).
+func (c *Client) retryConditionalRequest(ctx context.Context, url, etag string, result interface{}, operation string) (newETag string, notModified bool, err error) {
+	header, notModified, err := c.retryRequestHeader(ctx, url, etag, result, operation)
+	if err != nil {
+		return "", false, err
+	}
+	if notModified {
+		return etag, true, nil
+	}
+	return header.Get("ETag"), false, nil
+}
+
+// retryRequestHeader does the work for retryConditionalRequest and also
+// returns the successful response's headers (nil on 304 or error). Once ctx
+// is cancelled it stops retrying and returns an error wrapping ctx.Err().
+func (c *Client) retryRequestHeader(ctx context.Context, url, etag string, result interface{}, operation string) (header http.Header, notModified bool, err error) {
 	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		if attempt > 0 {
-			time.Sleep(time.Duration(attempt) * time.Second)
+	var rateLimitWait time.Duration
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		var wait time.Duration
+		if rateLimitWait > 0 {
+			wait = rateLimitWait
+			rateLimitWait = 0
+		} else if attempt > 0 {
+			wait = time.Duration(attempt) * c.backoff
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, false, fmt.Errorf("failed to %s: %w", operation, err)
 		}
 
-		resp, err := c.httpClient.Get(url)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
+			return nil, false, fmt.Errorf("failed to create %s request: %w", operation, err)
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, false, fmt.Errorf("failed to %s: %w", operation, ctx.Err())
+			}
 			lastErr = fmt.Errorf("failed to %s: %w", operation, err)
 			continue
 		}
 
+		if etag != "" && resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			return nil, true, nil
+		}
+
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close()
+			if wait, ok := retryAfter(resp, time.Now()); ok {
+				if wait > MaxRateLimitWait {
+					return nil, false, fmt.Errorf("failed to %s: %w (resets in %s)", operation, ErrRateLimited, wait.Round(time.Second))
+				}
+				rateLimitWait = wait
+				lastErr = fmt.Errorf("failed to %s: %w", operation, ErrRateLimited)
+				continue
+			}
+			if err := permanentError(resp); err != nil {
+				return nil, false, fmt.Errorf("failed to %s: %w", operation, err)
+			}
 			lastErr = fmt.Errorf("failed to %s: HTTP %d", operation, resp.StatusCode)
 			continue
 		}
@@ -139,21 +329,84 @@ func (c *Client) retryRequest(url string, result interface{}, operation string)
 		err = json.NewDecoder(resp.Body).Decode(result)
 		resp.Body.Close()
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, false, fmt.Errorf("failed to %s: %w", operation, ctx.Err())
+			}
 			lastErr = fmt.Errorf("failed to parse %s response: %w", operation, err)
 			continue
 		}
 
+		return resp.Header, false, nil
+	}
+	return nil, false, lastErr
+}
+
+// sleepContext waits for d, returning early with ctx.Err() if ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
 		return nil
 	}
-	return lastErr
+}
+
+// MaxRateLimitWait is the longest a request will wait for GitHub's rate limit
+// to reset before giving up
+const MaxRateLimitWait = 60 * time.Second
+
+// retryAfter reads how long GitHub asked us to wait from a 403/429 response:
+// Retry-After (seconds) or, once the quota is used up, X-RateLimit-Reset
+// (Unix time). ok is false if the response carries neither.
+func retryAfter(resp *http.Response, now time.Time) (wait time.Duration, ok bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait = time.Unix(reset, 0).Sub(now)
+			if wait < 0 {
+				wait = 0
+			}
+			return wait, true
+		}
+	}
+	return 0, false
+}
+
+// permanentError classifies responses that retrying won't fix, returning nil
+// for ones that are worth another attempt
+func permanentError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return ErrRateLimited
+		}
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	}
+	return nil
 }
 
 // GetLatestCommit fetches the latest commit for a given ref
-func (c *Client) GetLatestCommit(ref string) (*Commit, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", c.owner, c.repo, ref)
+func (c *Client) GetLatestCommit(ctx context.Context, ref string) (*Commit, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, c.owner, c.repo, ref)
 
 	var commit Commit
-	err := c.retryRequest(url, &commit, "fetch commit")
+	err := c.retryRequest(ctx, url, &commit, "fetch commit")
 	if err != nil {
 		return nil, err
 	}
@@ -162,34 +415,64 @@ func (c *Client) GetLatestCommit(ref string) (*Commit, error) {
 }
 
 // CompareCommits compares two commits and returns the comparison
-func (c *Client) CompareCommits(base, head string) (*Comparison, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s", c.owner, c.repo, base, head)
+func (c *Client) CompareCommits(ctx context.Context, base, head string) (*Comparison, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/compare/%s...%s", c.baseURL, c.owner, c.repo, base, head)
 
 	var comparison Comparison
-	if err := c.retryRequest(url, &comparison, "compare commits"); err != nil {
+	if err := c.retryRequest(ctx, url, &comparison, "compare commits"); err != nil {
 		return nil, err
 	}
 
 	return &comparison, nil
 }
 
+// GetReleaseByTag fetches the release published for a tag
+func (c *Client) GetReleaseByTag(ctx context.Context, tag string) (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", c.baseURL, c.owner, c.repo, tag)
+
+	var release Release
+	if err := c.retryRequest(ctx, url, &release, "fetch release"); err != nil {
+		return nil, err
+	}
+
+	return &release, nil
+}
+
 // GetLastCommitDate fetches the last commit date for a given ref
-func (c *Client) GetLastCommitDate(ref string) (string, error) {
-	commit, err := c.GetLatestCommit(ref)
+func (c *Client) GetLastCommitDate(ctx context.Context, ref string) (string, error) {
+	commit, err := c.GetLatestCommit(ctx, ref)
 	if err != nil {
 		return "", err
 	}
 	return commit.Commit.Author.Date, nil
 }
 
-// GetLatestTag fetches the latest tag from the repository
-func (c *Client) GetLatestTag() (string, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/tags", c.owner, c.repo)
+// GetLatestTag fetches the latest tag from the repository. Like GetTree, it
+// sends the last ETag and reuses the cached tag list on a 304.
+func (c *Client) GetLatestTag(ctx context.Context) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/refs/tags", c.baseURL, c.owner, c.repo)
+
+	c.cacheMu.Lock()
+	cached := c.cache.Tags
+	c.cacheMu.Unlock()
+	var cachedETag string
+	if cached != nil {
+		cachedETag = cached.ETag
+	}
 
 	var refs []Ref
-	if err := c.retryRequest(url, &refs, "fetch tags"); err != nil {
+	etag, notModified, err := c.retryConditionalRequest(ctx, url, cachedETag, &refs, "fetch tags")
+	if err != nil {
 		return "", err
 	}
+	if notModified && cached != nil {
+		refs = cached.Refs
+	} else if etag != "" {
+		c.cacheMu.Lock()
+		c.cache.Tags = &cachedTags{ETag: etag, Refs: refs}
+		c.saveCache()
+		c.cacheMu.Unlock()
+	}
 
 	if len(refs) == 0 {
 		return "", fmt.Errorf("no tags found in repository")
@@ -206,15 +489,40 @@ func (c *Client) GetLatestTag() (string, error) {
 	return tagName, nil
 }
 
-// GetTree fetches the tree object for a given ref
-func (c *Client) GetTree(ref string) (*Tree, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s?recursive=1", c.owner, c.repo, ref)
+// GetTree fetches the tree object for a given ref.
+// The last ETag per ref is remembered and sent as If-None-Match; on a 304
+// (which doesn't count against the rate limit) the cached tree is returned.
+func (c *Client) GetTree(ctx context.Context, ref string) (*Tree, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s?recursive=1", c.baseURL, c.owner, c.repo, ref)
+
+	c.cacheMu.Lock()
+	cached, ok := c.cache.Trees[ref]
+	c.cacheMu.Unlock()
+	if cached.Tree == nil {
+		ok = false
+		cached.ETag = ""
+	}
 
 	var tree Tree
-	if err := c.retryRequest(url, &tree, "fetch tree"); err != nil {
+	etag, notModified, err := c.retryConditionalRequest(ctx, url, cached.ETag, &tree, "fetch tree")
+	if err != nil {
 		return nil, err
 	}
 
+	if notModified && ok {
+		return cached.Tree, nil
+	}
+
+	if etag != "" {
+		c.cacheMu.Lock()
+		if c.cache.Trees == nil {
+			c.cache.Trees = make(map[string]cachedTree)
+		}
+		c.cache.Trees[ref] = cachedTree{ETag: etag, Tree: &tree}
+		c.saveCache()
+		c.cacheMu.Unlock()
+	}
+
 	return &tree, nil
 }
 
@@ -224,42 +532,103 @@ func (c *Client) GetRawURL(tag string, path string) string {
 }
 
 // GetBranches fetches all branches from the repository
-func (c *Client) GetBranches() ([]Branch, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/branches?per_page=100", c.owner, c.repo)
+func (c *Client) GetBranches(ctx context.Context) ([]Branch, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/branches?per_page=100", c.baseURL, c.owner, c.repo)
 
+	// Follow the Link header until the last page
 	var branches []Branch
-	if err := c.retryRequest(url, &branches, "fetch branches"); err != nil {
-		return nil, err
+	for url != "" {
+		var page []Branch
+		header, _, err := c.retryRequestHeader(ctx, url, "", &page, "fetch branches")
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, page...)
+		url = nextPageURL(header.Get("Link"))
 	}
 
 	return branches, nil
 }
 
-// FormatCommitAsCliffNote formats a commit message as a cliff note
-func FormatCommitAsCliffNote(commit Commit) string {
-	message := commit.Commit.Message
-	firstLine := strings.Split(message, "\n")[0]
-
-	// Skip merge commits
-	if strings.HasPrefix(strings.ToLower(firstLine), "merge ") {
-		return ""
+// nextPageURL returns the rel="next" URL from a GitHub Link header, or ""
+// on the last page
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		fields := strings.Split(part, ";")
+		if len(fields) < 2 {
+			continue
+		}
+		target := strings.TrimSpace(fields[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range fields[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return target[1 : len(target)-1]
+			}
+		}
 	}
+	return ""
+}
 
-	// Try to extract semantic commit type
-	var commitType string
-	var commitMessage string
+// IsMergeCommit reports whether a commit's summary starts with "Merge"
+func IsMergeCommit(commit Commit) bool {
+	firstLine := strings.Split(commit.Commit.Message, "\n")[0]
+	return strings.HasPrefix(strings.ToLower(firstLine), "merge ")
+}
+
+// CommitType returns the semantic type of a commit ("feat" for "feat(ui):
+// add map"), or "" if its summary has none
+func CommitType(commit Commit) string {
+	commitType, _ := splitCommitMessage(commit)
+	if idx := strings.IndexAny(commitType, "(!"); idx >= 0 {
+		commitType = commitType[:idx]
+	}
+	return strings.ToLower(commitType)
+}
 
+// splitCommitMessage splits a commit's summary into its semantic type, as
+// written, and the rest of the message
+func splitCommitMessage(commit Commit) (commitType, message string) {
+	firstLine := strings.Split(commit.Commit.Message, "\n")[0]
 	if idx := strings.Index(firstLine, ":"); idx > 0 && idx < 20 {
-		commitType = strings.TrimSpace(firstLine[:idx])
-		commitMessage = strings.TrimSpace(firstLine[idx+1:])
-	} else {
-		commitMessage = firstLine
+		return strings.TrimSpace(firstLine[:idx]), strings.TrimSpace(firstLine[idx+1:])
+	}
+	return "", firstLine
+}
+
+// FormatCommitDate formats a GitHub RFC3339 timestamp as "Jan 2, 2006",
+// returning it unchanged if it doesn't parse
+func FormatCommitDate(date string) string {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+	return t.Format("Jan 2, 2006")
+}
+
+// FormatCommitAsCliffNote formats a commit message as a cliff note. With
+// withAuthor set, the author's name and the commit date follow the short SHA.
+func FormatCommitAsCliffNote(commit Commit, withAuthor bool) string {
+	// Skip merge commits
+	if IsMergeCommit(commit) {
+		return ""
 	}
 
+	commitType, commitMessage := splitCommitMessage(commit)
+
 	// Format output
-	shortSHA := commit.SHA[:7]
+	ref := commit.SHA[:7]
+	if withAuthor {
+		if author := commit.Commit.Author.Name; author != "" {
+			ref += " by " + author
+		}
+		if date := commit.Commit.Author.Date; date != "" {
+			ref += " on " + FormatCommitDate(date)
+		}
+	}
 	if commitType != "" {
-		return fmt.Sprintf("- [%s] %s (%s)", commitType, commitMessage, shortSHA)
+		return fmt.Sprintf("- [%s] %s (%s)", commitType, commitMessage, ref)
 	}
-	return fmt.Sprintf("- %s (%s)", commitMessage, shortSHA)
+	return fmt.Sprintf("- %s (%s)", commitMessage, ref)
 }
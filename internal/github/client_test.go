@@ -1,9 +1,15 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -34,13 +40,10 @@ func TestGetLatestCommit_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Create client with custom HTTP client that uses our test server
 	client := NewClient("owner", "repo", &http.Client{})
-	// Note: We can't easily test this without modifying the URLs since they're hardcoded to api.github.com
-	// For now, skip this test - it requires refactoring to inject base URL
-	t.Skip("requires base URL injection - see internal/github/client.go")
+	client.SetBaseURL(server.URL + "/")
 
-	commit, err := client.GetLatestCommit("main")
+	commit, err := client.GetLatestCommit(context.Background(), "main")
 	if err != nil {
 		t.Fatalf("GetLatestCommit() error = %v", err)
 	}
@@ -54,6 +57,36 @@ func TestGetLatestCommit_Success(t *testing.T) {
 	}
 }
 
+// TestGetReleaseByTag tests fetching a release's notes by tag
+func TestGetReleaseByTag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/releases/tags/v1.2.5":
+			w.Write([]byte(`{"tag_name": "v1.2.5", "name": "1.2.5", "body": "## Fixes\n- The map no longer flickers"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("owner", "repo", &http.Client{})
+	client.SetBaseURL(server.URL)
+	client.SetRetryPolicy(0, 0)
+
+	release, err := client.GetReleaseByTag(context.Background(), "v1.2.5")
+	if err != nil {
+		t.Fatalf("GetReleaseByTag() error = %v", err)
+	}
+	if release.TagName != "v1.2.5" || release.Body != "## Fixes\n- The map no longer flickers" {
+		t.Errorf("GetReleaseByTag() = %+v", release)
+	}
+
+	// A tag without a release is an error, so callers can fall back
+	if _, err := client.GetReleaseByTag(context.Background(), "v9.9.9"); err == nil {
+		t.Error("GetReleaseByTag() for a missing release should fail")
+	}
+}
+
 // TestFormatCommitAsCliffNote tests commit message formatting
 func TestFormatCommitAsCliffNote(t *testing.T) {
 	tests := []struct {
@@ -116,7 +149,7 @@ func TestFormatCommitAsCliffNote(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := FormatCommitAsCliffNote(tt.commit)
+			got := FormatCommitAsCliffNote(tt.commit, false)
 
 			if tt.wantEmpty {
 				if got != "" {
@@ -144,6 +177,45 @@ func TestFormatCommitAsCliffNote(t *testing.T) {
 	}
 }
 
+// TestFormatCommitAsCliffNote_Author tests the author and date suffix
+func TestFormatCommitAsCliffNote_Author(t *testing.T) {
+	commit := Commit{
+		SHA: "abc123def456789",
+		Commit: CommitInner{
+			Message: "fix: resolve bug",
+			Author:  CommitAuthor{Name: "Jane Doe", Date: "2025-01-04T12:00:00Z"},
+		},
+	}
+	if got, want := FormatCommitAsCliffNote(commit, true), "- [fix] resolve bug (abc123d by Jane Doe on Jan 4, 2025)"; got != want {
+		t.Errorf("FormatCommitAsCliffNote(true) = %q, want %q", got, want)
+	}
+	if got, want := FormatCommitAsCliffNote(commit, false), "- [fix] resolve bug (abc123d)"; got != want {
+		t.Errorf("FormatCommitAsCliffNote(false) = %q, want %q", got, want)
+	}
+
+	// An unparseable date is shown as GitHub sent it
+	commit.Commit.Author.Date = "yesterday"
+	if got := FormatCommitAsCliffNote(commit, true); !strings.Contains(got, "on yesterday") {
+		t.Errorf("FormatCommitAsCliffNote() = %q, want the raw date", got)
+	}
+}
+
+// TestCommitType tests semantic type parsing
+func TestCommitType(t *testing.T) {
+	tests := map[string]string{
+		"feat: add map":          "feat",
+		"Fix(ui): stop flicker":  "fix",
+		"feat!: drop old config": "feat",
+		"update documentation":   "",
+	}
+	for message, want := range tests {
+		commit := Commit{SHA: "abc123def456789", Commit: CommitInner{Message: message}}
+		if got := CommitType(commit); got != want {
+			t.Errorf("CommitType(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
+
 // TestNewClient tests client creation
 func TestNewClient(t *testing.T) {
 	t.Run("with custom http client", func(t *testing.T) {
@@ -189,6 +261,24 @@ func TestSetHTTPClient(t *testing.T) {
 	}
 }
 
+// TestSetBaseURL tests that requests go to the configured API root
+func TestSetBaseURL(t *testing.T) {
+	client := NewClient("owner", "repo", nil)
+	if client.baseURL != DefaultBaseURL {
+		t.Errorf("default baseURL = %s, want %s", client.baseURL, DefaultBaseURL)
+	}
+
+	client.SetBaseURL("http://localhost:8080/api/")
+	if client.baseURL != "http://localhost:8080/api" {
+		t.Errorf("baseURL = %s, want trailing slash trimmed", client.baseURL)
+	}
+
+	client.SetBaseURL("")
+	if client.baseURL != DefaultBaseURL {
+		t.Errorf("baseURL after reset = %s, want %s", client.baseURL, DefaultBaseURL)
+	}
+}
+
 // TestGetRawURL tests raw URL construction
 func TestGetRawURL(t *testing.T) {
 	client := NewClient("myowner", "myrepo", nil)
@@ -228,3 +318,405 @@ func TestGetRawURL(t *testing.T) {
 		})
 	}
 }
+
+// rewriteTransport sends every request to a test server, keeping path and query
+type rewriteTransport struct {
+	target string
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, err := url.Parse(rt.target)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = u.Scheme
+	req.URL.Host = u.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// TestGetTree_NotModified tests that a 304 reuses the cached tree
+func TestGetTree_NotModified(t *testing.T) {
+	const etag = `"tree-etag-1"`
+	requests := 0
+	var lastIfNoneMatch string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		lastIfNoneMatch = r.Header.Get("If-None-Match")
+		if !strings.Contains(r.URL.Path, "/git/trees/main") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if lastIfNoneMatch == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		json.NewEncoder(w).Encode(Tree{
+			SHA:  "treesha",
+			Tree: []TreeItem{{Path: "file.lua", Type: "blob", SHA: "blobsha"}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+
+	first, err := client.GetTree(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("GetTree() first call error = %v", err)
+	}
+	if lastIfNoneMatch != "" {
+		t.Errorf("first request sent If-None-Match %q, want none", lastIfNoneMatch)
+	}
+
+	second, err := client.GetTree(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("GetTree() second call error = %v", err)
+	}
+	if lastIfNoneMatch != etag {
+		t.Errorf("second request If-None-Match = %q, want %q", lastIfNoneMatch, etag)
+	}
+	if requests != 2 {
+		t.Errorf("server saw %d requests, want 2", requests)
+	}
+	if second.SHA != first.SHA || len(second.Tree) != 1 || second.Tree[0].Path != "file.lua" {
+		t.Errorf("GetTree() on 304 = %+v, want cached tree %+v", second, first)
+	}
+}
+
+// TestSetRetryPolicy tests that the retry count bounds the number of requests
+func TestSetRetryPolicy(t *testing.T) {
+	if c := NewClient("owner", "repo", nil); c.retries != DefaultRetries || c.backoff != DefaultBackoff {
+		t.Errorf("NewClient() retry policy = %d/%v, want %d/%v", c.retries, c.backoff, DefaultRetries, DefaultBackoff)
+	}
+
+	tests := []struct {
+		name         string
+		retries      int
+		failures     int
+		wantRequests int
+		wantErr      bool
+	}{
+		{"zero retries fails fast", 0, 100, 1, true},
+		{"negative retries treated as zero", -5, 100, 1, true},
+		{"high retries recover", 10, 7, 8, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tt.failures {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				json.NewEncoder(w).Encode(Commit{SHA: "abc123"})
+			}))
+			defer server.Close()
+
+			client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+			client.SetRetryPolicy(tt.retries, time.Millisecond)
+
+			_, err := client.GetLatestCommit(context.Background(), "main")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetLatestCommit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("server saw %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+// TestRetryPolicy_Cancelled tests that cancelling the context stops a request
+// waiting between retries instead of sleeping out the backoff
+func TestRetryPolicy_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+	client.SetRetryPolicy(3, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.GetLatestCommit(ctx, "main")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetLatestCommit() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetLatestCommit() took %v after cancel", elapsed)
+	}
+}
+
+// TestGetLatestCommit_TreeSHA tests that a commit carries its root tree SHA,
+// which must match what GetTree reports for the same ref
+func TestGetLatestCommit_TreeSHA(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/commits/main"):
+			w.Write([]byte(`{"sha": "commitsha", "commit": {"message": "msg", "tree": {"sha": "treesha"}}}`))
+		case strings.Contains(r.URL.Path, "/git/trees/main"):
+			w.Write([]byte(`{"sha": "treesha", "tree": []}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+
+	commit, err := client.GetLatestCommit(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("GetLatestCommit() error = %v", err)
+	}
+	tree, err := client.GetTree(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("GetTree() error = %v", err)
+	}
+	if commit.Commit.Tree.SHA != tree.SHA {
+		t.Errorf("commit tree SHA = %q, GetTree SHA = %q, want equal", commit.Commit.Tree.SHA, tree.SHA)
+	}
+}
+
+// TestSetToken tests that a token is sent only when configured
+func TestSetToken(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"sha": "abc"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+
+	if _, err := client.GetLatestCommit(context.Background(), "main"); err != nil {
+		t.Fatalf("GetLatestCommit() error = %v", err)
+	}
+	if auth != "" {
+		t.Errorf("Authorization without token = %q, want none", auth)
+	}
+
+	client.SetToken(" secret\n")
+	if _, err := client.GetLatestCommit(context.Background(), "main"); err != nil {
+		t.Fatalf("GetLatestCommit() error = %v", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+	}
+}
+
+// TestPermanentErrors tests that rate limiting and missing resources fail
+// fast with distinguishable errors, while other failures are retried
+func TestPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		headers  map[string]string
+		wantErr  error
+		attempts int
+	}{
+		{"rate limited", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, ErrRateLimited, 1},
+		{"secondary rate limit", http.StatusTooManyRequests, map[string]string{"X-RateLimit-Remaining": "0"}, ErrRateLimited, 1},
+		{"reset beyond the cap", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset": strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)}, ErrRateLimited, 1},
+		{"not found", http.StatusNotFound, nil, ErrNotFound, 1},
+		{"bad token", http.StatusUnauthorized, nil, ErrUnauthorized, 1},
+		{"forbidden with quota left", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "12"}, nil, 3},
+		{"server error", http.StatusBadGateway, nil, nil, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+			client.SetRetryPolicy(2, 0)
+
+			_, err := client.GetLatestCommit(context.Background(), "main")
+			if err == nil {
+				t.Fatal("GetLatestCommit() succeeded, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+// TestRetryAfter tests that a 429 with Retry-After waits that long before retrying
+func TestRetryAfter(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"sha": "abc"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+	client.SetRetryPolicy(2, 10*time.Millisecond)
+
+	if _, err := client.GetLatestCommit(context.Background(), "main"); err != nil {
+		t.Fatalf("GetLatestCommit() error = %v", err)
+	}
+	if len(times) != 2 {
+		t.Fatalf("server saw %d requests, want 2", len(times))
+	}
+	if waited := times[1].Sub(times[0]); waited < 2*time.Second || waited > 3*time.Second {
+		t.Errorf("waited %v between attempts, want about 2s", waited)
+	}
+}
+
+func TestRetryAfterHeaders(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name     string
+		status   int
+		headers  map[string]string
+		wantWait time.Duration
+		wantOK   bool
+	}{
+		{"retry-after", http.StatusTooManyRequests, map[string]string{"Retry-After": "5"}, 5 * time.Second, true},
+		{"reset", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000030"}, 30 * time.Second, true},
+		{"reset in the past", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1699999990"}, 0, true},
+		{"quota left", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "5", "X-RateLimit-Reset": "1700000030"}, 0, false},
+		{"not a rate limit status", http.StatusBadGateway, map[string]string{"Retry-After": "5"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+			wait, ok := retryAfter(resp, now)
+			if wait != tt.wantWait || ok != tt.wantOK {
+				t.Errorf("retryAfter() = %v, %v; want %v, %v", wait, ok, tt.wantWait, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestGetBranches_Pagination tests that every page named by the Link header
+// is fetched and concatenated
+func TestGetBranches_Pagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.Header().Set("Link", `<https://api.github.com/repos/owner/repo/branches?per_page=100&page=1>; rel="prev", `+
+				`<https://api.github.com/repos/owner/repo/branches?per_page=100&page=1>; rel="first"`)
+			w.Write([]byte(`[{"name": "feature-c"}]`))
+			return
+		}
+		w.Header().Set("Link", `<https://api.github.com/repos/owner/repo/branches?per_page=100&page=2>; rel="next", `+
+			`<https://api.github.com/repos/owner/repo/branches?per_page=100&page=2>; rel="last"`)
+		w.Write([]byte(`[{"name": "main"}, {"name": "feature-a"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+
+	branches, err := client.GetBranches(context.Background())
+	if err != nil {
+		t.Fatalf("GetBranches() error = %v", err)
+	}
+	var names []string
+	for _, b := range branches {
+		names = append(names, b.Name)
+	}
+	if got, want := strings.Join(names, ","), "main,feature-a,feature-c"; got != want {
+		t.Errorf("GetBranches() = %s, want %s", got, want)
+	}
+}
+
+// TestSetCacheFile tests that ETags survive across clients through the cache
+// file and that a corrupt cache is ignored
+func TestSetCacheFile(t *testing.T) {
+	const treeETag, tagsETag = `"tree-etag"`, `"tags-etag"`
+	var conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := r.Header.Get("If-None-Match")
+		switch {
+		case strings.Contains(r.URL.Path, "/git/trees/main"):
+			if match == treeETag {
+				conditional++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", treeETag)
+			json.NewEncoder(w).Encode(Tree{SHA: "treesha", Tree: []TreeItem{{Path: "file.lua", Type: "blob"}}})
+		case strings.Contains(r.URL.Path, "/git/refs/tags"):
+			if match == tagsETag {
+				conditional++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", tagsETag)
+			w.Write([]byte(`[{"ref": "refs/tags/v1.0.0"}, {"ref": "refs/tags/v1.1.0"}]`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), ".github-cache.json")
+	newClient := func() *Client {
+		client := NewClient("owner", "repo", &http.Client{Transport: rewriteTransport{target: server.URL}})
+		client.SetCacheFile(cacheFile)
+		return client
+	}
+
+	first := newClient()
+	if _, err := first.GetTree(context.Background(), "main"); err != nil {
+		t.Fatalf("GetTree() error = %v", err)
+	}
+	if _, err := first.GetLatestTag(context.Background()); err != nil {
+		t.Fatalf("GetLatestTag() error = %v", err)
+	}
+
+	second := newClient()
+	tree, err := second.GetTree(context.Background(), "main")
+	if err != nil {
+		t.Fatalf("GetTree() from cache error = %v", err)
+	}
+	if tree.SHA != "treesha" || len(tree.Tree) != 1 {
+		t.Errorf("GetTree() from cache = %+v, want the cached tree", tree)
+	}
+	tag, err := second.GetLatestTag(context.Background())
+	if err != nil || tag != "v1.1.0" {
+		t.Errorf("GetLatestTag() from cache = %q, %v; want v1.1.0", tag, err)
+	}
+	if conditional != 2 {
+		t.Errorf("server answered %d requests with 304, want 2", conditional)
+	}
+
+	if err := os.WriteFile(cacheFile, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	third := newClient()
+	if _, err := third.GetTree(context.Background(), "main"); err != nil {
+		t.Fatalf("GetTree() with corrupt cache error = %v", err)
+	}
+	if conditional != 2 {
+		t.Error("corrupt cache should not send If-None-Match")
+	}
+}
@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLang is the catalog used when no translation is available
+const DefaultLang = "en"
+
+// Catalogs live in locales/<lang>.json as a flat map of message ID to format string.
+// To contribute a translation, copy en.json, translate the values and keep the IDs.
+//
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	mu         sync.RWMutex
+	activeLang = DefaultLang
+	active     map[string]string
+	fallback   map[string]string
+)
+
+func init() {
+	catalog, err := loadCatalog(DefaultLang)
+	if err != nil {
+		panic(fmt.Sprintf("i18n: default catalog is invalid: %v", err))
+	}
+	fallback = catalog
+	active = catalog
+}
+
+// Init selects the message catalog. An empty lang uses the system locale.
+// An explicitly requested language without a catalog returns an error and
+// leaves English active; an unsupported system locale silently falls back.
+func Init(lang string) error {
+	explicit := lang != ""
+	if !explicit {
+		lang = systemLocale()
+	}
+
+	for _, candidate := range candidates(lang) {
+		catalog, err := loadCatalog(candidate)
+		if err != nil {
+			continue
+		}
+		mu.Lock()
+		activeLang = candidate
+		active = catalog
+		mu.Unlock()
+		return nil
+	}
+
+	mu.Lock()
+	activeLang = DefaultLang
+	active = fallback
+	mu.Unlock()
+
+	if explicit {
+		return fmt.Errorf("no translation for language %q (available: %s)", lang, strings.Join(Available(), ", "))
+	}
+	return nil
+}
+
+// Lang returns the active language code
+func Lang() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return activeLang
+}
+
+// T looks up a message by ID and formats it with args.
+// Missing translations fall back to English, then to the ID itself.
+func T(id string, args ...interface{}) string {
+	mu.RLock()
+	format, ok := active[id]
+	if !ok {
+		format, ok = fallback[id]
+	}
+	mu.RUnlock()
+
+	if !ok {
+		format = id
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Available returns the language codes that have a catalog
+func Available() []string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		return []string{DefaultLang}
+	}
+
+	var langs []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json") {
+			langs = append(langs, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// loadCatalog reads and parses locales/<lang>.json
+func loadCatalog(lang string) (map[string]string, error) {
+	data, err := localeFS.ReadFile(path.Join("locales", lang+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog map[string]string
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse %s catalog: %w", lang, err)
+	}
+	return catalog, nil
+}
+
+// candidates turns a locale such as "pt_BR.UTF-8" into catalog names to try,
+// most specific first: "pt-br", then "pt"
+func candidates(locale string) []string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if idx := strings.IndexAny(locale, ".@"); idx >= 0 {
+		locale = locale[:idx]
+	}
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if locale == "" || locale == "c" || locale == "posix" {
+		return nil
+	}
+
+	result := []string{locale}
+	if idx := strings.Index(locale, "-"); idx > 0 {
+		result = append(result, locale[:idx])
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestT tests message lookup and formatting
+func TestT(t *testing.T) {
+	if err := Init(DefaultLang); err != nil {
+		t.Fatalf("Init(%q) error = %v", DefaultLang, err)
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		args []interface{}
+		want string
+	}{
+		{"plain message", "update_complete", nil, "Update complete!"},
+		{"formatted message", "downloading_files", []interface{}{12}, "Downloading 12 files..."},
+		{"unknown id falls back to id", "no_such_message", nil, "no_such_message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.id, tt.args...); got != tt.want {
+				t.Errorf("T(%q) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestInit tests language selection and fallback
+func TestInit(t *testing.T) {
+	t.Run("explicit unknown language", func(t *testing.T) {
+		err := Init("xx")
+		if err == nil {
+			t.Error("Init(\"xx\") should return an error")
+		}
+		if Lang() != DefaultLang {
+			t.Errorf("Lang() = %q, want %q", Lang(), DefaultLang)
+		}
+	})
+
+	t.Run("regional variant uses base catalog", func(t *testing.T) {
+		if err := Init("en_GB.UTF-8"); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if Lang() != "en" {
+			t.Errorf("Lang() = %q, want %q", Lang(), "en")
+		}
+	})
+
+	t.Run("unsupported system locale falls back silently", func(t *testing.T) {
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", "xx_YY.UTF-8")
+		if err := Init(""); err != nil {
+			t.Errorf("Init(\"\") error = %v, want nil", err)
+		}
+		if Lang() != DefaultLang {
+			t.Errorf("Lang() = %q, want %q", Lang(), DefaultLang)
+		}
+	})
+}
+
+// TestCandidates tests locale name normalization
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{"pt_BR.UTF-8", []string{"pt-br", "pt"}},
+		{"de-DE", []string{"de-de", "de"}},
+		{"fr", []string{"fr"}},
+		{"C", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := candidates(tt.locale); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("candidates(%q) = %v, want %v", tt.locale, got, tt.want)
+		}
+	}
+}
+
+// TestCatalogsMatchDefault tests that every translation only uses IDs the
+// English catalog defines, with the same number of format verbs
+func TestCatalogsMatchDefault(t *testing.T) {
+	for _, lang := range Available() {
+		catalog, err := loadCatalog(lang)
+		if err != nil {
+			t.Errorf("loadCatalog(%q) error = %v", lang, err)
+			continue
+		}
+		for id, msg := range catalog {
+			english, ok := fallback[id]
+			if !ok {
+				t.Errorf("%s: unknown message ID %q", lang, id)
+				continue
+			}
+			if strings.Count(msg, "%") != strings.Count(english, "%") {
+				t.Errorf("%s: %q has different format verbs than English", lang, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package i18n
+
+import "os"
+
+// systemLocale returns the locale from the standard environment variables
+func systemLocale() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
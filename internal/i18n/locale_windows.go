@@ -0,0 +1,25 @@
+//go:build windows
+
+package i18n
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// systemLocale returns the user's default locale name, e.g. "en-US"
+func systemLocale() string {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	proc := kernel32.NewProc("GetUserDefaultLocaleName")
+	if proc.Find() != nil {
+		return ""
+	}
+
+	const localeNameMaxLength = 85
+	buf := make([]uint16, localeNameMaxLength)
+	ret, _, _ := proc.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if ret == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buf)
+}
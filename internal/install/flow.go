@@ -0,0 +1,301 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/distantorigin/next-launcher/internal/channel"
+	"github.com/distantorigin/next-launcher/internal/i18n"
+	"github.com/distantorigin/next-launcher/internal/manifest"
+	"github.com/distantorigin/next-launcher/internal/paths"
+	"github.com/distantorigin/next-launcher/internal/version"
+)
+
+// ErrCancelled is returned when the user declines the installation
+var ErrCancelled = errors.New("operation cancelled by user")
+
+// Prompter is the interactive UI the install flow needs
+type Prompter interface {
+	Confirm(prompt string) bool
+	SelectFolder(defaultPath string) (string, error)
+}
+
+// Source provides release information for the selected channel (normally GitHub)
+type Source interface {
+	ArchiveURL() (string, error)
+	RemoteManifest() (map[string]manifest.FileInfo, error)
+	LatestVersion() (*version.Version, error)
+}
+
+// Downloader fetches an archive and extracts it into a directory
+type Downloader interface {
+	DownloadAndExtract(url, targetDir string) error
+}
+
+// ShortcutCreator creates the desktop shortcut for an installation
+type ShortcutCreator interface {
+	CreateShortcut(installDir string) error
+}
+
+// ClientChecker makes sure MUSHclient isn't running from the install directory,
+// closing it or asking the user to, and returns an error if it is still running
+type ClientChecker interface {
+	EnsureClosed(installDir string) error
+}
+
+// Elevator is consulted when the install directory isn't writable. It may
+// relaunch the updater with administrator rights, returning an error of its
+// own so this run stops while the elevated copy installs, or return an error
+// if elevation isn't allowed or was declined.
+type Elevator interface {
+	Elevate(installDir string) error
+}
+
+// Deps holds everything the install flow talks to outside the install directory.
+// The updater wires in the real implementations; tests can pass mocks.
+type Deps struct {
+	Prompt   Prompter
+	Source   Source
+	Download Downloader
+	Shortcut ShortcutCreator
+	Client   ClientChecker
+	Elevator Elevator // Optional; without it an unwritable directory is an error
+}
+
+// Options controls a single install run
+type Options struct {
+	DefaultDir     string
+	DirChosen      bool // DefaultDir was already chosen and confirmed (e.g. before relaunching elevated)
+	Channel        string
+	NonInteractive bool
+	Quiet          bool
+	Verbose        bool
+	ManifestFile   string
+	VersionFile    string
+	ExcludesFile   string
+}
+
+// Run performs a complete online installation: Prepare followed by FromArchive
+func Run(opts Options, deps Deps) (string, error) {
+	installDir, err := Prepare(opts, deps)
+	if err != nil {
+		return "", err
+	}
+	if err := FromArchive(installDir, opts, deps); err != nil {
+		return "", err
+	}
+	return installDir, nil
+}
+
+// Prepare chooses the installation directory, makes sure MUSHclient isn't
+// running there, confirms with the user and creates the directory
+func Prepare(opts Options, deps Deps) (string, error) {
+	installDir := opts.DefaultDir
+
+	// Ask if user wants to change the default location
+	if !opts.NonInteractive && !opts.DirChosen {
+		fmt.Printf("\nDefault installation location: %s\n", opts.DefaultDir)
+		if deps.Prompt.Confirm("Do you want to change the installation location?") {
+			selectedDir, err := deps.Prompt.SelectFolder(opts.DefaultDir)
+			if err != nil {
+				fmt.Printf("Error selecting folder: %v\n", err)
+				fmt.Printf("Using default location: %s\n", opts.DefaultDir)
+			} else {
+				installDir = selectedDir
+			}
+		}
+	}
+
+	fmt.Printf("\nThis will install the %s version to: %s\n", opts.Channel, installDir)
+
+	if err := deps.Client.EnsureClosed(installDir); err != nil {
+		return "", err
+	}
+
+	if !opts.NonInteractive && !opts.DirChosen && !deps.Prompt.Confirm(i18n.T("confirm_proceed_install")) {
+		fmt.Println(i18n.T("installation_cancelled"))
+		return "", ErrCancelled
+	}
+
+	// Protected locations such as Program Files need administrator rights
+	if err := CheckWritable(installDir); err != nil {
+		if !errors.Is(err, os.ErrPermission) || deps.Elevator == nil {
+			return "", err
+		}
+		if err := deps.Elevator.Elevate(installDir); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create installation directory: %w", err)
+	}
+
+	if !opts.Quiet {
+		fmt.Printf("\nInstalling to: %s\n", installDir)
+	}
+
+	return installDir, nil
+}
+
+// FromArchive downloads and extracts the channel archive into installDir and
+// writes the files the updater needs for future runs. Only the download
+// itself is fatal; the remaining steps warn and continue.
+func FromArchive(installDir string, opts Options, deps Deps) error {
+	zipURL, err := deps.Source.ArchiveURL()
+	if err != nil {
+		return err
+	}
+
+	if err := deps.Download.DownloadAndExtract(zipURL, installDir); err != nil {
+		return fmt.Errorf("failed to download installation: %w", err)
+	}
+
+	// Save a local manifest for future updates
+	if !opts.Quiet {
+		fmt.Println(i18n.T("saving_manifest"))
+	}
+	if err := saveManifest(installDir, opts, deps.Source); err != nil {
+		// Non-fatal - just warn
+		fmt.Printf("Warning: failed to save manifest: %v\n", err)
+	}
+
+	// Save channel preference
+	if err := channel.Save(installDir, opts.Channel); err != nil {
+		// Non-fatal - just warn
+		fmt.Printf("Warning: failed to save channel preference: %v\n", err)
+	} else if !opts.Quiet && opts.Verbose {
+		fmt.Printf("Saved channel preference: %s\n", opts.Channel)
+	}
+
+	// Save version.json with the installed version
+	if latestVer, err := deps.Source.LatestVersion(); err == nil {
+		if err := version.Save(installDir, opts.VersionFile, latestVer); err != nil {
+			fmt.Printf("Warning: failed to save version file: %v\n", err)
+		} else if !opts.Quiet && opts.Verbose {
+			fmt.Printf("Saved version: %s\n", latestVer.String())
+		}
+	}
+
+	// Create .updater-excludes file to protect user configuration
+	if err := WriteDefaultExcludes(installDir, opts.ExcludesFile, opts.Channel); err != nil {
+		// Non-fatal - just warn
+		fmt.Printf("Warning: failed to create %s: %v\n", opts.ExcludesFile, err)
+	} else if !opts.Quiet && opts.Verbose {
+		fmt.Printf("Created %s file\n", opts.ExcludesFile)
+	}
+
+	// Create channel switching batch files
+	if err := CreateChannelSwitchBatchFiles(installDir); err != nil {
+		// Non-fatal - just warn
+		fmt.Printf("Warning: failed to create channel switch batch files: %v\n", err)
+	} else if !opts.Quiet && opts.Verbose {
+		fmt.Println("Created channel switching batch files (switch-to-stable.bat, switch-to-dev.bat)")
+	}
+
+	if !opts.Quiet {
+		fmt.Println("\n" + i18n.T("installation_complete"))
+		fmt.Println("Location:", installDir)
+	}
+
+	if err := deps.Shortcut.CreateShortcut(installDir); err != nil {
+		if !opts.Quiet {
+			fmt.Printf("Warning: failed to create desktop icon: %v\n", err)
+		}
+	} else if !opts.Quiet {
+		fmt.Println("Desktop shortcut created!")
+	}
+
+	return nil
+}
+
+// saveManifest records the remote files that landed in installDir
+func saveManifest(installDir string, opts Options, source Source) error {
+	remote, err := source.RemoteManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load remote manifest: %w", err)
+	}
+	mgr := manifest.NewManager(manifest.Config{ManifestFile: opts.ManifestFile})
+	return mgr.SaveTo(installDir, remote, paths.Denormalize)
+}
+
+// DefaultExcludes are the protective entries WriteDefaultExcludes puts in a
+// new exclusions file, in the normalized form paths.LoadExcludes returns
+var DefaultExcludes = []string{
+	"mushclient.ini",
+	"mushclient_prefs.sqlite",
+	"worlds/*.mcl",
+}
+
+// ChannelExcludes are extra default entries for a channel's new exclusions
+// file. The dev channel skips documentation and test fixtures, which change on
+// nearly every commit but aren't needed to play; stable keeps the full set.
+var ChannelExcludes = map[string][]string{
+	"dev": {
+		"docs/",
+		"tests/",
+	},
+}
+
+// CompareExcludes reports how a loaded exclusions list differs from the
+// defaults: protective entries that were removed, and entries that were added
+// (negations keep their "!"). Both lists are sorted.
+func CompareExcludes(excludes paths.Excludes) (removed, added []string) {
+	loaded := make(map[string]struct{}, len(excludes))
+	for _, entry := range excludes {
+		loaded[entry] = struct{}{}
+	}
+	defaults := make(map[string]struct{}, len(DefaultExcludes))
+	for _, entry := range DefaultExcludes {
+		defaults[entry] = struct{}{}
+		if _, ok := loaded[entry]; !ok {
+			removed = append(removed, entry)
+		}
+	}
+	for entry := range loaded {
+		if _, ok := defaults[entry]; !ok {
+			added = append(added, entry)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	return removed, added
+}
+
+// WriteDefaultExcludes writes the default exclusions file protecting user
+// configuration, followed by any ChannelExcludes for channel
+func WriteDefaultExcludes(installDir, excludesFile, channel string) error {
+	var content strings.Builder
+	content.WriteString("# Updater Exclusions\n")
+	content.WriteString("# This file lists paths that the updater will NEVER touch.\n")
+	content.WriteString("# These are typically user configuration files and data.\n")
+	content.WriteString("#\n")
+	content.WriteString("# Lines starting with # are comments.\n")
+	content.WriteString("# One path per line.\n")
+	content.WriteString("# Paths are relative to the installation directory.\n")
+	content.WriteString("#\n")
+	content.WriteString("# DO NOT delete this file unless you want the updater to\n")
+	content.WriteString("# potentially overwrite your configuration!\n")
+	content.WriteString("\n")
+	content.WriteString("# MUSHclient configuration files\n")
+	content.WriteString("mushclient.ini\n")
+	content.WriteString("mushclient_prefs.sqlite\n")
+	content.WriteString("\n")
+	content.WriteString("# World configuration files (*.mcl files in worlds directory)\n")
+	content.WriteString("worlds/*.mcl\n")
+	content.WriteString("\n")
+
+	if extra := ChannelExcludes[channel]; len(extra) > 0 {
+		content.WriteString("# Frequently changing files skipped on the " + channel + " channel\n")
+		for _, entry := range extra {
+			content.WriteString(entry + "\n")
+		}
+		content.WriteString("\n")
+	}
+
+	return os.WriteFile(filepath.Join(installDir, excludesFile), []byte(content.String()), 0644)
+}
@@ -1,48 +1,411 @@
 package install
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // WorldFileConfig holds the server configuration for world files
 type WorldFileConfig struct {
 	DefaultServer string
+	DefaultPort   string // The game's own port, restored when no backup records one
 	LocalServer   string
 	ProxianiPort  string
 	MUDMixerPort  string
 }
 
-// UpdateWorldFile updates a world file to use localhost instead of the default server
+// UpdateWorldFile updates a world file to use cfg.LocalServer instead of the default server.
+// Only the site (and, with updatePort, port) attributes of the <world> element
+// change; the rest of the file is kept byte for byte.
+// The first time a file is changed, the original is kept alongside it as <file>.bak.
 func UpdateWorldFile(worldFilePath string, updatePort bool, cfg WorldFileConfig) error {
 	data, err := os.ReadFile(worldFilePath)
 	if err != nil {
 		return fmt.Errorf("failed to read world file: %w", err)
 	}
 
-	content := string(data)
-
-	// Replace server with localhost
-	updatedContent := strings.ReplaceAll(content, `site="`+cfg.DefaultServer+`"`, `site="`+cfg.LocalServer+`"`)
+	world, err := findWorldElement(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(worldFilePath), err)
+	}
 
+	site := world.attrs["site"]
+	if site != cfg.DefaultServer && site != cfg.LocalServer {
+		return fmt.Errorf("%w: no %s references found in world file", ErrOtherServer, cfg.DefaultServer)
+	}
+	set := map[string]string{}
+	if site == cfg.DefaultServer {
+		set["site"] = cfg.LocalServer
+		// Proxiani usually listens on the game's own port, but can be moved
+		if !updatePort && world.attrs["port"] != cfg.ProxianiPort {
+			set["port"] = cfg.ProxianiPort
+		}
+	}
 	// Update port for MUDMixer if requested
-	if updatePort {
-		updatedContent = strings.ReplaceAll(updatedContent, `port="`+cfg.ProxianiPort+`"`, `port="`+cfg.MUDMixerPort+`"`)
+	if updatePort && (site == cfg.DefaultServer || site == cfg.LocalServer) && world.attrs["port"] != cfg.MUDMixerPort {
+		set["port"] = cfg.MUDMixerPort
 	}
-
-	if updatedContent == content {
+	if len(set) == 0 {
 		return fmt.Errorf("no %s references found in world file", cfg.DefaultServer)
 	}
 
-	if err := os.WriteFile(worldFilePath, []byte(updatedContent), 0644); err != nil {
+	if err := backupWorldFile(worldFilePath, data); err != nil {
+		return err
+	}
+	if err := os.WriteFile(worldFilePath, world.rewrite(data, set), 0644); err != nil {
+		return fmt.Errorf("failed to write world file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadProxyConfig applies the proxy settings in a .proxy-config file to cfg.
+// The file holds key=value lines, with # starting a comment:
+//
+//	host=192.168.1.20
+//	proxiani_port=5000
+//	mudmixer_port=7788
+//
+// Every key is optional and a missing file leaves cfg unchanged.
+func LoadProxyConfig(path string, cfg WorldFileConfig) (WorldFileConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return cfg, fmt.Errorf("%s line %d: expected key=value", filepath.Base(path), i+1)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "host":
+			if !validSitePattern.MatchString(value) {
+				return cfg, fmt.Errorf("%s line %d: invalid host %q", filepath.Base(path), i+1, value)
+			}
+			cfg.LocalServer = value
+		case "proxiani_port", "mudmixer_port":
+			if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+				return cfg, fmt.Errorf("%s line %d: invalid port %q", filepath.Base(path), i+1, value)
+			}
+			if key == "proxiani_port" {
+				cfg.ProxianiPort = value
+			} else {
+				cfg.MUDMixerPort = value
+			}
+		default:
+			return cfg, fmt.Errorf("%s line %d: unknown setting %q", filepath.Base(path), i+1, key)
+		}
+	}
+	return cfg, nil
+}
+
+// ErrOtherServer is returned by UpdateWorldFile when the world file connects
+// to neither cfg.DefaultServer nor cfg.LocalServer, such as a test server world
+var ErrOtherServer = errors.New("world file connects to another server")
+
+// ErrAlreadyDirect is returned by RestoreDirectConnection when the world file
+// doesn't go through a local proxy
+var ErrAlreadyDirect = errors.New("already using direct connection")
+
+// UsesLocalProxy reports whether a world file connects through a proxy on
+// cfg.LocalServer, as set up by UpdateWorldFile
+func UsesLocalProxy(worldFilePath string, cfg WorldFileConfig) (bool, error) {
+	data, err := os.ReadFile(worldFilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read world file: %w", err)
+	}
+	world, err := findWorldElement(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", filepath.Base(worldFilePath), err)
+	}
+	return world.attrs["site"] == cfg.LocalServer, nil
+}
+
+// RestoreDirectConnection reverses UpdateWorldFile, pointing a world file back
+// at the game server. The site and port come from the <file>.bak backup when
+// it records a direct connection, otherwise from cfg's defaults. It returns
+// the site and port the file now uses.
+func RestoreDirectConnection(worldFilePath string, cfg WorldFileConfig) (site, port string, err error) {
+	data, err := os.ReadFile(worldFilePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read world file: %w", err)
+	}
+
+	world, err := findWorldElement(data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse %s: %w", filepath.Base(worldFilePath), err)
+	}
+	if world.attrs["site"] != cfg.LocalServer {
+		return "", "", ErrAlreadyDirect
+	}
+
+	site, port = cfg.DefaultServer, cfg.DefaultPort
+	if backup, err := os.ReadFile(worldFilePath + ".bak"); err == nil {
+		if b, err := findWorldElement(backup); err == nil {
+			s, sok := b.attrs["site"]
+			p, pok := b.attrs["port"]
+			if sok && pok && s != cfg.LocalServer {
+				site, port = s, p
+			}
+		}
+	}
+
+	set := map[string]string{"site": site}
+	if world.attrs["port"] != port {
+		set["port"] = port
+	}
+
+	if err := os.WriteFile(worldFilePath, world.rewrite(data, set), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write world file: %w", err)
+	}
+	return site, port, nil
+}
+
+// worldElement is the <world> start tag of a world file
+type worldElement struct {
+	start, end int               // Byte range of the tag in the file
+	attrs      map[string]string // Attribute values, unescaped
+}
+
+// findWorldElement parses data as XML up to its <world> element. World files
+// usually declare iso-8859-1; the attributes the updater touches are ASCII,
+// so the bytes are read as-is rather than transcoded.
+func findWorldElement(data []byte) (*worldElement, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	for {
+		start := int(d.InputOffset())
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no <world> element found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if el, ok := tok.(xml.StartElement); ok && el.Name.Space == "" && el.Name.Local == "world" {
+			w := &worldElement{start: start, end: int(d.InputOffset()), attrs: map[string]string{}}
+			for _, attr := range el.Attr {
+				if attr.Name.Space == "" {
+					w.attrs[attr.Name.Local] = attr.Value
+				}
+			}
+			return w, nil
+		}
+	}
+}
+
+// rewrite returns a copy of data with the named attributes of the <world>
+// tag set to new values. Everything else, including the quoting of other
+// attributes and the whitespace between them, is left as it was.
+func (w *worldElement) rewrite(data []byte, set map[string]string) []byte {
+	tag := data[w.start:w.end]
+	isSpace := func(c byte) bool { return c == ' ' || c == '\t' || c == '\r' || c == '\n' }
+
+	var out bytes.Buffer
+	out.Write(data[:w.start])
+	i := len("<world")
+	out.Write(tag[:i])
+	// The decoder has already checked the tag is well formed, so every
+	// attribute is name, optional spaces, '=', optional spaces, quoted value
+	for {
+		j := i
+		for j < len(tag) && isSpace(tag[j]) {
+			j++
+		}
+		if j >= len(tag) || tag[j] == '/' || tag[j] == '>' {
+			out.Write(tag[i:])
+			break
+		}
+		nameStart := j
+		for tag[j] != '=' && !isSpace(tag[j]) {
+			j++
+		}
+		name := string(tag[nameStart:j])
+		for tag[j] != '"' && tag[j] != '\'' {
+			j++
+		}
+		quote := tag[j]
+		valueStart := j + 1
+		valueEnd := valueStart + bytes.IndexByte(tag[valueStart:], quote)
+
+		if value, ok := set[name]; ok {
+			// Rewritten values are always double quoted
+			out.Write(tag[i:j])
+			out.WriteByte('"')
+			xml.EscapeText(&out, []byte(value))
+			out.WriteByte('"')
+		} else {
+			out.Write(tag[i : valueEnd+1])
+		}
+		i = valueEnd + 1
+	}
+	out.Write(data[w.end:])
+	return out.Bytes()
+}
+
+// backupWorldFile saves data as <file>.bak unless a backup already exists, so
+// the backup always holds the file as it was before the updater first touched it
+func backupWorldFile(worldFilePath string, data []byte) error {
+	backupPath := worldFilePath + ".bak"
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		if err := os.WriteFile(backupPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to back up world file: %w", err)
+		}
+	}
+	return nil
+}
+
+var validSitePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// ValidHost reports whether host is a hostname or IP address that can be
+// written into a world file
+func ValidHost(host string) bool {
+	return validSitePattern.MatchString(host)
+}
+
+// SetWorldConnection points a world file at an arbitrary host and port by
+// rewriting the site and port attributes of its <world> element. The first
+// time a file is changed, the original is kept alongside it as <file>.bak.
+func SetWorldConnection(worldFilePath, site string, port int) error {
+	if !validSitePattern.MatchString(site) {
+		return fmt.Errorf("invalid host %q", site)
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+	}
+
+	data, err := os.ReadFile(worldFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read world file: %w", err)
+	}
+
+	world, err := findWorldElement(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(worldFilePath), err)
+	}
+	_, hasSite := world.attrs["site"]
+	_, hasPort := world.attrs["port"]
+	if !hasSite || !hasPort {
+		return fmt.Errorf("<world> element in %s has no site or port", filepath.Base(worldFilePath))
+	}
+	updated := world.rewrite(data, map[string]string{"site": site, "port": strconv.Itoa(port)})
+
+	if err := backupWorldFile(worldFilePath, data); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(worldFilePath, updated, 0644); err != nil {
 		return fmt.Errorf("failed to write world file: %w", err)
 	}
+	return nil
+}
+
+// CheckWritable probes whether files can be created in dir (or, if it doesn't
+// exist yet, its nearest existing parent). A permission failure is returned
+// wrapping os.ErrPermission so callers can offer elevation.
+func CheckWritable(dir string) error {
+	probeDir := dir
+	for {
+		if info, err := os.Stat(probeDir); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(probeDir)
+		if parent == probeDir {
+			return fmt.Errorf("no existing parent directory for %s", dir)
+		}
+		probeDir = parent
+	}
 
+	f, err := os.CreateTemp(probeDir, ".write-probe-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s: %w", probeDir, err)
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
 	return nil
 }
 
+// PrefsFile is MUSHclient's preferences database, relative to the install root
+const PrefsFile = "mushclient_prefs.sqlite"
+
+// SharedPrefs returns the directories in others whose preferences database is
+// the same file as installDir's - reached through a junction, symlink or hard
+// link rather than being a separate copy. Comparison uses os.SameFile (volume
+// and file index on Windows), so differently spelled paths are still caught.
+// others entries that resolve to installDir itself are ignored.
+func SharedPrefs(installDir string, others []string) []string {
+	target, err := os.Stat(filepath.Join(installDir, PrefsFile))
+	if err != nil {
+		return nil
+	}
+	self, _ := os.Stat(installDir)
+
+	var shared []string
+	for _, dir := range others {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && self != nil && os.SameFile(info, self) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, PrefsFile))
+		if err == nil && os.SameFile(info, target) {
+			shared = append(shared, dir)
+		}
+	}
+	return shared
+}
+
+// FindWorldFiles lists every world file with the given extension anywhere
+// under installDir/worldsDir, sorted, with the preferred file first if present.
+func FindWorldFiles(installDir, worldsDir, preferred, ext string) ([]string, error) {
+	root := filepath.Join(installDir, worldsDir)
+	preferredPath := filepath.Join(root, preferred)
+
+	var found []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ext) {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for world files: %w", err)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if (found[i] == preferredPath) != (found[j] == preferredPath) {
+			return found[i] == preferredPath
+		}
+		return found[i] < found[j]
+	})
+	return found, nil
+}
+
 // CreateChannelSwitchBatchFiles creates batch files for switching update channels
 func CreateChannelSwitchBatchFiles(installDir string) error {
 	files := map[string]string{
@@ -1,10 +1,14 @@
 package install
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/distantorigin/next-launcher/internal/paths"
 )
 
 // TestUpdateWorldFile_Success tests successful world file update
@@ -14,11 +18,12 @@ func TestUpdateWorldFile_Success(t *testing.T) {
 
 	// Create test world file with default server
 	originalContent := `<?xml version="1.0" encoding="iso-8859-1"?>
-<world>
-  <name>Test World</name>
-  <site="miriani.org"
+<world
+  name="Test World"
+  site="miriani.org"
   port="1234"
   use_proxy="y"
+>
 </world>`
 
 	err := os.WriteFile(worldFile, []byte(originalContent), 0644)
@@ -60,10 +65,11 @@ func TestUpdateWorldFile_WithPortUpdate(t *testing.T) {
 	worldFile := filepath.Join(tempDir, "test.mcl")
 
 	originalContent := `<?xml version="1.0" encoding="iso-8859-1"?>
-<world>
-  <name>Test World</name>
-  <site="miriani.org"
+<world
+  name="Test World"
+  site="miriani.org"
   port="1234"
+>
 </world>`
 
 	err := os.WriteFile(worldFile, []byte(originalContent), 0644)
@@ -109,10 +115,11 @@ func TestUpdateWorldFile_NoServerFound(t *testing.T) {
 
 	// Create world file without the target server
 	originalContent := `<?xml version="1.0" encoding="iso-8859-1"?>
-<world>
-  <name>Test World</name>
-  <site="different-server.com"
+<world
+  name="Test World"
+  site="different-server.com"
   port="1234"
+>
 </world>`
 
 	err := os.WriteFile(worldFile, []byte(originalContent), 0644)
@@ -135,6 +142,150 @@ func TestUpdateWorldFile_NoServerFound(t *testing.T) {
 	if !strings.Contains(err.Error(), "no miriani.org references found") {
 		t.Errorf("UpdateWorldFile() error = %v, want error about server not found", err)
 	}
+	if !errors.Is(err, ErrOtherServer) {
+		t.Errorf("UpdateWorldFile() error = %v, want ErrOtherServer", err)
+	}
+}
+
+// TestUpdateWorldFile_XML tests that the world file is parsed as XML rather
+// than matched as text: single-quoted attributes and odd spacing are handled,
+// the server name elsewhere in the file is left alone, and other attributes
+// of the <world> tag survive untouched
+func TestUpdateWorldFile_XML(t *testing.T) {
+	original := `<?xml version="1.0" encoding="iso-8859-1"?>
+<!-- site="miriani.org" -->
+<muclient>
+<world
+   auto_say_string = 'say '
+   name="Miriani &amp; friends"
+   site = 'miriani.org'
+   port='1234'
+   use_proxy="0"
+   >
+  <notes>Connect to site="miriani.org" port="1234"</notes>
+</world>
+</muclient>`
+	cfg := WorldFileConfig{
+		DefaultServer: "miriani.org",
+		LocalServer:   "localhost",
+		ProxianiPort:  "1234",
+		MUDMixerPort:  "5678",
+	}
+
+	worldFile := filepath.Join(t.TempDir(), "test.mcl")
+	if err := os.WriteFile(worldFile, []byte(original), 0644); err != nil {
+		t.Fatalf("failed to create test world file: %v", err)
+	}
+	if err := UpdateWorldFile(worldFile, true, cfg); err != nil {
+		t.Fatalf("UpdateWorldFile() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(worldFile)
+	want := strings.Replace(original, `site = 'miriani.org'`, `site = "localhost"`, 1)
+	want = strings.Replace(want, `port='1234'`, `port="5678"`, 1)
+	if string(data) != want {
+		t.Errorf("updated world file =\n%s\nwant\n%s", data, want)
+	}
+
+	// The rewritten tag still parses with the other attributes intact
+	world, err := findWorldElement(data)
+	if err != nil {
+		t.Fatalf("findWorldElement() error = %v", err)
+	}
+	wantAttrs := map[string]string{
+		"auto_say_string": "say ",
+		"name":            "Miriani & friends",
+		"site":            "localhost",
+		"port":            "5678",
+		"use_proxy":       "0",
+	}
+	if !reflect.DeepEqual(world.attrs, wantAttrs) {
+		t.Errorf("world attributes = %v, want %v", world.attrs, wantAttrs)
+	}
+
+	notWorld := filepath.Join(t.TempDir(), "broken.mcl")
+	os.WriteFile(notWorld, []byte(`<muclient><world site="miriani.org"`), 0644)
+	if err := UpdateWorldFile(notWorld, false, cfg); err == nil {
+		t.Error("UpdateWorldFile() on malformed XML succeeded, want error")
+	}
+}
+
+// TestUpdateWorldFile_CustomProxy tests proxy hosts and ports other than the
+// defaults, as set in .proxy-config
+func TestUpdateWorldFile_CustomProxy(t *testing.T) {
+	original := `<world name="Miriani" site="toastsoft.net" port="1234">`
+	cfg := WorldFileConfig{
+		DefaultServer: "toastsoft.net",
+		DefaultPort:   "1234",
+		LocalServer:   "192.168.1.20",
+		ProxianiPort:  "5000",
+		MUDMixerPort:  "9000",
+	}
+
+	for _, tt := range []struct {
+		name       string
+		updatePort bool
+		want       string
+	}{
+		{"proxiani", false, `<world name="Miriani" site="192.168.1.20" port="5000">`},
+		{"mudmixer", true, `<world name="Miriani" site="192.168.1.20" port="9000">`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			worldFile := filepath.Join(t.TempDir(), "miriani.mcl")
+			os.WriteFile(worldFile, []byte(original), 0644)
+
+			if err := UpdateWorldFile(worldFile, tt.updatePort, cfg); err != nil {
+				t.Fatalf("UpdateWorldFile() error = %v", err)
+			}
+			data, _ := os.ReadFile(worldFile)
+			if string(data) != tt.want {
+				t.Errorf("updated world file = %s, want %s", data, tt.want)
+			}
+
+			if _, _, err := RestoreDirectConnection(worldFile, cfg); err != nil {
+				t.Fatalf("RestoreDirectConnection() error = %v", err)
+			}
+			data, _ = os.ReadFile(worldFile)
+			if string(data) != original {
+				t.Errorf("restored world file = %s, want %s", data, original)
+			}
+		})
+	}
+}
+
+// TestLoadProxyConfig tests reading proxy overrides from .proxy-config
+func TestLoadProxyConfig(t *testing.T) {
+	defaults := WorldFileConfig{
+		DefaultServer: "toastsoft.net",
+		LocalServer:   "localhost",
+		ProxianiPort:  "1234",
+		MUDMixerPort:  "7788",
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".proxy-config")
+
+	got, err := LoadProxyConfig(path, defaults)
+	if err != nil || got != defaults {
+		t.Errorf("LoadProxyConfig() without a file = %+v, %v; want defaults", got, err)
+	}
+
+	os.WriteFile(path, []byte("# Proxiani on the desktop\nhost = 192.168.1.20\nproxiani_port=5000\n"), 0644)
+	got, err = LoadProxyConfig(path, defaults)
+	if err != nil {
+		t.Fatalf("LoadProxyConfig() error = %v", err)
+	}
+	want := defaults
+	want.LocalServer, want.ProxianiPort = "192.168.1.20", "5000"
+	if got != want {
+		t.Errorf("LoadProxyConfig() = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{"host=bad host\n", "proxiani_port=0\n", "mudmixer_port=abc\n", "port=1234\n", "localhost\n"} {
+		os.WriteFile(path, []byte(bad), 0644)
+		if _, err := LoadProxyConfig(path, defaults); err == nil {
+			t.Errorf("LoadProxyConfig(%q) succeeded, want error", bad)
+		}
+	}
 }
 
 // TestUpdateWorldFile_MissingFile tests error handling for missing file
@@ -362,3 +513,372 @@ func TestHasWorldFiles(t *testing.T) {
 		})
 	}
 }
+
+// TestFindWorldFiles tests locating the world file to configure
+func TestFindWorldFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{
+			name:  "preferred file first",
+			files: []string{"worlds/alt.mcl", "worlds/miriani.mcl", "worlds/test/zeta.mcl", "worlds/miriani.mcl.bak"},
+			want:  []string{"worlds/miriani.mcl", "worlds/alt.mcl", "worlds/test/zeta.mcl"},
+		},
+		{
+			name:  "single renamed file",
+			files: []string{"worlds/mine/game.MCL", "worlds/readme.txt"},
+			want:  []string{"worlds/mine/game.MCL"},
+		},
+		{
+			name:  "several candidates sorted",
+			files: []string{"worlds/b.mcl", "worlds/a/a.mcl"},
+			want:  []string{"worlds/a/a.mcl", "worlds/b.mcl"},
+		},
+		{
+			name:  "none found",
+			files: []string{"worlds/readme.txt"},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(f))
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+					t.Fatalf("setup failed: %v", err)
+				}
+			}
+
+			got, err := FindWorldFiles(dir, "worlds", "miriani.mcl", ".mcl")
+			if err != nil {
+				t.Fatalf("FindWorldFiles() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FindWorldFiles() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if want := filepath.Join(dir, filepath.FromSlash(tt.want[i])); got[i] != want {
+					t.Errorf("FindWorldFiles()[%d] = %q, want %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestCheckWritable tests the write probe used to decide on elevation
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := CheckWritable(dir); err != nil {
+		t.Errorf("CheckWritable(existing) error = %v", err)
+	}
+	if err := CheckWritable(filepath.Join(dir, "not", "yet", "created")); err != nil {
+		t.Errorf("CheckWritable(missing) error = %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("CheckWritable() left %d files behind", len(entries))
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permission checks don't apply to root")
+	}
+	readOnly := filepath.Join(dir, "readonly")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	defer os.Chmod(readOnly, 0755)
+	if err := CheckWritable(readOnly); err != nil && !errors.Is(err, os.ErrPermission) {
+		t.Errorf("CheckWritable(read-only) error = %v, want os.ErrPermission", err)
+	}
+}
+
+func TestSharedPrefs(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	copied := filepath.Join(root, "copied")
+	linked := filepath.Join(root, "linked")
+	for _, dir := range []string{target, copied, linked} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	prefs := filepath.Join(target, PrefsFile)
+	if err := os.WriteFile(prefs, []byte("db"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(copied, PrefsFile), []byte("db"), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.Link(prefs, filepath.Join(linked, PrefsFile)); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	others := []string{"", target, copied, linked, filepath.Join(root, "missing")}
+	got := SharedPrefs(target, others)
+	if len(got) != 1 || got[0] != linked {
+		t.Errorf("SharedPrefs() = %v, want [%s]", got, linked)
+	}
+
+	if got := SharedPrefs(copied+"-none", others); got != nil {
+		t.Errorf("SharedPrefs(no prefs) = %v, want nil", got)
+	}
+}
+
+func TestCompareExcludes(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteDefaultExcludes(dir, ".updater-excludes", "stable"); err != nil {
+		t.Fatalf("WriteDefaultExcludes() error = %v", err)
+	}
+	excludes := paths.LoadExcludes(filepath.Join(dir, ".updater-excludes"))
+
+	// The generated file must match the defaults exactly
+	removed, added := CompareExcludes(excludes)
+	if len(removed) != 0 || len(added) != 0 {
+		t.Fatalf("default file: removed = %v, added = %v, want none", removed, added)
+	}
+
+	var edited paths.Excludes
+	for _, entry := range excludes {
+		if entry != "mushclient_prefs.sqlite" {
+			edited = append(edited, entry)
+		}
+	}
+	edited = append(edited, "scripts/security.lua")
+	removed, added = CompareExcludes(edited)
+	if !reflect.DeepEqual(removed, []string{"mushclient_prefs.sqlite"}) {
+		t.Errorf("removed = %v, want [mushclient_prefs.sqlite]", removed)
+	}
+	if !reflect.DeepEqual(added, []string{"scripts/security.lua"}) {
+		t.Errorf("added = %v, want [scripts/security.lua]", added)
+	}
+}
+
+func TestWriteDefaultExcludes_Channel(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteDefaultExcludes(dir, ".updater-excludes", "dev"); err != nil {
+		t.Fatalf("WriteDefaultExcludes() error = %v", err)
+	}
+	excludes := paths.LoadExcludes(filepath.Join(dir, ".updater-excludes"))
+
+	removed, added := CompareExcludes(excludes)
+	if len(removed) != 0 {
+		t.Errorf("removed = %v, want the defaults kept on dev", removed)
+	}
+	if !reflect.DeepEqual(added, ChannelExcludes["dev"]) {
+		t.Errorf("added = %v, want %v", added, ChannelExcludes["dev"])
+	}
+
+	// Custom branches get only the defaults
+	if err := WriteDefaultExcludes(dir, ".updater-excludes", "feature-x"); err != nil {
+		t.Fatalf("WriteDefaultExcludes() error = %v", err)
+	}
+	if _, added := CompareExcludes(paths.LoadExcludes(filepath.Join(dir, ".updater-excludes"))); len(added) != 0 {
+		t.Errorf("custom branch added = %v, want none", added)
+	}
+}
+
+func TestSetWorldConnection(t *testing.T) {
+	original := `<?xml version="1.0" encoding="iso-8859-1"?>
+<muclient>
+<world
+   name="Miriani"
+   site='toastsoft.net'
+   port="1234"
+   use_proxy="0"
+   >
+  <notes>connect to site="toastsoft.net" port="1234"</notes>
+</world>
+</muclient>`
+
+	worldFile := filepath.Join(t.TempDir(), "miriani.mcl")
+	if err := os.WriteFile(worldFile, []byte(original), 0644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	if err := SetWorldConnection(worldFile, "staging.example.com", 4000); err != nil {
+		t.Fatalf("SetWorldConnection() error = %v", err)
+	}
+	data, _ := os.ReadFile(worldFile)
+	content := string(data)
+	for _, want := range []string{`site="staging.example.com"`, `port="4000"`, `name="Miriani"`, `use_proxy="0"`,
+		`<notes>connect to site="toastsoft.net" port="1234"</notes>`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("updated world file missing %s:\n%s", want, content)
+		}
+	}
+
+	// A second change keeps the original backup
+	if err := SetWorldConnection(worldFile, "localhost", 7788); err != nil {
+		t.Fatalf("SetWorldConnection() second call error = %v", err)
+	}
+	backup, err := os.ReadFile(worldFile + ".bak")
+	if err != nil || string(backup) != original {
+		t.Errorf("backup = %q, %v; want the original file", backup, err)
+	}
+
+	for _, tt := range []struct {
+		site string
+		port int
+	}{
+		{"", 4000},
+		{"bad host", 4000},
+		{`evil"host`, 4000},
+		{"localhost", 0},
+		{"localhost", 70000},
+	} {
+		if err := SetWorldConnection(worldFile, tt.site, tt.port); err == nil {
+			t.Errorf("SetWorldConnection(%q, %d) succeeded, want error", tt.site, tt.port)
+		}
+	}
+
+	noWorld := filepath.Join(t.TempDir(), "empty.mcl")
+	os.WriteFile(noWorld, []byte("<muclient></muclient>"), 0644)
+	if err := SetWorldConnection(noWorld, "localhost", 4000); err == nil {
+		t.Error("SetWorldConnection() without a <world> element succeeded, want error")
+	}
+}
+
+// TestRestoreDirectConnection round-trips proxy configuration back to a
+// direct connection
+func TestRestoreDirectConnection(t *testing.T) {
+	cfg := WorldFileConfig{
+		DefaultServer: "toastsoft.net",
+		DefaultPort:   "1234",
+		LocalServer:   "localhost",
+		ProxianiPort:  "1234",
+		MUDMixerPort:  "7788",
+	}
+	original := `<?xml version="1.0" encoding="iso-8859-1"?>
+<muclient>
+<world name="Miriani" site="toastsoft.net" port="1234" use_proxy="0">
+</world>
+</muclient>`
+
+	for _, tt := range []struct {
+		name       string
+		updatePort bool
+	}{
+		{"proxiani", false},
+		{"mudmixer", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			worldFile := filepath.Join(t.TempDir(), "miriani.mcl")
+			if err := os.WriteFile(worldFile, []byte(original), 0644); err != nil {
+				t.Fatalf("setup failed: %v", err)
+			}
+
+			if proxied, err := UsesLocalProxy(worldFile, cfg); err != nil || proxied {
+				t.Errorf("UsesLocalProxy() before update = %v, %v; want false", proxied, err)
+			}
+			if err := UpdateWorldFile(worldFile, tt.updatePort, cfg); err != nil {
+				t.Fatalf("UpdateWorldFile() error = %v", err)
+			}
+			if proxied, err := UsesLocalProxy(worldFile, cfg); err != nil || !proxied {
+				t.Errorf("UsesLocalProxy() after update = %v, %v; want true", proxied, err)
+			}
+			if backup, err := os.ReadFile(worldFile + ".bak"); err != nil || string(backup) != original {
+				t.Errorf("backup = %q, %v; want the original file", backup, err)
+			}
+
+			site, port, err := RestoreDirectConnection(worldFile, cfg)
+			if err != nil {
+				t.Fatalf("RestoreDirectConnection() error = %v", err)
+			}
+			if site != "toastsoft.net" || port != "1234" {
+				t.Errorf("RestoreDirectConnection() = %s:%s, want toastsoft.net:1234", site, port)
+			}
+			data, _ := os.ReadFile(worldFile)
+			if string(data) != original {
+				t.Errorf("restored world file = %q, want the original", data)
+			}
+
+			if _, _, err := RestoreDirectConnection(worldFile, cfg); !errors.Is(err, ErrAlreadyDirect) {
+				t.Errorf("RestoreDirectConnection() on a direct connection error = %v, want ErrAlreadyDirect", err)
+			}
+		})
+	}
+}
+
+// TestRestoreDirectConnection_BackupPort tests that a non-default port kept
+// in the backup is restored instead of the configured default
+func TestRestoreDirectConnection_BackupPort(t *testing.T) {
+	cfg := WorldFileConfig{
+		DefaultServer: "toastsoft.net",
+		DefaultPort:   "1234",
+		LocalServer:   "localhost",
+		ProxianiPort:  "1234",
+		MUDMixerPort:  "7788",
+	}
+	worldFile := filepath.Join(t.TempDir(), "miriani.mcl")
+	os.WriteFile(worldFile+".bak", []byte(`<world site="toastsoft.net" port="4000">`), 0644)
+	os.WriteFile(worldFile, []byte(`<world site="localhost" port="7788">`), 0644)
+
+	if _, _, err := RestoreDirectConnection(worldFile, cfg); err != nil {
+		t.Fatalf("RestoreDirectConnection() error = %v", err)
+	}
+	data, _ := os.ReadFile(worldFile)
+	if want := `<world site="toastsoft.net" port="4000">`; string(data) != want {
+		t.Errorf("restored world file = %s, want %s", data, want)
+	}
+}
+
+// yesPrompter answers every confirmation yes, as -yes does, and every choice
+// with choice
+type yesPrompter struct {
+	choice    bool
+	asked     []string
+	confirmed []string
+}
+
+func (p *yesPrompter) Ask(prompt string) bool {
+	p.asked = append(p.asked, prompt)
+	return p.choice
+}
+
+func (p *yesPrompter) Confirm(prompt string) bool {
+	p.confirmed = append(p.confirmed, prompt)
+	return true
+}
+
+// TestConfirmUninstall tests that uninstall -yes without -all still lets the
+// user choose whether the whole installation is deleted
+func TestConfirmUninstall(t *testing.T) {
+	t.Run("yes without all keeps the installation", func(t *testing.T) {
+		p := &yesPrompter{choice: false}
+		all, proceed := ConfirmUninstall(`C:\Games\Miriani-Next`, false, false, p)
+		if all || !proceed {
+			t.Errorf("ConfirmUninstall() = %v, %v; want false, true", all, proceed)
+		}
+		if len(p.asked) != 1 || !strings.Contains(p.asked[0], "whole installation") {
+			t.Errorf("asked %q, want the whole-installation choice", p.asked)
+		}
+		if len(p.confirmed) != 1 || !strings.Contains(p.confirmed[0], "updater's files") {
+			t.Errorf("confirmed %q, want removing only the updater's files", p.confirmed)
+		}
+	})
+
+	t.Run("user chooses the whole installation", func(t *testing.T) {
+		p := &yesPrompter{choice: true}
+		if all, _ := ConfirmUninstall(`C:\Games\Miriani-Next`, false, false, p); !all {
+			t.Error("ConfirmUninstall() should delete everything when the user chooses to")
+		}
+	})
+
+	t.Run("all flag isn't asked again", func(t *testing.T) {
+		p := &yesPrompter{}
+		all, proceed := ConfirmUninstall(`C:\Games\Miriani-Next`, true, true, p)
+		if !all || !proceed || len(p.asked) != 0 {
+			t.Errorf("ConfirmUninstall() = %v, %v with questions %q; want true, true and none", all, proceed, p.asked)
+		}
+		if len(p.confirmed) != 1 || !strings.Contains(p.confirmed[0], "including your MUSHclient settings") {
+			t.Errorf("confirmed %q, want the purge question", p.confirmed)
+		}
+	})
+}
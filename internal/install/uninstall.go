@@ -0,0 +1,28 @@
+package install
+
+import "fmt"
+
+// UninstallPrompter asks the uninstall questions. Ask chooses between two
+// outcomes and always reaches the user; Confirm may be answered
+// automatically (-yes).
+type UninstallPrompter interface {
+	Ask(prompt string) bool
+	Confirm(prompt string) bool
+}
+
+// ConfirmUninstall asks what an uninstall of baseDir removes. all and purge
+// are the -all and -purge flags; without -all the user chooses whether the
+// whole installation goes too. It returns whether to delete everything and
+// whether to go ahead at all.
+func ConfirmUninstall(baseDir string, all, purge bool, p UninstallPrompter) (removeAll, proceed bool) {
+	if !all {
+		all = p.Ask(fmt.Sprintf("Also delete the whole installation in %s?", baseDir))
+	}
+	question := fmt.Sprintf("Remove the updater's files from %s?", baseDir)
+	if all && purge {
+		question = fmt.Sprintf("Delete everything in %s, including your MUSHclient settings?", baseDir)
+	} else if all {
+		question = fmt.Sprintf("Delete %s, keeping only your MUSHclient settings?", baseDir)
+	}
+	return all, p.Confirm(question)
+}
@@ -1,11 +1,18 @@
 package manifest
 
 import (
+	"crypto/sha1"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/distantorigin/next-launcher/internal/paths"
 )
 
 // FileInfo represents a file in the manifest
@@ -13,6 +20,7 @@ type FileInfo struct {
 	Name string `json:"name"`
 	Hash string `json:"hash"`
 	URL  string `json:"url"`
+	Size int64  `json:"size,omitempty"` // Bytes; 0 if unknown (manifests from older versions)
 }
 
 // TreeItem represents a file in the Git tree
@@ -20,6 +28,17 @@ type TreeItem struct {
 	Path string
 	Type string
 	SHA  string
+	Size int64
+}
+
+// TotalSize adds up the sizes of files. Files with no recorded size count as
+// zero, so the total is a lower bound when any are unknown.
+func TotalSize(files []FileInfo) int64 {
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	return total
 }
 
 // Config holds configuration for manifest operations
@@ -30,6 +49,10 @@ type Config struct {
 	ChannelFlag  string
 	QuietFlag    bool
 	VerboseFlag  bool
+
+	// Excludes are the user's patterns from .updater-excludes (see
+	// paths.LoadExcludes), applied on top of the built-in exclusions
+	Excludes paths.Excludes
 }
 
 // Manager handles manifest operations
@@ -56,17 +79,8 @@ func (m *Manager) LoadLocal() (map[string]FileInfo, error) {
 		return nil, fmt.Errorf("failed to read local manifest: %w", err)
 	}
 
-	// Strip comment lines (lines starting with //) before parsing JSON
-	lines := strings.Split(string(data), "\n")
-	var jsonLines []string
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Skip lines that start with // (comments)
-		if !strings.HasPrefix(trimmed, "//") {
-			jsonLines = append(jsonLines, line)
-		}
-	}
-	cleanedData := strings.Join(jsonLines, "\n")
+	// Strip // comments (whole-line or trailing) before parsing JSON
+	cleanedData := StripComments(string(data))
 
 	var manifest map[string]FileInfo
 	if err := json.Unmarshal([]byte(cleanedData), &manifest); err != nil {
@@ -75,6 +89,49 @@ func (m *Manager) LoadLocal() (map[string]FileInfo, error) {
 	return manifest, nil
 }
 
+// StripComments removes // comments from JSON text, whether they occupy a
+// whole line or trail a value. Sequences inside string literals (such as the
+// "//" in a URL) are left untouched.
+func StripComments(data string) string {
+	var out strings.Builder
+	out.Grow(len(data))
+
+	inString := false
+	escaped := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		} else if c == '/' && i+1 < len(data) && data[i+1] == '/' {
+			// Skip to end of line, keeping the newline itself
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out.WriteByte('\n')
+			}
+			continue
+		}
+		out.WriteByte(c)
+	}
+
+	return out.String()
+}
+
 // BuildFromTree builds a manifest from a Git tree
 func (m *Manager) BuildFromTree(ref string, tree []TreeItem, normalizePath func(string) string, getRawURL func(string, string) string) (map[string]FileInfo, error) {
 	if !m.config.QuietFlag && m.config.VerboseFlag {
@@ -104,6 +161,7 @@ func (m *Manager) BuildFromTree(ref string, tree []TreeItem, normalizePath func(
 			Name: normalizedPath,
 			Hash: item.SHA, // Git SHA-1 hash from GitHub API
 			URL:  rawURL,
+			Size: item.Size,
 		}
 	}
 
@@ -114,7 +172,9 @@ func (m *Manager) BuildFromTree(ref string, tree []TreeItem, normalizePath func(
 	return manifest, nil
 }
 
-// ShouldExclude determines if a path should be excluded from the manifest
+// ShouldExclude determines if a path should be excluded from the manifest.
+// The built-in list always applies. World files are excluded by default, but
+// a negation in Config.Excludes (e.g. !worlds/managed.mcl) can re-include one.
 func (m *Manager) ShouldExclude(path string, normalizePath func(string) string) bool {
 	// Normalize the path for case-insensitive comparison
 	normalizedPath := strings.ToLower(normalizePath(path))
@@ -142,11 +202,9 @@ func (m *Manager) ShouldExclude(path string, normalizePath func(string) string)
 	}
 
 	// Exclude .mcl files in worlds directory (user configuration files)
-	if strings.HasPrefix(normalizedPath, m.config.WorldsDir+"/") && strings.HasSuffix(normalizedPath, m.config.WorldFileExt) {
-		return true
-	}
+	worldFile := strings.HasPrefix(normalizedPath, m.config.WorldsDir+"/") && strings.HasSuffix(normalizedPath, m.config.WorldFileExt)
 
-	return false
+	return m.config.Excludes.Match(normalizedPath, worldFile)
 }
 
 // Save saves a manifest to the local filesystem
@@ -155,7 +213,11 @@ func (m *Manager) Save(manifest map[string]FileInfo, denormalizePath func(string
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
+	return m.SaveTo(baseDir, manifest, denormalizePath)
+}
 
+// SaveTo saves a manifest into baseDir rather than the working directory
+func (m *Manager) SaveTo(baseDir string, manifest map[string]FileInfo, denormalizePath func(string) string) error {
 	// Only save files to local manifest that exist both in remote AND locally on disk
 	// This ensures the local manifest accurately represents what's actually installed
 	localManifest := make(map[string]FileInfo)
@@ -173,9 +235,230 @@ func (m *Manager) Save(manifest map[string]FileInfo, denormalizePath func(string
 		return fmt.Errorf("failed to marshal manifest: %w", err)
 	}
 
-	if err := os.WriteFile(filepath.Join(baseDir, m.config.ManifestFile), append(data, '\n'), 0644); err != nil {
+	if err := paths.WriteFileAtomic(filepath.Join(baseDir, m.config.ManifestFile), append(data, '\n'), 0644); err != nil {
 		return fmt.Errorf("failed to save manifest: %w", err)
 	}
 
 	return nil
 }
+
+// GitBlobSHA computes the Git blob hash of data (the SHA-1 of
+// "blob <size>\x00" followed by the content), matching the hashes the
+// GitHub tree API reports and the manifest records
+func GitBlobSHA(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// GitBlobSHAFile is GitBlobSHA for the file at path, read as it is hashed
+// rather than loaded whole
+func GitBlobSHAFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", info.Size())
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Verify re-hashes files under baseDir and returns the names (sorted) whose
+// content is missing or doesn't match the recorded hash. denormalizePath maps
+// a manifest path to the on-disk relative path.
+func Verify(baseDir string, files []FileInfo, denormalizePath func(string) string) []string {
+	var mismatched []string
+	for _, info := range files {
+		hash, err := GitBlobSHAFile(filepath.Join(baseDir, denormalizePath(info.Name)))
+		if err != nil || hash != info.Hash {
+			mismatched = append(mismatched, info.Name)
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched
+}
+
+// SnapshotSuffix is appended to the names of files saved by SnapshotFiles
+const SnapshotSuffix = ".prev"
+
+// SnapshotFiles copies each named file in baseDir to destDir/<name>.prev so
+// the pre-update state can be restored later. Each copy is written to a
+// temporary file and renamed into place, so a snapshot is never half written.
+// Files that don't exist are skipped.
+func SnapshotFiles(baseDir, destDir string, names ...string) error {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", destDir, err)
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(baseDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		target := filepath.Join(destDir, name+SnapshotSuffix)
+		if err := paths.WriteFileAtomic(target, data, 0644); err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SnapshotPrevious is the first step of an update that changes files: it
+// clears oldDir, which holds what the previous update removed, then snapshots
+// the manifest and versionFile in baseDir into it (see SnapshotFiles), so a
+// rollback can restore the state from before the update. Runs with nothing to
+// apply never call it, so the snapshot lasts until the next real update.
+func (m *Manager) SnapshotPrevious(baseDir, oldDir, versionFile string) error {
+	if err := os.RemoveAll(oldDir); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", oldDir, err)
+	}
+	return SnapshotFiles(baseDir, oldDir, m.config.ManifestFile, versionFile)
+}
+
+// AuditReport is the result of Audit. Every list is sorted.
+type AuditReport struct {
+	Checked    int      // Manifest entries examined
+	Missing    []string // In the manifest but not on disk
+	Mismatched []string // On disk with a different hash than recorded
+	Extra      []string // On disk but not in the manifest (and not skipped)
+}
+
+// Audit compares the files under baseDir with the local manifest without
+// changing anything. skip is called with normalized paths (directories with a
+// trailing slash) to leave out of the manifest check and the extra-file scan.
+func Audit(baseDir string, local map[string]FileInfo, normalizePath, denormalizePath func(string) string, skip func(string) bool) (AuditReport, error) {
+	var report AuditReport
+	known := make(map[string]bool, len(local))
+	for name, info := range local {
+		normalized := normalizePath(name)
+		known[strings.ToLower(normalized)] = true
+		if skip(normalized) {
+			continue
+		}
+		report.Checked++
+		hash, err := GitBlobSHAFile(filepath.Join(baseDir, denormalizePath(normalized)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			report.Missing = append(report.Missing, normalized)
+		case err != nil || hash != info.Hash:
+			report.Mismatched = append(report.Mismatched, normalized)
+		}
+	}
+
+	extra, err := untracked(baseDir, known, normalizePath, skip)
+	if err != nil {
+		return report, err
+	}
+	report.Extra = extra
+
+	sort.Strings(report.Missing)
+	sort.Strings(report.Mismatched)
+	return report, nil
+}
+
+// Untracked lists the files under baseDir that aren't in the local manifest,
+// without hashing anything. skip works as for Audit. The list is sorted.
+func Untracked(baseDir string, local map[string]FileInfo, normalizePath func(string) string, skip func(string) bool) ([]string, error) {
+	known := make(map[string]bool, len(local))
+	for name := range local {
+		known[strings.ToLower(normalizePath(name))] = true
+	}
+	return untracked(baseDir, known, normalizePath, skip)
+}
+
+// untracked walks baseDir for files whose lowercased normalized path isn't
+// in known
+func untracked(baseDir string, known map[string]bool, normalizePath func(string) string, skip func(string) bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(baseDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		normalized := normalizePath(rel)
+		if d.IsDir() {
+			if skip(normalized + "/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !known[strings.ToLower(normalized)] && !skip(normalized) {
+			files = append(files, normalized)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", baseDir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Diff is the comparison between a local and a remote manifest. Paths are
+// normalized with the function given to Compare; every list is sorted by path.
+type Diff struct {
+	Added    []FileInfo // Only in the remote manifest
+	Changed  []FileInfo // In both, with a different hash (remote entry)
+	Removed  []string   // Only in the local manifest
+	Excluded []string   // Remote paths skipped because isExcluded matched
+}
+
+// Compare diffs local against remote. Remote entries for which isExcluded
+// returns true are left out of the comparison (and so never count as added,
+// changed or keeping a local file alive) and reported in Excluded instead.
+func Compare(local, remote map[string]FileInfo, normalizePath func(string) string, isExcluded func(string) bool) Diff {
+	normalizedLocal := make(map[string]FileInfo, len(local))
+	for path, info := range local {
+		normalizedLocal[normalizePath(path)] = info
+	}
+
+	var diff Diff
+	normalizedRemote := make(map[string]FileInfo, len(remote))
+	for path, info := range remote {
+		normalized := normalizePath(path)
+		if isExcluded != nil && isExcluded(normalized) {
+			diff.Excluded = append(diff.Excluded, normalized)
+			continue
+		}
+		normalizedRemote[normalized] = info
+	}
+
+	for path, info := range normalizedRemote {
+		if existing, ok := normalizedLocal[path]; !ok {
+			diff.Added = append(diff.Added, info)
+		} else if existing.Hash != info.Hash {
+			diff.Changed = append(diff.Changed, info)
+		}
+	}
+	for path := range normalizedLocal {
+		if _, ok := normalizedRemote[path]; !ok {
+			diff.Removed = append(diff.Removed, path)
+		}
+	}
+
+	byName := func(files []FileInfo) {
+		sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	}
+	byName(diff.Added)
+	byName(diff.Changed)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Excluded)
+	return diff
+}
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+
+	"github.com/distantorigin/next-launcher/internal/paths"
 )
 
 // TestLoadLocal_WithComments tests loading manifest with // comments
@@ -67,6 +71,94 @@ func TestLoadLocal_WithComments(t *testing.T) {
 	}
 }
 
+// TestLoadLocal_TrailingComments tests trailing // comments after values,
+// while preserving // sequences inside URLs
+func TestLoadLocal_TrailingComments(t *testing.T) {
+	tempDir := t.TempDir()
+	manifestPath := filepath.Join(tempDir, ".manifest")
+
+	content := `{
+  "file1.txt": { // first file
+    "name": "file1.txt", // the name
+    "hash": "abc123",
+    "url": "https://example.com/file1.txt" // fetched over https
+  },
+  "docs/notes.txt": {
+    "name": "docs/notes.txt",
+    "hash": "def456",
+    "url": "https://example.com//double//slash.txt"
+  }
+}
+// trailing comment with no newline`
+
+	if err := os.WriteFile(manifestPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test manifest: %v", err)
+	}
+
+	originalDir, _ := os.Getwd()
+	os.Chdir(tempDir)
+	defer os.Chdir(originalDir)
+
+	manager := NewManager(Config{
+		ManifestFile: ".manifest",
+	})
+
+	manifest, err := manager.LoadLocal()
+	if err != nil {
+		t.Fatalf("LoadLocal() error = %v", err)
+	}
+
+	if got := manifest["file1.txt"].URL; got != "https://example.com/file1.txt" {
+		t.Errorf("file1.txt url = %q, want https://example.com/file1.txt", got)
+	}
+	if got := manifest["docs/notes.txt"].URL; got != "https://example.com//double//slash.txt" {
+		t.Errorf("docs/notes.txt url = %q, want https://example.com//double//slash.txt", got)
+	}
+}
+
+// TestStripComments tests comment stripping around string literals
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "whole line comment",
+			input: "// comment\n{}",
+			want:  "\n{}",
+		},
+		{
+			name:  "trailing comment",
+			input: `{"a": 1} // note`,
+			want:  `{"a": 1} `,
+		},
+		{
+			name:  "url in string is preserved",
+			input: `{"url": "https://example.com/x"}`,
+			want:  `{"url": "https://example.com/x"}`,
+		},
+		{
+			name:  "escaped quote inside string",
+			input: `{"a": "say \"//hi\""} // gone`,
+			want:  `{"a": "say \"//hi\""} `,
+		},
+		{
+			name:  "single slash is not a comment",
+			input: `{"a": 4/2}`,
+			want:  `{"a": 4/2}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripComments(tt.input); got != tt.want {
+				t.Errorf("StripComments(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestLoadLocal_InvalidJSON tests error handling for corrupt manifest
 func TestLoadLocal_InvalidJSON(t *testing.T) {
 	tempDir := t.TempDir()
@@ -218,7 +310,7 @@ func TestBuildFromTree(t *testing.T) {
 	}
 
 	tree := []TreeItem{
-		{Path: "README.md", Type: "blob", SHA: "abc123"},
+		{Path: "README.md", Type: "blob", SHA: "abc123", Size: 1200},
 		{Path: "src/main.go", Type: "blob", SHA: "def456"},
 		{Path: ".git/config", Type: "blob", SHA: "should-exclude"},
 		{Path: "updater.exe", Type: "blob", SHA: "should-exclude-exe"},
@@ -261,6 +353,99 @@ func TestBuildFromTree(t *testing.T) {
 	if manifest["README.md"].URL != "https://raw.githubusercontent.com/owner/repo/main/README.md" {
 		t.Errorf("README.md URL = %s, want correct raw URL", manifest["README.md"].URL)
 	}
+
+	if manifest["README.md"].Size != 1200 {
+		t.Errorf("README.md size = %d, want 1200", manifest["README.md"].Size)
+	}
+	if manifest["src/main.go"].Size != 0 {
+		t.Errorf("src/main.go size = %d, want 0 (unknown)", manifest["src/main.go"].Size)
+	}
+}
+
+// TestBuildFromTree_UserExcludes tests that patterns from the user's
+// excludes file are left out of the manifest alongside the built-in ones
+func TestBuildFromTree_UserExcludes(t *testing.T) {
+	excludesPath := filepath.Join(t.TempDir(), ".updater-excludes")
+	content := "# My changes\nworlds/plugins/myplugin/\n"
+	if err := os.WriteFile(excludesPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(Config{
+		ManifestFile: ".manifest",
+		WorldsDir:    "worlds",
+		WorldFileExt: ".mcl",
+		QuietFlag:    true,
+		Excludes:     paths.LoadExcludes(excludesPath),
+	})
+	normalize := func(p string) string {
+		return strings.ReplaceAll(p, "\\", "/")
+	}
+	getRawURL := func(ref, path string) string {
+		return "https://raw.githubusercontent.com/owner/repo/" + ref + "/" + path
+	}
+
+	tree := []TreeItem{
+		{Path: "worlds/plugins/myplugin/myplugin.xml", Type: "blob", SHA: "a"},
+		{Path: "worlds\\plugins\\MyPlugin\\sounds\\beep.ogg", Type: "blob", SHA: "b"},
+		{Path: "worlds/plugins/myplugin.xml", Type: "blob", SHA: "c"},
+		{Path: "worlds/plugins/myplugin2/other.xml", Type: "blob", SHA: "d"},
+		{Path: "worlds/miriani.mcl", Type: "blob", SHA: "e"},
+	}
+
+	manifest, err := manager.BuildFromTree("main", tree, normalize, getRawURL)
+	if err != nil {
+		t.Fatalf("BuildFromTree() error = %v", err)
+	}
+
+	var got []string
+	for name := range manifest {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	// The prefix only covers the folder itself, case-insensitively; the
+	// built-in .mcl exclusion still applies
+	want := []string{"worlds/plugins/myplugin.xml", "worlds/plugins/myplugin2/other.xml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildFromTree() files = %v, want %v", got, want)
+	}
+}
+
+// TestShouldExclude_Negation tests that a negated user pattern re-includes a
+// world file but can't override the rest of the built-in list
+func TestShouldExclude_Negation(t *testing.T) {
+	manager := NewManager(Config{
+		WorldsDir:    "worlds",
+		WorldFileExt: ".mcl",
+		Excludes:     paths.Excludes{"worlds/*.mcl", "!worlds/managed.mcl", "!update.exe"},
+	})
+	normalize := func(p string) string { return p }
+
+	tests := map[string]bool{
+		"worlds/managed.mcl": false,
+		"worlds/miriani.mcl": true,
+		"update.exe":         true,
+	}
+	for path, want := range tests {
+		if got := manager.ShouldExclude(path, normalize); got != want {
+			t.Errorf("ShouldExclude(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// TestTotalSize tests adding up file sizes, with unknown sizes counting as zero
+func TestTotalSize(t *testing.T) {
+	files := []FileInfo{
+		{Name: "a.lua", Size: 1000},
+		{Name: "b.lua"},
+		{Name: "c.lua", Size: 24},
+	}
+	if got := TotalSize(files); got != 1024 {
+		t.Errorf("TotalSize() = %d, want 1024", got)
+	}
+	if got := TotalSize(nil); got != 0 {
+		t.Errorf("TotalSize(nil) = %d, want 0", got)
+	}
 }
 
 // TestSave tests saving manifest to file
@@ -336,6 +521,44 @@ func TestSave(t *testing.T) {
 	}
 }
 
+// TestSave_Atomic tests that a leftover partial write doesn't affect Save,
+// which always leaves a complete manifest and no temporary files of its own
+func TestSave_Atomic(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "file1.txt"), []byte("content1"), 0644)
+
+	// An earlier run died mid-write, and the manifest is from before that
+	os.WriteFile(filepath.Join(tempDir, ".manifest.tmp"), []byte(`{"file1.txt": {"name": "fi`), 0644)
+	os.WriteFile(filepath.Join(tempDir, ".manifest"), []byte(`{"old.txt": {"name": "old.txt"}}`), 0644)
+
+	manager := NewManager(Config{ManifestFile: ".manifest"})
+	denormalize := func(p string) string { return p }
+	manifest := map[string]FileInfo{
+		"file1.txt": {Name: "file1.txt", Hash: "abc123", URL: "https://example.com/file1.txt"},
+	}
+
+	if err := manager.SaveTo(tempDir, manifest, denormalize); err != nil {
+		t.Fatalf("SaveTo() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, ".manifest"))
+	if err != nil {
+		t.Fatalf("failed to read saved manifest: %v", err)
+	}
+	var savedManifest map[string]FileInfo
+	if err := json.Unmarshal(data, &savedManifest); err != nil {
+		t.Fatalf("saved manifest is not valid JSON: %v", err)
+	}
+	if len(savedManifest) != 1 || savedManifest["file1.txt"].Hash != "abc123" {
+		t.Errorf("saved manifest = %v, want only file1.txt", savedManifest)
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(tempDir, ".manifest.*.tmp"))
+	if len(leftovers) != 0 {
+		t.Errorf("SaveTo() left temporary files: %v", leftovers)
+	}
+}
+
 // TestSave_EmptyManifest tests saving empty manifest
 func TestSave_EmptyManifest(t *testing.T) {
 	tempDir := t.TempDir()
@@ -393,3 +616,209 @@ func TestNewManager(t *testing.T) {
 		t.Errorf("NewManager() config.ManifestFile = %s, want .manifest", manager.config.ManifestFile)
 	}
 }
+
+// TestGitBlobSHA tests that hashes match what Git reports
+func TestGitBlobSHA(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty file", "", "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"},
+		{"hello", "hello\n", "ce013625030ba8dba906f756967f9e9ca394464a"},
+		{"hello world", "hello world\n", "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GitBlobSHA([]byte(tt.content)); got != tt.want {
+				t.Errorf("GitBlobSHA() = %s, want %s", got, tt.want)
+			}
+
+			path := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("setup failed: %v", err)
+			}
+			got, err := GitBlobSHAFile(path)
+			if err != nil {
+				t.Fatalf("GitBlobSHAFile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GitBlobSHAFile() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := GitBlobSHAFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("GitBlobSHAFile() should fail for a missing file")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	write("good.txt", "hello\n")
+	write("bad.txt", "truncated")
+
+	// "hello\n" as a git blob
+	const helloSHA = "ce013625030ba8dba906f756967f9e9ca394464a"
+	files := []FileInfo{
+		{Name: "good.txt", Hash: helloSHA},
+		{Name: "bad.txt", Hash: helloSHA},
+		{Name: "missing.txt", Hash: helloSHA},
+	}
+
+	got := Verify(dir, files, func(p string) string { return p })
+	want := []string{"bad.txt", "missing.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Verify() = %v, want %v", got, want)
+	}
+
+	if got := Verify(dir, files[:1], func(p string) string { return p }); len(got) != 0 {
+		t.Errorf("Verify() = %v, want no mismatches", got)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	// "hello\n" as a git blob
+	const helloSHA = "ce013625030ba8dba906f756967f9e9ca394464a"
+	write("scripts/good.lua", "hello\n")
+	write("scripts/bad.lua", "edited")
+	write("scripts/extra.lua", "mine")
+	write("mushclient.ini", "user settings")
+	write(".old/scripts/removed.lua", "old")
+
+	local := map[string]FileInfo{
+		"scripts/good.lua":    {Hash: helloSHA},
+		"scripts/bad.lua":     {Hash: helloSHA},
+		"scripts/missing.lua": {Hash: helloSHA},
+		"mushclient.ini":      {Hash: helloSHA},
+	}
+	skip := func(p string) bool { return p == "mushclient.ini" || p == ".old/" }
+	identity := func(p string) string { return p }
+
+	report, err := Audit(dir, local, filepath.ToSlash, identity, skip)
+	if err != nil {
+		t.Fatalf("Audit() error = %v", err)
+	}
+	if report.Checked != 3 {
+		t.Errorf("Checked = %d, want 3", report.Checked)
+	}
+	if !reflect.DeepEqual(report.Missing, []string{"scripts/missing.lua"}) {
+		t.Errorf("Missing = %v, want [scripts/missing.lua]", report.Missing)
+	}
+	if !reflect.DeepEqual(report.Mismatched, []string{"scripts/bad.lua"}) {
+		t.Errorf("Mismatched = %v, want [scripts/bad.lua]", report.Mismatched)
+	}
+	if !reflect.DeepEqual(report.Extra, []string{"scripts/extra.lua"}) {
+		t.Errorf("Extra = %v, want [scripts/extra.lua]", report.Extra)
+	}
+}
+
+// TestUntracked tests listing files missing from the manifest, matched
+// case-insensitively and honoring skip for files and whole directories
+func TestUntracked(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Scripts/Known.lua", "scripts/stray.lua", "worlds/plugins/old.xml", "logs/today.txt", "mushclient.ini"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	local := map[string]FileInfo{"scripts/known.lua": {}, "scripts/deleted.lua": {}}
+	skip := func(p string) bool { return p == "mushclient.ini" || p == "logs/" }
+
+	got, err := Untracked(dir, local, filepath.ToSlash, skip)
+	if err != nil {
+		t.Fatalf("Untracked() error = %v", err)
+	}
+	want := []string{"scripts/stray.lua", "worlds/plugins/old.xml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Untracked() = %v, want %v", got, want)
+	}
+}
+
+func TestSnapshotFiles(t *testing.T) {
+	base := t.TempDir()
+	dest := filepath.Join(base, ".old")
+	os.WriteFile(filepath.Join(base, ".manifest"), []byte(`{"a.lua": {}}`), 0644)
+
+	if err := SnapshotFiles(base, dest, ".manifest", "version.json"); err != nil {
+		t.Fatalf("SnapshotFiles() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, ".manifest"+SnapshotSuffix))
+	if err != nil || string(data) != `{"a.lua": {}}` {
+		t.Errorf("snapshot = %q, %v; want the original manifest", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "version.json"+SnapshotSuffix)); !os.IsNotExist(err) {
+		t.Errorf("missing source should not be snapshotted, stat error = %v", err)
+	}
+
+	// A later snapshot replaces the earlier one and leaves no temp files
+	os.WriteFile(filepath.Join(base, ".manifest"), []byte(`{}`), 0644)
+	if err := SnapshotFiles(base, dest, ".manifest"); err != nil {
+		t.Fatalf("SnapshotFiles() second call error = %v", err)
+	}
+	entries, _ := os.ReadDir(dest)
+	if len(entries) != 1 {
+		t.Errorf("snapshot dir has %d entries, want 1", len(entries))
+	}
+}
+
+func TestCompare(t *testing.T) {
+	local := map[string]FileInfo{
+		"same.lua":           {Name: "same.lua", Hash: "a"},
+		"scripts\\old.lua":   {Name: "scripts/old.lua", Hash: "b"},
+		"changed.lua":        {Name: "changed.lua", Hash: "c"},
+		"worlds/miriani.mcl": {Name: "worlds/miriani.mcl", Hash: "d"},
+	}
+	remote := map[string]FileInfo{
+		"same.lua":           {Name: "same.lua", Hash: "a"},
+		"changed.lua":        {Name: "changed.lua", Hash: "c2"},
+		"new.lua":            {Name: "new.lua", Hash: "e"},
+		"worlds/miriani.mcl": {Name: "worlds/miriani.mcl", Hash: "d2"},
+	}
+	normalize := func(p string) string { return strings.ReplaceAll(p, "\\", "/") }
+	excluded := func(p string) bool { return strings.HasSuffix(p, ".mcl") }
+
+	diff := Compare(local, remote, normalize, excluded)
+
+	names := func(files []FileInfo) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+	if got := names(diff.Added); !reflect.DeepEqual(got, []string{"new.lua"}) {
+		t.Errorf("Added = %v, want [new.lua]", got)
+	}
+	if got := names(diff.Changed); !reflect.DeepEqual(got, []string{"changed.lua"}) {
+		t.Errorf("Changed = %v, want [changed.lua]", got)
+	}
+	// Excluded remote entries don't protect their local counterpart
+	if want := []string{"scripts/old.lua", "worlds/miriani.mcl"}; !reflect.DeepEqual(diff.Removed, want) {
+		t.Errorf("Removed = %v, want %v", diff.Removed, want)
+	}
+	if want := []string{"worlds/miriani.mcl"}; !reflect.DeepEqual(diff.Excluded, want) {
+		t.Errorf("Excluded = %v, want %v", diff.Excluded, want)
+	}
+}
@@ -3,6 +3,7 @@ package paths
 import (
 	"bufio"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -45,6 +46,38 @@ func FindActual(targetPath string) (string, error) {
 	return targetPath, nil
 }
 
+// WriteFileAtomic writes data to a temporary file in the same directory as
+// path and renames it into place, so a crash mid-write leaves either the old
+// file or the new one, never a truncated mix. Renames within a volume are
+// atomic on Windows as well. The temporary file is removed on failure.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 // IsUserConfig checks if a path is a user configuration file that should be preserved
 func IsUserConfig(path string) bool {
 	normalizedPath := strings.ToLower(Normalize(path))
@@ -78,9 +111,15 @@ func IsUserConfig(path string) bool {
 	return false
 }
 
+// Excludes is an ordered list of exclusion patterns, normalized and
+// lowercased. A pattern starting with "!" is a negation that re-includes
+// paths an earlier pattern excluded. Order matters: like .gitignore, the last
+// pattern matching a path decides.
+type Excludes []string
+
 // LoadExcludes reads exclusion patterns from an excludes file
-func LoadExcludes(excludesPath string) map[string]struct{} {
-	excludes := make(map[string]struct{})
+func LoadExcludes(excludesPath string) Excludes {
+	var excludes Excludes
 
 	file, err := os.Open(excludesPath)
 	if err != nil {
@@ -91,34 +130,136 @@ func LoadExcludes(excludesPath string) map[string]struct{} {
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
-		if line != "" && !strings.HasPrefix(line, "#") {
-			normalized := strings.ToLower(Normalize(line))
-			excludes[normalized] = struct{}{}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(line, "!")
+		if negate {
+			line = strings.TrimSpace(line[1:])
+			if line == "" {
+				continue
+			}
+		}
+		normalized := strings.ToLower(Normalize(line))
+		// Keep the trailing slash Clean drops; matching needs it for directories
+		if strings.HasSuffix(line, "/") || strings.HasSuffix(line, `\`) {
+			normalized += "/"
 		}
+		if negate {
+			normalized = "!" + normalized
+		}
+		excludes = append(excludes, normalized)
 	}
 	return excludes
 }
 
-// MatchesExclusion checks if a path matches any exclusion pattern
-func MatchesExclusion(path string, excludes map[string]struct{}) bool {
-	normalizedPath := strings.ToLower(Normalize(path))
-
-	for pattern := range excludes {
-		if normalizedPath == pattern {
+// UnderPrefix reports whether path falls under one of the prefixes. Prefixes
+// match whole path segments, case-insensitively; with no prefixes every path
+// is under one.
+func UnderPrefix(p string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	p = strings.ToLower(Normalize(p))
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(strings.ToLower(Normalize(prefix)), "/")
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
 			return true
 		}
+	}
+	return false
+}
 
-		if strings.Contains(pattern, "*") {
-			matched, _ := filepath.Match(pattern, normalizedPath)
-			if matched {
-				return true
-			}
+// LoadRestartPatterns reads a restart paths file (same syntax as an excludes
+// file), returning nil if it doesn't exist
+func LoadRestartPatterns(restartPath string) Excludes {
+	if _, err := os.Stat(restartPath); err != nil {
+		return nil
+	}
+	return LoadExcludes(restartPath)
+}
+
+// NeedsRestart reports whether updating path only takes effect after
+// MUSHclient restarts. With no patterns, MUSHclient.exe and DLLs are the only
+// such files.
+func NeedsRestart(path string, patterns Excludes) bool {
+	if patterns != nil {
+		return MatchesExclusion(path, patterns)
+	}
+	lowerName := strings.ToLower(path)
+	return lowerName == "mushclient.exe" || strings.HasSuffix(lowerName, ".dll")
+}
+
+// MatchesExclusion checks if a path matches the exclusion patterns
+func MatchesExclusion(path string, excludes Excludes) bool {
+	return excludes.Match(path, false)
+}
+
+// Match runs path through the patterns in order, starting from excluded,
+// and reports whether it ends up excluded. A matching pattern excludes the
+// path and a matching negation re-includes it, so the last match wins. The
+// starting value lets a built-in default be overridden by the file.
+func (e Excludes) Match(path string, excluded bool) bool {
+	normalizedPath := strings.ToLower(Normalize(path))
+	for _, pattern := range e {
+		negate := strings.HasPrefix(pattern, "!")
+		if matchesPattern(normalizedPath, strings.TrimPrefix(pattern, "!")) {
+			excluded = !negate
 		}
+	}
+	return excluded
+}
+
+// Reincludes reports whether the last pattern matching path is a negation,
+// i.e. the file explicitly asks for path to be updated
+func (e Excludes) Reincludes(path string) bool {
+	return !e.Match(path, true)
+}
 
-		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(normalizedPath, pattern) {
+// matchesPattern checks one normalized, lowercased pattern against a path
+func matchesPattern(normalizedPath, pattern string) bool {
+	if normalizedPath == pattern {
+		return true
+	}
+
+	if strings.Contains(pattern, "**") {
+		// A directory pattern covers everything below it
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return matchSegments(strings.Split(pattern, "/"), strings.Split(normalizedPath, "/"))
+	}
+
+	if strings.Contains(pattern, "*") {
+		matched, _ := filepath.Match(pattern, normalizedPath)
+		if matched {
 			return true
 		}
 	}
 
-	return false
+	return strings.HasSuffix(pattern, "/") && strings.HasPrefix(normalizedPath, pattern)
+}
+
+// matchSegments matches a pattern against a path one folder at a time. A
+// "**" segment matches any number of folders, including none; any other
+// segment is a path.Match pattern for a single name.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
 }
@@ -204,20 +204,74 @@ func TestMatchesExclusion(t *testing.T) {
 			patterns: []string{"*.log", "*.txt"},
 			want:     true,
 		},
+		{
+			name:     "recursive glob at depth",
+			path:     "worlds/a/b/c/session.log",
+			patterns: []string{"worlds/**/*.log"},
+			want:     true,
+		},
+		{
+			name:     "recursive glob matches zero folders",
+			path:     "worlds/session.log",
+			patterns: []string{"worlds/**/*.log"},
+			want:     true,
+		},
+		{
+			name:     "recursive glob respects the prefix",
+			path:     "logs/a/session.log",
+			patterns: []string{"worlds/**/*.log"},
+			want:     false,
+		},
+		{
+			name:     "recursive glob with folder in the middle",
+			path:     "worlds/plugins/foo/cache/x.tmp",
+			patterns: []string{"worlds/**/cache/*.tmp"},
+			want:     true,
+		},
+		{
+			name:     "recursive glob with folder in the middle - wrong folder",
+			path:     "worlds/plugins/foo/state/x.tmp",
+			patterns: []string{"worlds/**/cache/*.tmp"},
+			want:     false,
+		},
+		{
+			name:     "recursive glob single star stays within a folder",
+			path:     "worlds/plugins/cache/sub/x.tmp",
+			patterns: []string{"worlds/**/cache/*.tmp"},
+			want:     false,
+		},
+		{
+			name:     "recursive glob is case-insensitive",
+			path:     "Worlds/Plugins/Cache/X.TMP",
+			patterns: []string{"worlds/**/cache/*.tmp"},
+			want:     true,
+		},
+		{
+			name:     "leading recursive glob",
+			path:     "a/b/thumbs.db",
+			patterns: []string{"**/thumbs.db"},
+			want:     true,
+		},
+		{
+			name:     "recursive directory pattern",
+			path:     "worlds/plugins/foo/cache/deep/x.bin",
+			patterns: []string{"**/cache/"},
+			want:     true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create excludes map from patterns
-			excludes := make(map[string]struct{})
+			// Create excludes list from patterns
+			var excludes Excludes
 			for _, pattern := range tt.patterns {
 				// Preserve trailing slash for directory patterns
 				// (Normalize() would remove it via filepath.Clean)
 				if strings.HasSuffix(pattern, "/") {
 					normalized := strings.ToLower(strings.ReplaceAll(pattern, "\\", "/"))
-					excludes[normalized] = struct{}{}
+					excludes = append(excludes, normalized)
 				} else {
-					excludes[strings.ToLower(Normalize(pattern))] = struct{}{}
+					excludes = append(excludes, strings.ToLower(Normalize(pattern)))
 				}
 			}
 
@@ -258,11 +312,157 @@ temp/
 		t.Fatalf("LoadExcludes() returned %d patterns, want %d", len(excludes), len(expected))
 	}
 
-	// Check each expected pattern exists in the map
-	for _, pattern := range expected {
-		normalized := strings.ToLower(Normalize(pattern))
-		if _, exists := excludes[normalized]; !exists {
-			t.Errorf("LoadExcludes() missing pattern %q", pattern)
+	// Patterns keep their order; directories keep their trailing slash
+	for i, pattern := range expected {
+		if excludes[i] != pattern {
+			t.Errorf("LoadExcludes()[%d] = %q, want %q", i, excludes[i], pattern)
+		}
+	}
+	if !MatchesExclusion("temp/cache/file.txt", excludes) {
+		t.Error("LoadExcludes() directory pattern temp/ should match files inside it")
+	}
+}
+
+// TestLoadExcludes_Negation tests that a ! line re-includes a path excluded
+// by an earlier pattern, with the last matching line deciding
+func TestLoadExcludes_Negation(t *testing.T) {
+	excludeFile := filepath.Join(t.TempDir(), ".updater-excludes")
+	content := `# World configuration files
+worlds/*.mcl
+!worlds/managed.mcl
+
+# Keep logs, but not the archive
+!logs/
+logs/archive/
+`
+	if err := os.WriteFile(excludeFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	excludes := LoadExcludes(excludeFile)
+
+	want := Excludes{"worlds/*.mcl", "!worlds/managed.mcl", "!logs/", "logs/archive/"}
+	if len(excludes) != len(want) {
+		t.Fatalf("LoadExcludes() = %v, want %v", excludes, want)
+	}
+	for i := range want {
+		if excludes[i] != want[i] {
+			t.Errorf("LoadExcludes()[%d] = %q, want %q", i, excludes[i], want[i])
+		}
+	}
+
+	tests := []struct {
+		path       string
+		excluded   bool
+		reincluded bool
+	}{
+		{"worlds/miriani.mcl", true, false},
+		{"worlds/managed.mcl", false, true},
+		{"Worlds/Managed.MCL", false, true},
+		{"worlds/plugins/plugin.xml", false, false},
+		{"logs/session.txt", false, true},
+		{"logs/archive/2024.txt", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := MatchesExclusion(tt.path, excludes); got != tt.excluded {
+				t.Errorf("MatchesExclusion(%q) = %v, want %v", tt.path, got, tt.excluded)
+			}
+			if got := excludes.Reincludes(tt.path); got != tt.reincluded {
+				t.Errorf("Reincludes(%q) = %v, want %v", tt.path, got, tt.reincluded)
+			}
+		})
+	}
+}
+
+// TestExcludesMatch_Default tests that a negation can override a built-in
+// default passed as the starting value
+func TestExcludesMatch_Default(t *testing.T) {
+	excludes := Excludes{"!worlds/managed.mcl"}
+	if excludes.Match("worlds/managed.mcl", true) {
+		t.Error("Match() should re-include worlds/managed.mcl over the default")
+	}
+	if !excludes.Match("worlds/other.mcl", true) {
+		t.Error("Match() should keep the default for paths no pattern matches")
+	}
+}
+
+// TestUnderPrefix tests -only style prefix scoping
+func TestUnderPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		prefixes []string
+		want     bool
+	}{
+		{"no prefixes", "worlds/plugins/a.xml", nil, true},
+		{"directory prefix", "worlds/plugins/a.xml", []string{"worlds/plugins"}, true},
+		{"trailing slash", "worlds/plugins/a.xml", []string{"worlds/plugins/"}, true},
+		{"exact file", "sounds/ping.ogg", []string{"sounds/ping.ogg"}, true},
+		{"case insensitive", "Worlds/Plugins/a.xml", []string{"worlds/PLUGINS"}, true},
+		{"partial segment", "worlds/plugins2/a.xml", []string{"worlds/plugins"}, false},
+		{"other directory", "sounds/ping.ogg", []string{"worlds"}, false},
+		{"second prefix matches", "sounds/ping.ogg", []string{"worlds", "sounds"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnderPrefix(tt.path, tt.prefixes); got != tt.want {
+				t.Errorf("UnderPrefix(%q, %v) = %v, want %v", tt.path, tt.prefixes, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNeedsRestart_CustomPatterns tests that a restart paths file replaces
+// the built-in default
+func TestNeedsRestart_CustomPatterns(t *testing.T) {
+	restartPath := filepath.Join(t.TempDir(), ".restart-paths")
+	content := "# startup plugins\nworlds/plugins/startup/\n*.exe\n"
+	if err := os.WriteFile(restartPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write restart paths file: %v", err)
+	}
+
+	patterns := LoadRestartPatterns(restartPath)
+	if patterns == nil {
+		t.Fatal("LoadRestartPatterns() = nil, want the file's patterns")
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"worlds/plugins/startup/init.xml", true},
+		{"MUSHclient.exe", true},
+		{"lua5.1.dll", false},
+		{"worlds/plugins/other.xml", false},
+	}
+	for _, tt := range tests {
+		if got := NeedsRestart(tt.path, patterns); got != tt.want {
+			t.Errorf("NeedsRestart(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestNeedsRestart_Default tests the built-in default when there is no
+// restart paths file
+func TestNeedsRestart_Default(t *testing.T) {
+	patterns := LoadRestartPatterns(filepath.Join(t.TempDir(), ".restart-paths"))
+	if patterns != nil {
+		t.Fatalf("LoadRestartPatterns() = %v for a missing file, want nil", patterns)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"MUSHclient.exe", true},
+		{"lua5.1.dll", true},
+		{"worlds/plugins/startup/init.xml", false},
+		{"docs/changelog.txt", false},
+	}
+	for _, tt := range tests {
+		if got := NeedsRestart(tt.path, patterns); got != tt.want {
+			t.Errorf("NeedsRestart(%q) = %v, want %v", tt.path, got, tt.want)
 		}
 	}
 }
@@ -317,3 +517,30 @@ func TestFindActual(t *testing.T) {
 	// Case-insensitive lookup is filesystem-dependent, skip for portability
 	t.Log("Skipping case-insensitive tests - behavior depends on filesystem")
 }
+
+// TestWriteFileAtomic tests that the file is replaced whole, and that a
+// failed rename keeps the old file and cleans up the temporary one
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "version.json")
+	os.WriteFile(path, []byte(`{"major": 1}`), 0644)
+
+	if err := WriteFileAtomic(path, []byte(`{"major": 2}`), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"major": 2}` {
+		t.Errorf("file = %q, want new content", data)
+	}
+
+	// A directory in the way makes the rename fail
+	blocked := filepath.Join(dir, "blocked")
+	os.MkdirAll(filepath.Join(blocked, "child"), 0755)
+	if err := WriteFileAtomic(blocked, []byte("data"), 0644); err == nil {
+		t.Error("WriteFileAtomic() over a directory should fail")
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(leftovers) != 0 {
+		t.Errorf("WriteFileAtomic() left temporary files: %v", leftovers)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package process
+
+import "errors"
+
+// FreeSpace is only supported on Windows
+func FreeSpace(dir string) (uint64, error) {
+	return 0, errors.New("reading free disk space is only supported on Windows")
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceExWProc = kernel32.NewProc("GetDiskFreeSpaceExW")
+
+// FreeSpace returns the bytes available to the current user on the volume
+// holding dir. A dir that doesn't exist yet (such as a new install folder) is
+// measured through its nearest existing parent.
+func FreeSpace(dir string) (uint64, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	dirPtr, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	ret, _, err := getDiskFreeSpaceExWProc.Call(
+		uintptr(unsafe.Pointer(dirPtr)),
+		uintptr(unsafe.Pointer(&available)),
+		0,
+		0,
+	)
+	if ret == 0 {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package process
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestFreeSpace tests that free space is reported for an existing folder
+// and, through its parent, for one that doesn't exist yet
+func TestFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	free, err := FreeSpace(dir)
+	if err != nil {
+		t.Fatalf("FreeSpace() error = %v", err)
+	}
+	if free == 0 {
+		t.Error("FreeSpace() = 0, want the free space on the temp volume")
+	}
+
+	missing, err := FreeSpace(filepath.Join(dir, "not", "created"))
+	if err != nil {
+		t.Fatalf("FreeSpace() on a missing folder error = %v", err)
+	}
+	if missing == 0 {
+		t.Error("FreeSpace() on a missing folder = 0, want its parent's free space")
+	}
+}
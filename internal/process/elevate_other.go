@@ -0,0 +1,10 @@
+//go:build !windows
+
+package process
+
+import "errors"
+
+// StartElevated is only supported on Windows
+func StartElevated(exePath, dir string, args []string) error {
+	return errors.New("elevation is only supported on Windows")
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package process
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// StartElevated relaunches exePath with args through ShellExecute's "runas"
+// verb, which shows the UAC consent prompt. The new process starts in dir
+// rather than System32, where runas would otherwise put it. It returns once
+// the new process has been started (or the user declined the prompt).
+func StartElevated(exePath, dir string, args []string) error {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = syscall.EscapeArg(arg)
+	}
+
+	if err := shellExecute("runas", exePath, strings.Join(quoted, " "), dir); err != nil {
+		return fmt.Errorf("failed to start elevated process: %w", err)
+	}
+	return nil
+}
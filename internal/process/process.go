@@ -2,6 +2,7 @@ package process
 
 import (
 	"fmt"
+	"net"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -36,21 +37,52 @@ func IsPortListening(port string) bool {
 		return false
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, ":"+port) && strings.Contains(line, "LISTENING") {
+	return netstatListening(string(output), port)
+}
+
+// netstatListening reports whether `netstat -ano` output has a TCP socket
+// listening on port. Only the local address column is checked, and it must
+// end in exactly ":<port>", so :1234 doesn't match :12345 or a remote address.
+func netstatListening(output, port string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		// Proto, Local Address, Foreign Address, State, PID
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.EqualFold(fields[0], "TCP") {
+			continue
+		}
+		if strings.HasSuffix(fields[1], ":"+port) && fields[3] == "LISTENING" {
 			return true
 		}
 	}
-
 	return false
 }
 
+// IsPortReachable checks if something accepts TCP connections on host:port,
+// for services on another machine that netstat can't see
+func IsPortReachable(host, port string, timeout time.Duration) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
 // IsMUSHClientRunningInDir checks if MUSHclient.exe is running from the specified directory
 func IsMUSHClientRunningInDir(targetDir string) bool {
 	expectedPath := paths.CleanLower(filepath.Join(targetDir, "MUSHclient.exe"))
 
-	// Use WMIC to get all running MUSHclient.exe processes with their full paths
+	// Ask Windows directly; WMIC is deprecated and missing from newer builds
+	if running, err := processImagePaths("MUSHclient.exe"); err == nil {
+		for _, path := range running {
+			if paths.CleanLower(path) == expectedPath {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Fall back to WMIC to get all running MUSHclient.exe processes with their full paths
 	cmd := exec.Command("wmic", "process", "where", "name='MUSHclient.exe'", "get", "ExecutablePath", "/format:list")
 	output, err := cmd.Output()
 	if err != nil {
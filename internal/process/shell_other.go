@@ -0,0 +1,10 @@
+//go:build !windows
+
+package process
+
+import "errors"
+
+// OpenDefault is only supported on Windows
+func OpenDefault(path string) error {
+	return errors.New("opening files with the default application is only supported on Windows")
+}
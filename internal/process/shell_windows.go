@@ -0,0 +1,59 @@
+//go:build windows
+
+package process
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	shell32           = syscall.NewLazyDLL("shell32.dll")
+	shellExecuteWProc = shell32.NewProc("ShellExecuteW")
+)
+
+const swShowNormal = 1
+
+// shellExecute runs ShellExecuteW with the given verb, starting the program
+// in dir. Empty params and dir strings are passed as NULL.
+func shellExecute(verb, file, params, dir string) error {
+	verbPtr, err := syscall.UTF16PtrFromString(verb)
+	if err != nil {
+		return err
+	}
+	filePtr, err := syscall.UTF16PtrFromString(file)
+	if err != nil {
+		return err
+	}
+	var paramsPtr *uint16
+	if params != "" {
+		if paramsPtr, err = syscall.UTF16PtrFromString(params); err != nil {
+			return err
+		}
+	}
+	var dirPtr *uint16
+	if dir != "" {
+		if dirPtr, err = syscall.UTF16PtrFromString(dir); err != nil {
+			return err
+		}
+	}
+
+	// ShellExecute returns a value greater than 32 on success
+	ret, _, _ := shellExecuteWProc.Call(0,
+		uintptr(unsafe.Pointer(verbPtr)),
+		uintptr(unsafe.Pointer(filePtr)),
+		uintptr(unsafe.Pointer(paramsPtr)),
+		uintptr(unsafe.Pointer(dirPtr)),
+		swShowNormal)
+	if ret <= 32 {
+		return fmt.Errorf("ShellExecute %s failed (error %d)", verb, ret)
+	}
+	return nil
+}
+
+// OpenDefault opens path with whatever application the user has associated
+// with its file type, the same as double-clicking it in Explorer.
+func OpenDefault(path string) error {
+	return shellExecute("open", path, "", "")
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package process
+
+import "errors"
+
+// processImagePaths is only supported on Windows
+func processImagePaths(name string) ([]string, error) {
+	return nil, errors.New("listing process image paths is only supported on Windows")
+}
@@ -0,0 +1,70 @@
+//go:build windows
+
+package process
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	queryFullProcessImageNameWProc = kernel32.NewProc("QueryFullProcessImageNameW")
+)
+
+const processQueryLimitedInformation = 0x1000
+
+// processImagePaths returns the full executable path of every running
+// process whose image name matches name (case-insensitively), using a
+// Toolhelp32 snapshot. Processes that can't be opened are skipped.
+func processImagePaths(name string) ([]string, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err := syscall.Process32First(snapshot, &entry); err != nil {
+		return nil, err
+	}
+
+	var found []string
+	for {
+		if strings.EqualFold(syscall.UTF16ToString(entry.ExeFile[:]), name) {
+			if path, err := processImagePath(entry.ProcessID); err == nil {
+				found = append(found, path)
+			}
+		}
+		if err := syscall.Process32Next(snapshot, &entry); err != nil {
+			if err == syscall.ERROR_NO_MORE_FILES {
+				return found, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// processImagePath asks Windows for the full executable path of a process
+func processImagePath(pid uint32) (string, error) {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, pid)
+	if err != nil {
+		return "", err
+	}
+	defer syscall.CloseHandle(handle)
+
+	buf := make([]uint16, syscall.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	ret, _, err := queryFullProcessImageNameWProc.Call(
+		uintptr(handle),
+		0,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if ret == 0 {
+		return "", err
+	}
+	return syscall.UTF16ToString(buf[:size]), nil
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/distantorigin/next-launcher/internal/paths"
+)
+
+// TestProcessImagePaths_Self tests that the snapshot finds the running test
+// binary with its full path
+func TestProcessImagePaths_Self(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("can't find test executable: %v", err)
+	}
+
+	found, err := processImagePaths(filepath.Base(exe))
+	if err != nil {
+		t.Fatalf("processImagePaths() error = %v", err)
+	}
+	for _, path := range found {
+		if paths.CleanLower(path) == paths.CleanLower(exe) {
+			return
+		}
+	}
+	t.Errorf("processImagePaths(%q) = %v, want it to include %s", filepath.Base(exe), found, exe)
+}
@@ -3,8 +3,11 @@ package prompt
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/go-ole/go-ole"
 	"github.com/go-ole/go-ole/oleutil"
@@ -20,18 +23,54 @@ type SoundPlayer interface {
 
 // Config holds configuration for prompting
 type Config struct {
-	NonInteractive  bool
-	Sound           SoundPlayer
+	NonInteractive   bool
+	AssumeYes        bool // Confirm answers yes without asking (-yes)
+	Sound            SoundPlayer
 	GetConsoleWindow func() uintptr
 }
 
-// WaitForKey waits for user to press Enter
+// WaitForKey waits for user to press Enter.
+// It returns on EOF (stdin closed), and Ctrl+C at the prompt exits the process
+// instead of leaving it hung on the read.
 func WaitForKey(prompt string, cfg Config) {
 	if cfg.NonInteractive {
 		return
 	}
 	fmt.Print(prompt)
-	bufio.NewReader(os.Stdin).ReadBytes('\n')
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	if !waitForLine(os.Stdin, interrupt) {
+		fmt.Println()
+		os.Exit(130) // Conventional exit status for SIGINT
+	}
+}
+
+// waitForLine blocks until a line (or EOF/error) is read from r, returning
+// false if an interrupt arrives first
+func waitForLine(r io.Reader, interrupt <-chan os.Signal) bool {
+	done := make(chan struct{})
+	go func() {
+		// Any error, including io.EOF, ends the wait
+		bufio.NewReader(r).ReadBytes('\n')
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-interrupt:
+		return false
+	}
+}
+
+// Ask asks a yes/no question whose answer chooses between two outcomes
+// rather than confirming one, so AssumeYes (-yes) doesn't answer it
+func Ask(prompt string, cfg Config) bool {
+	cfg.AssumeYes = false
+	return Confirm(prompt, cfg)
 }
 
 // Confirm asks the user to confirm an action
@@ -39,6 +78,15 @@ func Confirm(prompt string, cfg Config) bool {
 	if cfg.NonInteractive {
 		return true
 	}
+	// Show the question and answer so the run still reads like one a user confirmed
+	if cfg.AssumeYes {
+		fmt.Printf("%s (y/n): y\n", prompt)
+		if cfg.Sound != nil {
+			cfg.Sound.Play("select")
+			cfg.Sound.Play("success")
+		}
+		return true
+	}
 
 	fmt.Printf("%s (y/n): ", prompt)
 	reader := bufio.NewReader(os.Stdin)
@@ -175,6 +223,84 @@ func InstallationMenu(existingInstallFound bool, detectedPath string, toastushPa
 	}
 }
 
+// Choose displays a numbered list and returns the index of the chosen option,
+// or -1 if input could not be read
+func Choose(title string, options []string, cfg Config) int {
+	fmt.Println("\n" + title)
+	for i, option := range options {
+		fmt.Printf("  %d. %s\n", i+1, option)
+	}
+	fmt.Printf("Enter your choice (1-%d): ", len(options))
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+			return -1
+		}
+
+		var choice int
+		if _, err := fmt.Sscanf(strings.TrimSpace(response), "%d", &choice); err == nil && choice >= 1 && choice <= len(options) {
+			if cfg.Sound != nil {
+				cfg.Sound.PlayAsync("select")
+			}
+			return choice - 1
+		}
+		fmt.Printf("Invalid choice. Please enter a number from 1 to %d: ", len(options))
+	}
+}
+
+// defaultPageHeight is the page size when the console's height is unknown
+const defaultPageHeight = 25
+
+// Page shows text a screen at a time, so long output such as the changelog
+// can be read without leaving the console. width and height are the console
+// size in characters, 0 when unknown. After each screen Enter shows the next
+// one and q stops. Non-interactive runs print the text in one go.
+func Page(text string, width, height int, cfg Config) {
+	if cfg.NonInteractive {
+		fmt.Print(text)
+		return
+	}
+	page(os.Stdout, bufio.NewReader(os.Stdin), text, width, height, cfg.Sound)
+}
+
+// page does the work for Page. Lines longer than width count as the rows they
+// wrap onto, and the last row of each screen is kept for the prompt.
+func page(w io.Writer, r *bufio.Reader, text string, width, height int, sound SoundPlayer) {
+	if height <= 1 {
+		height = defaultPageHeight
+	}
+	rows := height - 1
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	used := 0
+	for i, line := range lines {
+		n := 1
+		if length := utf8.RuneCountInString(line); width > 0 && length > width {
+			n = (length + width - 1) / width
+		}
+		if used > 0 && used+n > rows {
+			fmt.Fprintf(w, "-- More (%d%%): Enter for the next page, q to stop -- ", i*100/len(lines))
+			response, err := r.ReadString('\n')
+			if err != nil {
+				fmt.Fprintln(w)
+				return
+			}
+			if strings.EqualFold(strings.TrimSpace(response), "q") {
+				return
+			}
+			if sound != nil {
+				sound.PlayAsync("select")
+			}
+			used = 0
+		}
+		fmt.Fprintln(w, line)
+		used += n
+	}
+}
+
 // ChannelInfo provides info about a channel for display
 type ChannelInfo struct {
 	StableDate       string
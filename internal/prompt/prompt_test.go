@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// numbered returns n lines, "line 1" to "line n"
+func numbered(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+// TestPage tests that the pager stops after each screen, continues on
+// Enter, and quits on q or when input ends
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		width     int
+		height    int
+		input     string
+		wantLines int // Text lines printed
+		wantMore  int // "More" prompts shown
+	}{
+		{name: "fits on one screen", text: numbered(3), height: 5, wantLines: 3},
+		{name: "pages through to the end", text: numbered(10), height: 5, input: "\n\n", wantLines: 10, wantMore: 2},
+		{name: "q stops", text: numbered(10), height: 5, input: "q\n", wantLines: 4, wantMore: 1},
+		{name: "end of input stops", text: numbered(10), height: 5, input: "", wantLines: 4, wantMore: 1},
+		{name: "unknown height uses the default", text: numbered(30), height: 0, input: "\n", wantLines: 30, wantMore: 1},
+		{name: "wrapped lines take more rows", text: strings.Repeat("line "+strings.Repeat("x", 20)+"\n", 4), width: 10, height: 7, input: "\n\n", wantLines: 4, wantMore: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			page(&out, bufio.NewReader(strings.NewReader(tt.input)), tt.text, tt.width, tt.height, nil)
+
+			got := out.String()
+			if more := strings.Count(got, "-- More"); more != tt.wantMore {
+				t.Errorf("prompts = %d, want %d\n%s", more, tt.wantMore, got)
+			}
+			// The prompt isn't followed by a newline here, since input isn't echoed
+			if lines := strings.Count(got, "line "); lines != tt.wantLines {
+				t.Errorf("lines printed = %d, want %d\n%s", lines, tt.wantLines, got)
+			}
+		})
+	}
+}
+
+// recordingSound records the sounds played
+type recordingSound struct{ played []string }
+
+func (s *recordingSound) Play(name string)      { s.played = append(s.played, name) }
+func (s *recordingSound) PlayAsync(name string) { s.played = append(s.played, name) }
+
+// TestConfirm_AssumeYes tests that -yes answers without reading input and
+// keeps the confirmation sounds
+func TestConfirm_AssumeYes(t *testing.T) {
+	sound := &recordingSound{}
+	if !Confirm("Move the existing Miriani-Next directory aside and continue?", Config{AssumeYes: true, Sound: sound}) {
+		t.Fatal("Confirm() = false, want true with AssumeYes")
+	}
+	if got := strings.Join(sound.played, ","); got != "select,success" {
+		t.Errorf("sounds = %q, want select,success", got)
+	}
+}
+
+// TestAsk_AssumeYes tests that -yes doesn't answer a choice: the user's "n"
+// is read and kept
+func TestAsk_AssumeYes(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	w.WriteString("n\n")
+	w.Close()
+
+	if Ask("Would you like to view the detailed changelog?", Config{AssumeYes: true}) {
+		t.Error("Ask() = true, want the user's no")
+	}
+}
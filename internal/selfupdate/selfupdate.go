@@ -1,13 +1,22 @@
 package selfupdate
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/distantorigin/next-launcher/internal/version"
 )
 
 // Config holds the configuration for self-update
@@ -15,6 +24,8 @@ type Config struct {
 	ReleasesAPIURL string
 	BinaryURL      string
 	CurrentVersion string
+	LogPath        string            // Where Check records each attempt; empty disables logging
+	PublicKey      ed25519.PublicKey // Key release binaries must be signed with
 }
 
 // GitHubRelease represents the GitHub API response for a release
@@ -26,25 +37,216 @@ type GitHubRelease struct {
 	} `json:"assets"`
 }
 
+// signingKey is the hex-encoded Ed25519 public key release binaries are
+// signed with. Builds without one refuse every self-update.
+//
+//go:embed signing_key.pub
+var signingKey string
+
 // DefaultConfig returns the default self-update configuration
 func DefaultConfig(currentVersion string) Config {
 	return Config{
 		ReleasesAPIURL: "https://api.github.com/repos/distantorigin/next-launcher/releases/latest",
 		BinaryURL:      "https://github.com/distantorigin/next-launcher/releases/latest/download/miriani.exe",
 		CurrentVersion: currentVersion,
+		PublicKey:      parsePublicKey(signingKey),
+	}
+}
+
+// parsePublicKey decodes a hex Ed25519 public key, returning nil if it isn't one
+func parsePublicKey(s string) ed25519.PublicKey {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil
 	}
+	return ed25519.PublicKey(key)
+}
+
+// Checksum verification results reported by DryRun
+const (
+	ChecksumVerified     = "verified"
+	ChecksumNotPublished = "not published"
+	ChecksumMismatch     = "mismatch"
+)
+
+// checksumAssetName is the optional release asset holding the SHA-256 of miriani.exe
+const checksumAssetName = "miriani.exe.sha256"
+
+// Signature verification results reported by DryRun
+const (
+	SignatureVerified = "verified"
+	SignatureMissing  = "missing"
+	SignatureInvalid  = "invalid"
+	SignatureNoKey    = "unverifiable (no signing key built in)"
+)
+
+// errNoSigningKey is logged when an update is available but this build has no
+// key to verify it with
+var errNoSigningKey = errors.New("no signing key built in (internal/selfupdate/signing_key.pub is empty or invalid), self-updates are disabled")
+
+// signatureAssetName is the release asset holding the detached Ed25519
+// signature of miriani.exe, raw or base64-encoded. It's required.
+const signatureAssetName = "miriani.exe.sig"
+
+// Report describes what a self-update check found
+type Report struct {
+	RemoteVersion   string
+	UpdateAvailable bool
+	BinaryURL       string
+	BinarySize      int
+	Checksum        string // One of the Checksum* constants
+	Signature       string // One of the Signature* constants
+	WouldUpdate     bool
 }
 
 // Check checks for a new version of the updater and replaces it if available.
 // This function fails silently with a short timeout to avoid blocking the main update process.
-// Returns true if the updater was replaced and a restart is needed.
+// Because it usually runs detached with no console, every attempt is recorded
+// in cfg.LogPath (if set) so its outcome can be diagnosed later.
 func Check(cfg Config) error {
 	// Get the path of the current executable
 	exePath, err := os.Executable()
 	if err != nil {
+		cfg.log("", "skipped: cannot locate executable: %v", err)
 		return nil // Silent failure - not critical
 	}
 
+	release, err := fetchRelease(cfg)
+	if err != nil {
+		cfg.log("", "skipped: %v", err)
+		return nil // Silent failure - network issues, server down, etc.
+	}
+
+	// Extract version from tag (e.g., "v1.2.3" -> "1.2.3")
+	remoteVersion := strings.TrimPrefix(release.TagName, "v")
+	if remoteVersion == "" {
+		cfg.log("", "skipped: latest release has no version tag")
+		return nil
+	}
+	cmp, err := compareVersions(remoteVersion, cfg.CurrentVersion)
+	if err != nil {
+		cfg.log(remoteVersion, "skipped: %v", err)
+		return nil
+	}
+	if cmp == 0 {
+		cfg.log(remoteVersion, "up to date")
+		return nil // No update available
+	}
+	if cmp < 0 {
+		cfg.log(remoteVersion, "skipped: remote is older, refusing to downgrade")
+		return nil
+	}
+
+	// Without a key no download could ever be accepted, so don't fetch one
+	if len(cfg.PublicKey) != ed25519.PublicKeySize {
+		cfg.log(remoteVersion, "skipped: %v", errNoSigningKey)
+		return nil
+	}
+
+	// Update available - download and replace
+	assets := findAssets(release, cfg)
+	if err := replaceExecutable(assets, cfg.PublicKey, exePath); err != nil {
+		cfg.log(remoteVersion, "failed: %v", err)
+		return nil
+	}
+	cfg.log(remoteVersion, "updated")
+
+	if err := restart(exePath); err != nil {
+		cfg.log(remoteVersion, "restart failed, previous version restored: %v", err)
+		return err
+	}
+	return nil
+}
+
+// compareVersions compares a release's version with the running updater's,
+// returning -1, 0 or 1 as remote is older, the same or newer. A current
+// version that isn't X.Y.Z (a "dev" build) is treated as older than any
+// release, as string comparison did before.
+func compareVersions(remote, current string) (int, error) {
+	remoteVersion, err := version.FromTag(remote)
+	if err != nil {
+		return 0, fmt.Errorf("can't compare release version: %w", err)
+	}
+	currentVersion, err := version.FromTag(current)
+	if err != nil {
+		return 1, nil
+	}
+	return remoteVersion.Compare(currentVersion), nil
+}
+
+// maxLogSize caps the self-update log; older lines are dropped beyond it
+const maxLogSize = 32 * 1024
+
+// log appends one line describing a Check attempt to cfg.LogPath
+func (cfg Config) log(remoteVersion, format string, args ...interface{}) {
+	if cfg.LogPath == "" {
+		return
+	}
+	if remoteVersion == "" {
+		remoteVersion = "-"
+	}
+	line := fmt.Sprintf("%s current=%s remote=%s %s\n",
+		time.Now().UTC().Format(time.RFC3339), cfg.CurrentVersion, remoteVersion, fmt.Sprintf(format, args...))
+	_ = appendCapped(cfg.LogPath, line, maxLogSize)
+}
+
+// appendCapped appends line to path, first discarding the oldest whole lines
+// if the file would otherwise grow beyond limit bytes
+func appendCapped(path, line string, limit int) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	data = append(data, line...)
+	if len(data) > limit {
+		data = data[len(data)-limit:]
+		if i := strings.IndexByte(string(data), '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// DryRun runs the whole self-update flow (version compare, download, checksum
+// verification) and reports what it found, stopping before the executable is replaced.
+func DryRun(cfg Config) (*Report, error) {
+	release, err := fetchRelease(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{RemoteVersion: strings.TrimPrefix(release.TagName, "v")}
+	if report.RemoteVersion == "" {
+		return nil, fmt.Errorf("latest release has no version tag")
+	}
+	cmp, err := compareVersions(report.RemoteVersion, cfg.CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+	report.UpdateAvailable = cmp > 0
+
+	assets := findAssets(release, cfg)
+	report.BinaryURL = assets.binaryURL
+
+	data, err := fetchBinary(assets.binaryURL)
+	if err != nil {
+		return report, err
+	}
+	report.BinarySize = len(data)
+
+	report.Checksum, err = verifyChecksum(data, assets.checksumURL)
+	if err != nil {
+		return report, err
+	}
+	report.Signature = verifySignature(data, assets.signatureURL, cfg.PublicKey)
+
+	report.WouldUpdate = report.UpdateAvailable && report.Checksum != ChecksumMismatch && report.Signature == SignatureVerified
+	return report, nil
+}
+
+// fetchRelease queries the releases API for the latest release
+func fetchRelease(cfg Config) (*GitHubRelease, error) {
 	// Create a client with a short timeout for version check
 	quickClient := &http.Client{
 		Timeout: 5 * time.Second,
@@ -59,104 +261,287 @@ func Check(cfg Config) error {
 	// Make a request to GitHub releases API
 	req, err := http.NewRequest("GET", cfg.ReleasesAPIURL, nil)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("failed to create release request: %w", err)
 	}
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("User-Agent", "next-launcher")
 
 	resp, err := quickClient.Do(req)
 	if err != nil {
-		return nil // Silent failure - network issues, server down, etc.
+		return nil, fmt.Errorf("failed to fetch release info: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil // Silent failure - API error
+		return nil, fmt.Errorf("failed to fetch release info: HTTP %d", resp.StatusCode)
 	}
 
 	// Parse the release info
 	var release GitHubRelease
 	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil
+		return nil, fmt.Errorf("failed to parse release info: %w", err)
 	}
 
-	// Extract version from tag (e.g., "v1.2.3" -> "1.2.3")
-	remoteVersion := strings.TrimPrefix(release.TagName, "v")
-	if remoteVersion == "" || remoteVersion == cfg.CurrentVersion {
-		return nil // No update available
-	}
+	return &release, nil
+}
+
+// releaseAssets are the download URLs of a release's updater files
+type releaseAssets struct {
+	binaryURL    string
+	checksumURL  string // Empty if no checksum is published
+	signatureURL string
+}
 
-	// Find the binary asset URL
-	binaryURL := cfg.BinaryURL
+// findAssets returns the binary URL, the checksum URL if published, and the
+// signature URL for a release. Without a listed signature asset, the
+// signature is looked for next to the binary.
+func findAssets(release *GitHubRelease, cfg Config) releaseAssets {
+	assets := releaseAssets{binaryURL: cfg.BinaryURL}
 	for _, asset := range release.Assets {
-		if asset.Name == "miriani.exe" {
-			binaryURL = asset.BrowserDownloadURL
-			break
+		switch asset.Name {
+		case "miriani.exe":
+			assets.binaryURL = asset.BrowserDownloadURL
+		case checksumAssetName:
+			assets.checksumURL = asset.BrowserDownloadURL
+		case signatureAssetName:
+			assets.signatureURL = asset.BrowserDownloadURL
 		}
 	}
-
-	// Update available - download and replace
-	return downloadAndReplace(binaryURL, exePath)
+	if assets.signatureURL == "" {
+		assets.signatureURL = assets.binaryURL + ".sig"
+	}
+	return assets
 }
 
-// downloadAndReplace downloads the new binary and replaces the current executable.
-// We trust GitHub's HTTPS, so no additional hash verification is needed.
-func downloadAndReplace(binaryURL string, exePath string) error {
+// fetchBinary downloads the new updater binary and sanity-checks its size
+func fetchBinary(binaryURL string) ([]byte, error) {
 	downloadClient := &http.Client{Timeout: 60 * time.Second}
 
-	// Download new binary
 	resp, err := downloadClient.Get(binaryURL)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("failed to download updater: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil
+		return nil, fmt.Errorf("failed to download updater: HTTP %d", resp.StatusCode)
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("failed to read updater: %w", err)
 	}
 
 	// Basic sanity check - should be a reasonable size for an exe
 	if len(data) < 1024*1024 { // Less than 1MB is suspicious
-		return nil
+		return nil, fmt.Errorf("downloaded updater is suspiciously small (%d bytes)", len(data))
+	}
+
+	return data, nil
+}
+
+// verifyChecksum compares data against the published SHA-256, if there is one.
+// The checksum file holds a hex digest, optionally followed by a filename.
+func verifyChecksum(data []byte, checksumURL string) (string, error) {
+	if checksumURL == "" {
+		return ChecksumNotPublished, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(checksumURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksum: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download checksum: HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum: %w", err)
+	}
+
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return ChecksumMismatch, nil
+	}
+
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+		return ChecksumMismatch, nil
+	}
+	return ChecksumVerified, nil
+}
+
+// verifySignature checks data against the detached Ed25519 signature at
+// signatureURL. A signature that can't be fetched counts as missing.
+func verifySignature(data []byte, signatureURL string, key ed25519.PublicKey) string {
+	if len(key) != ed25519.PublicKeySize {
+		return SignatureNoKey
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(signatureURL)
+	if err != nil {
+		return SignatureMissing
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return SignatureMissing
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return SignatureMissing
+	}
+
+	sig := body
+	if len(sig) != ed25519.SignatureSize {
+		if sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(body))); err != nil {
+			return SignatureInvalid
+		}
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(key, data, sig) {
+		return SignatureInvalid
+	}
+	return SignatureVerified
+}
+
+// replaceExecutable downloads the new binary and swaps it in for exePath,
+// keeping the previous binary as exePath.old. The download must carry a valid
+// signature from key and, if the release publishes a SHA-256 checksum, match it.
+func replaceExecutable(assets releaseAssets, key ed25519.PublicKey, exePath string) error {
+	if len(key) != ed25519.PublicKeySize {
+		return errNoSigningKey
+	}
+	data, err := fetchBinary(assets.binaryURL)
+	if err != nil {
+		return err
+	}
+
+	status, err := verifyChecksum(data, assets.checksumURL)
+	if err != nil {
+		return err
+	}
+	if status == ChecksumMismatch {
+		return fmt.Errorf("downloaded updater does not match the published checksum")
+	}
+	if sig := verifySignature(data, assets.signatureURL, key); sig != SignatureVerified {
+		return fmt.Errorf("downloaded updater signature is %s", sig)
 	}
 
 	// Replace the executable
 	oldExe := exePath + ".old"
 	_ = os.Remove(oldExe)
 	if err := os.Rename(exePath, oldExe); err != nil {
-		return nil
+		return fmt.Errorf("failed to move current updater aside: %w", err)
 	}
 
 	if err := os.WriteFile(exePath, data, 0755); err != nil {
 		_ = os.Rename(oldExe, exePath)
-		return nil
+		return fmt.Errorf("failed to write new updater: %w", err)
 	}
+	return nil
+}
+
+// restart relaunches the replaced executable with the same arguments and
+// exits once it confirms it started. If it can't be started, exits early or
+// never confirms, the previous binary is restored and we carry on with it.
+func restart(exePath string) error {
+	marker := exePath + startedMarkerSuffix
+	_ = os.Remove(marker)
 
-	// Restart with same arguments
 	cmd := exec.Command(exePath, os.Args[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), "UPDATER_CLEANUP_OLD=1")
+	cmd.Env = append(os.Environ(), "UPDATER_CLEANUP_OLD=1", startedMarkerEnv+"="+marker)
 
 	if err := cmd.Start(); err != nil {
-		_ = os.Remove(exePath)
-		_ = os.Rename(oldExe, exePath)
+		rollback(exePath)
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+		close(exited)
+	}()
+
+	if err := awaitStart(marker, exited, startTimeout); err != nil {
+		_ = cmd.Process.Kill()
+		<-exited
+		_ = os.Remove(marker)
+		rollback(exePath)
 		return err
 	}
 
-	// Give the new process a moment to initialize before we exit
-	time.Sleep(100 * time.Millisecond)
+	_ = os.Remove(marker)
 	os.Exit(0)
 
 	return nil
 }
 
+// awaitStart waits for a restarted updater to confirm it started by creating
+// marker. It fails if the process exits first or nothing appears in timeout.
+func awaitStart(marker string, exited <-chan error, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	tick := time.NewTicker(startPollInterval)
+	defer tick.Stop()
+
+	for {
+		if _, err := os.Stat(marker); err == nil {
+			return nil
+		}
+		select {
+		case err := <-exited:
+			// It may have confirmed and finished between polls
+			if _, statErr := os.Stat(marker); statErr == nil {
+				return nil
+			}
+			if err == nil {
+				return fmt.Errorf("new version exited before starting")
+			}
+			return fmt.Errorf("new version failed to start: %w", err)
+		case <-deadline:
+			return fmt.Errorf("new version did not start within %v", timeout)
+		case <-tick.C:
+		}
+	}
+}
+
+// rollback puts the .old backup back in place of exePath
+func rollback(exePath string) {
+	oldExe := exePath + ".old"
+	if _, err := os.Stat(oldExe); err != nil {
+		return
+	}
+	_ = os.Remove(exePath)
+	_ = os.Rename(oldExe, exePath)
+}
+
+// ConfirmStarted tells the updater that restarted us that we came up, so it
+// can exit instead of rolling back. Call it as early in main as possible.
+func ConfirmStarted() {
+	marker := os.Getenv(startedMarkerEnv)
+	if marker == "" {
+		return
+	}
+	os.Unsetenv(startedMarkerEnv)
+	_ = os.WriteFile(marker, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644)
+}
+
+const (
+	// startedMarkerEnv names the file a restarted updater creates to confirm
+	// it launched; the marker sits next to the executable.
+	startedMarkerEnv    = "UPDATER_STARTED_MARKER"
+	startedMarkerSuffix = ".started"
+
+	startTimeout      = 10 * time.Second
+	startPollInterval = 100 * time.Millisecond
+)
+
 // CleanupOld removes the .old backup file if UPDATER_CLEANUP_OLD env var is set
 func CleanupOld() {
 	if os.Getenv("UPDATER_CLEANUP_OLD") != "1" {
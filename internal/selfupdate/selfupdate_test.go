@@ -1,10 +1,20 @@
 package selfupdate
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -133,3 +143,358 @@ func TestCheckInvalidJSON(t *testing.T) {
 		t.Errorf("Check() should silently handle invalid JSON, got: %v", err)
 	}
 }
+
+// newReleaseServer serves a release JSON, a fake binary and optional checksum
+// and signature files
+func newReleaseServer(t *testing.T, tag string, binary []byte, checksum string, signature []byte) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release":
+			assets := fmt.Sprintf(`{"name": "miriani.exe", "browser_download_url": "%s/miriani.exe"}`, server.URL)
+			if checksum != "" {
+				assets += fmt.Sprintf(`, {"name": "miriani.exe.sha256", "browser_download_url": "%s/miriani.exe.sha256"}`, server.URL)
+			}
+			fmt.Fprintf(w, `{"tag_name": %q, "assets": [%s]}`, tag, assets)
+		case "/miriani.exe":
+			w.Write(binary)
+		case "/miriani.exe.sha256":
+			fmt.Fprintf(w, "%s  miriani.exe\n", checksum)
+		case "/miriani.exe.sig":
+			if signature == nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server
+}
+
+func TestDryRun(t *testing.T) {
+	binary := make([]byte, 2*1024*1024)
+	sum := sha256.Sum256(binary)
+	goodSum := hex.EncodeToString(sum[:])
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	goodSig := ed25519.Sign(priv, binary)
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	wrongKeySig := ed25519.Sign(otherPriv, binary)
+
+	tests := []struct {
+		name          string
+		tag           string
+		checksum      string
+		signature     []byte
+		wantUpdate    bool
+		wantChecksum  string
+		wantSignature string
+		wantWould     bool
+	}{
+		{"update with verified checksum", "v1.1.0", goodSum, goodSig, true, ChecksumVerified, SignatureVerified, true},
+		{"update without checksum", "v1.1.0", "", goodSig, true, ChecksumNotPublished, SignatureVerified, true},
+		{"update with base64 signature", "v1.1.0", goodSum, []byte(base64.StdEncoding.EncodeToString(goodSig) + "\n"), true, ChecksumVerified, SignatureVerified, true},
+		{"update with bad checksum", "v1.1.0", "deadbeef", goodSig, true, ChecksumMismatch, SignatureVerified, false},
+		{"update without signature", "v1.1.0", goodSum, nil, true, ChecksumVerified, SignatureMissing, false},
+		{"update signed with another key", "v1.1.0", goodSum, wrongKeySig, true, ChecksumVerified, SignatureInvalid, false},
+		{"already current", "v1.0.0", goodSum, goodSig, false, ChecksumVerified, SignatureVerified, false},
+		{"older release refused", "v0.9.0", goodSum, goodSig, false, ChecksumVerified, SignatureVerified, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newReleaseServer(t, tt.tag, binary, tt.checksum, tt.signature)
+			defer server.Close()
+
+			report, err := DryRun(Config{
+				ReleasesAPIURL: server.URL + "/release",
+				CurrentVersion: "1.0.0",
+				PublicKey:      pub,
+			})
+			if err != nil {
+				t.Fatalf("DryRun() error = %v", err)
+			}
+			if report.UpdateAvailable != tt.wantUpdate {
+				t.Errorf("UpdateAvailable = %v, want %v", report.UpdateAvailable, tt.wantUpdate)
+			}
+			if report.Checksum != tt.wantChecksum {
+				t.Errorf("Checksum = %q, want %q", report.Checksum, tt.wantChecksum)
+			}
+			if report.Signature != tt.wantSignature {
+				t.Errorf("Signature = %q, want %q", report.Signature, tt.wantSignature)
+			}
+			if report.WouldUpdate != tt.wantWould {
+				t.Errorf("WouldUpdate = %v, want %v", report.WouldUpdate, tt.wantWould)
+			}
+			if report.BinarySize != len(binary) {
+				t.Errorf("BinarySize = %d, want %d", report.BinarySize, len(binary))
+			}
+		})
+	}
+}
+
+// TestCompareVersions tests that only strictly newer releases count as updates
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		remote, current string
+		want            int
+		wantErr         bool
+	}{
+		{"1.0.0", "1.0.0", 0, false},
+		{"1.0.1", "1.0.0", 1, false},
+		{"1.10.0", "1.9.0", 1, false},
+		{"0.9.0", "1.0.0", -1, false},
+		{"1.2.3", "1.10.0", -1, false},
+		{"1.0.0", "dev", 1, false},
+		{"latest", "1.0.0", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := compareVersions(tt.remote, tt.current)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("compareVersions(%q, %q) error = %v, wantErr %v", tt.remote, tt.current, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.remote, tt.current, got, tt.want)
+		}
+	}
+}
+
+// TestCheckRefusesDowngrade tests that an older release is logged and left alone
+func TestCheckRefusesDowngrade(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v0.9.0", "assets": []}`))
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(t.TempDir(), ".selfupdate-log")
+	if err := Check(Config{ReleasesAPIURL: server.URL, CurrentVersion: "1.0.0", LogPath: logPath}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	data, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(data), "remote=0.9.0 skipped: remote is older") {
+		t.Errorf("log = %q, want the downgrade refused", data)
+	}
+}
+
+// TestCheckNoSigningKey tests that a build without a key logs why and never
+// downloads the binary it couldn't accept
+func TestCheckNoSigningKey(t *testing.T) {
+	var binaryRequested bool
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/miriani.exe" {
+			binaryRequested = true
+			w.Write([]byte("updater binary"))
+			return
+		}
+		fmt.Fprintf(w, `{"tag_name": "v1.1.0", "assets": [{"name": "miriani.exe", "browser_download_url": "%s/miriani.exe"}]}`, server.URL)
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(t.TempDir(), ".selfupdate-log")
+	if err := Check(Config{ReleasesAPIURL: server.URL, CurrentVersion: "1.0.0", LogPath: logPath}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if binaryRequested {
+		t.Error("Check() downloaded the binary without a key to verify it")
+	}
+	data, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(data), "remote=1.1.0 skipped: no signing key built in") {
+		t.Errorf("log = %q, want the missing key reported", data)
+	}
+}
+
+// TestVerifySignature tests the detached signature check against a key
+// generated for the test
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	data := []byte("updater binary")
+	sig := ed25519.Sign(priv, data)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good.sig":
+			w.Write(sig)
+		case "/garbage.sig":
+			w.Write([]byte("not a signature"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+		url  string
+		key  ed25519.PublicKey
+		want string
+	}{
+		{"valid", data, server.URL + "/good.sig", pub, SignatureVerified},
+		{"tampered data", []byte("updater binarY"), server.URL + "/good.sig", pub, SignatureInvalid},
+		{"no key built in", data, server.URL + "/good.sig", nil, SignatureNoKey},
+		{"garbage signature", data, server.URL + "/garbage.sig", pub, SignatureInvalid},
+		{"not published", data, server.URL + "/missing.sig", pub, SignatureMissing},
+	}
+	for _, tt := range tests {
+		if got := verifySignature(tt.data, tt.url, tt.key); got != tt.want {
+			t.Errorf("%s: verifySignature() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := parsePublicKey(hex.EncodeToString(pub) + "\n"); !pub.Equal(got) {
+		t.Errorf("parsePublicKey() = %x, want %x", got, pub)
+	}
+	if got := parsePublicKey(""); got != nil {
+		t.Errorf("parsePublicKey(\"\") = %x, want nil", got)
+	}
+}
+
+func TestDryRunSmallBinary(t *testing.T) {
+	server := newReleaseServer(t, "v1.1.0", []byte("tiny"), "", nil)
+	defer server.Close()
+
+	_, err := DryRun(Config{
+		ReleasesAPIURL: server.URL + "/release",
+		CurrentVersion: "1.0.0",
+	})
+	if err == nil {
+		t.Error("DryRun() should reject a suspiciously small binary")
+	}
+}
+
+func TestCheckLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"tag_name": "v1.0.0", "assets": []}`))
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(t.TempDir(), ".selfupdate-log")
+	Check(Config{ReleasesAPIURL: server.URL, CurrentVersion: "1.0.0", LogPath: logPath})
+	Check(Config{ReleasesAPIURL: server.URL + "/broken", CurrentVersion: "1.0.0", LogPath: logPath})
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log has %d lines, want 2:\n%s", len(lines), data)
+	}
+	if !strings.HasSuffix(lines[0], "current=1.0.0 remote=1.0.0 up to date") {
+		t.Errorf("line 1 = %q, want up to date", lines[0])
+	}
+	if !strings.Contains(lines[1], "remote=- skipped: failed to fetch release info: HTTP 500") {
+		t.Errorf("line 2 = %q, want skipped with reason", lines[1])
+	}
+}
+
+func TestAppendCapped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	for i := 0; i < 10; i++ {
+		if err := appendCapped(path, fmt.Sprintf("line %d\n", i), 20); err != nil {
+			t.Fatalf("appendCapped() error = %v", err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if len(data) > 20 {
+		t.Errorf("log is %d bytes, want at most 20", len(data))
+	}
+	if got := string(data); got != "line 8\nline 9\n" {
+		t.Errorf("log = %q, want the newest whole lines", got)
+	}
+}
+
+func TestAwaitStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		marker  bool          // marker exists before waiting
+		delay   time.Duration // create the marker after this long (0 = never)
+		exit    bool          // process exits immediately
+		exitErr error
+		wantErr bool
+	}{
+		{name: "already confirmed", marker: true},
+		{name: "confirms while waiting", delay: 50 * time.Millisecond},
+		{name: "crashes on launch", exit: true, exitErr: errors.New("exit status 2"), wantErr: true},
+		{name: "exits without confirming", exit: true, wantErr: true},
+		{name: "confirms then exits", marker: true, exit: true},
+		{name: "hangs", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "update.exe.started")
+			if tt.marker {
+				os.WriteFile(marker, []byte("1\n"), 0644)
+			}
+			if tt.delay > 0 {
+				time.AfterFunc(tt.delay, func() { os.WriteFile(marker, []byte("1\n"), 0644) })
+			}
+			exited := make(chan error, 1)
+			if tt.exit {
+				exited <- tt.exitErr
+			}
+
+			err := awaitStart(marker, exited, 500*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("awaitStart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	dir := t.TempDir()
+	exePath := filepath.Join(dir, "update.exe")
+
+	// Without a backup the current binary is left alone
+	os.WriteFile(exePath, []byte("new"), 0644)
+	rollback(exePath)
+	if data, _ := os.ReadFile(exePath); string(data) != "new" {
+		t.Errorf("rollback without backup changed exe to %q", data)
+	}
+
+	os.WriteFile(exePath+".old", []byte("old"), 0644)
+	rollback(exePath)
+	if data, _ := os.ReadFile(exePath); string(data) != "old" {
+		t.Errorf("exe after rollback = %q, want %q", data, "old")
+	}
+	if _, err := os.Stat(exePath + ".old"); !os.IsNotExist(err) {
+		t.Error("backup should be gone after rollback")
+	}
+}
+
+func TestConfirmStarted(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "update.exe.started")
+
+	t.Setenv(startedMarkerEnv, "")
+	ConfirmStarted()
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("marker written without the env var")
+	}
+
+	t.Setenv(startedMarkerEnv, marker)
+	ConfirmStarted()
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("marker not written: %v", err)
+	}
+	if os.Getenv(startedMarkerEnv) != "" {
+		t.Error("env var should be cleared so child processes don't confirm")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build release
+
+package selfupdate
+
+import "testing"
+
+// TestReleaseSigningKey fails release builds that have no signing key built
+// in, since a binary without one can never self-update again
+func TestReleaseSigningKey(t *testing.T) {
+	if parsePublicKey(signingKey) == nil {
+		t.Fatal("signing_key.pub must hold the hex Ed25519 release public key for release builds")
+	}
+}
@@ -2,11 +2,14 @@ package version
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/distantorigin/next-launcher/internal/paths"
 )
 
 // Version represents the application version
@@ -16,6 +19,9 @@ type Version struct {
 	Patch  int    `json:"patch"`
 	Commit string `json:"commit,omitempty"`
 	Date   string `json:"date,omitempty"`
+	// Head is the full SHA of the branch commit a dev or branch install was
+	// updated to. Commit holds the root tree SHA, so it can't be compared.
+	Head string `json:"head,omitempty"`
 }
 
 // String returns the version in semantic format
@@ -27,6 +33,35 @@ func (v Version) String() string {
 	return ver
 }
 
+// Compare orders v against other by major, minor and patch, returning -1, 0
+// or 1 as v is older, the same or newer. Commit and Date are ignored, so two
+// builds of the same release compare equal.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1
+		case pair[0] > pair[1]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// Less reports whether v is an older release than other
+func (v Version) Less(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// FromTag parses a git tag (e.g., "v1.2.3") into a Version
+func FromTag(tag string) (Version, error) {
+	major, minor, patch, err := ParseTag(tag)
+	if err != nil {
+		return Version{}, err
+	}
+	return Version{Major: major, Minor: minor, Patch: patch}, nil
+}
+
 // ParseTag extracts version components from a git tag (e.g., "v1.2.3")
 func ParseTag(tag string) (major, minor, patch int, err error) {
 	tagVersion := strings.TrimPrefix(tag, "v")
@@ -51,6 +86,10 @@ func ParseTag(tag string) (major, minor, patch int, err error) {
 	return major, minor, patch, nil
 }
 
+// ErrCorrupt is returned by LoadLocal when the version file exists but can't
+// be parsed, as opposed to a missing file (which wraps os.ErrNotExist)
+var ErrCorrupt = errors.New("version file is corrupt")
+
 // LoadLocal reads version information from a local version.json file
 func LoadLocal(baseDir, versionFile string) (*Version, error) {
 	path := filepath.Join(baseDir, versionFile)
@@ -61,13 +100,14 @@ func LoadLocal(baseDir, versionFile string) (*Version, error) {
 
 	var v Version
 	if err := json.Unmarshal(data, &v); err != nil {
-		return nil, fmt.Errorf("failed to parse local version: %w", err)
+		return nil, fmt.Errorf("failed to parse local version: %w: %v", ErrCorrupt, err)
 	}
 
 	return &v, nil
 }
 
-// Save writes version information to a version.json file
+// Save writes version information to a version.json file, replacing it
+// atomically so an interrupted write never leaves it truncated
 func Save(baseDir, versionFile string, v *Version) error {
 	path := filepath.Join(baseDir, versionFile)
 	data, err := json.MarshalIndent(v, "", "  ")
@@ -75,7 +115,7 @@ func Save(baseDir, versionFile string, v *Version) error {
 		return fmt.Errorf("failed to marshal version: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := paths.WriteFileAtomic(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write version file: %w", err)
 	}
 
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -44,6 +45,49 @@ func TestVersionString(t *testing.T) {
 	}
 }
 
+// TestVersionCompare tests ordering versions by major, minor and patch
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Version
+		want int
+	}{
+		{"equal", Version{Major: 1, Minor: 2, Patch: 3}, Version{Major: 1, Minor: 2, Patch: 3}, 0},
+		{"patch newer", Version{Major: 1, Minor: 2, Patch: 4}, Version{Major: 1, Minor: 2, Patch: 3}, 1},
+		{"minor older", Version{Major: 1, Minor: 1, Patch: 9}, Version{Major: 1, Minor: 2, Patch: 0}, -1},
+		{"major wins over minor", Version{Major: 2, Minor: 0, Patch: 0}, Version{Major: 1, Minor: 99, Patch: 99}, 1},
+		{"double-digit minor", Version{Major: 1, Minor: 10, Patch: 0}, Version{Major: 1, Minor: 9, Patch: 0}, 1},
+		{"double-digit patch", Version{Major: 1, Minor: 0, Patch: 9}, Version{Major: 1, Minor: 0, Patch: 10}, -1},
+		{"same core, different commit", Version{Major: 1, Minor: 2, Patch: 3, Commit: "abc1234", Date: "2025-01-01"},
+			Version{Major: 1, Minor: 2, Patch: 3, Commit: "def5678"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Compare(tt.b); got != tt.want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := tt.b.Compare(tt.a); got != -tt.want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+			if got := tt.a.Less(tt.b); got != (tt.want < 0) {
+				t.Errorf("%s.Less(%s) = %v, want %v", tt.a, tt.b, got, tt.want < 0)
+			}
+		})
+	}
+}
+
+// TestFromTag tests building a Version from a tag
+func TestFromTag(t *testing.T) {
+	v, err := FromTag("v1.10.2")
+	if err != nil || v != (Version{Major: 1, Minor: 10, Patch: 2}) {
+		t.Errorf("FromTag(v1.10.2) = %+v, %v", v, err)
+	}
+	if _, err := FromTag("main"); err == nil {
+		t.Error("FromTag(main) succeeded, want error")
+	}
+}
+
 func TestParseTag(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -191,6 +235,9 @@ func TestLoadLocalErrors(t *testing.T) {
 	if err == nil {
 		t.Error("LoadLocal() expected error for non-existent file")
 	}
+	if !errors.Is(err, os.ErrNotExist) || errors.Is(err, ErrCorrupt) {
+		t.Errorf("LoadLocal() missing file error = %v, want os.ErrNotExist only", err)
+	}
 
 	// Test loading invalid JSON
 	invalidPath := filepath.Join(tmpDir, "invalid.json")
@@ -202,4 +249,16 @@ func TestLoadLocalErrors(t *testing.T) {
 	if err == nil {
 		t.Error("LoadLocal() expected error for invalid JSON")
 	}
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("LoadLocal() invalid JSON error = %v, want ErrCorrupt", err)
+	}
+
+	// A write cut short leaves a truncated file
+	truncatedPath := filepath.Join(tmpDir, "truncated.json")
+	if err := os.WriteFile(truncatedPath, []byte(`{"major": 1, "min`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLocal(tmpDir, "truncated.json"); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("LoadLocal() truncated error = %v, want ErrCorrupt", err)
+	}
 }
@@ -11,8 +11,10 @@ import (
 
 	"github.com/distantorigin/next-launcher/internal/channel"
 	"github.com/distantorigin/next-launcher/internal/github"
+	"github.com/distantorigin/next-launcher/internal/install"
 	"github.com/distantorigin/next-launcher/internal/manifest"
 	"github.com/distantorigin/next-launcher/internal/paths"
+	"github.com/distantorigin/next-launcher/internal/version"
 )
 
 // TestEnvironment represents a complete test environment
@@ -66,9 +68,9 @@ func SetupTestEnvironment(t *testing.T) *TestEnvironment {
 	channelMgr := ChannelManager{baseDir: baseDir}
 
 	// Create GitHub client pointing to mock server
-	// Note: We can't easily redirect the client to mock server without refactoring
-	// For now, create client but tests should use mock server directly
 	githubClient := github.NewClient("testowner", "testrepo", &http.Client{})
+	githubClient.SetBaseURL(githubServer.URL)
+	githubClient.SetRetryPolicy(0, 0)
 
 	env := &TestEnvironment{
 		T:            t,
@@ -253,6 +255,7 @@ func (e *TestEnvironment) BuildManifestFromFiles(ref string) (map[string]manifes
 			Path: relPath,
 			Type: "blob",
 			SHA:  "fake-sha-" + relPath,
+			Size: info.Size(),
 		})
 
 		return nil
@@ -264,3 +267,97 @@ func (e *TestEnvironment) BuildManifestFromFiles(ref string) (map[string]manifes
 
 	return e.ManifestMgr.BuildFromTree(ref, treeItems, normalize, getRawURL)
 }
+
+// MockInstall provides mock dependencies for install.Run and records what it was asked to do
+type MockInstall struct {
+	// Files maps relative paths to the content the mock archive extracts
+	Files map[string]string
+	// Version is what the mock source reports as the latest version
+	Version version.Version
+	// ConfirmAnswer is returned from every confirmation prompt
+	ConfirmAnswer bool
+
+	Prompts         []string
+	DownloadedURL   string
+	ShortcutCreated string
+}
+
+// InstallDeps returns install.Deps backed by this mock
+func (m *MockInstall) InstallDeps() install.Deps {
+	return install.Deps{
+		Prompt:   m,
+		Source:   m,
+		Download: m,
+		Shortcut: m,
+		Client:   m,
+	}
+}
+
+// Confirm records the prompt and returns ConfirmAnswer
+func (m *MockInstall) Confirm(prompt string) bool {
+	m.Prompts = append(m.Prompts, prompt)
+	return m.ConfirmAnswer
+}
+
+// SelectFolder keeps the default location
+func (m *MockInstall) SelectFolder(defaultPath string) (string, error) {
+	return defaultPath, nil
+}
+
+// ArchiveURL returns a fake archive URL
+func (m *MockInstall) ArchiveURL() (string, error) {
+	return "https://example.invalid/archive.zip", nil
+}
+
+// RemoteManifest builds a manifest covering every mock archive file
+func (m *MockInstall) RemoteManifest() (map[string]manifest.FileInfo, error) {
+	result := make(map[string]manifest.FileInfo, len(m.Files))
+	for name := range m.Files {
+		result[name] = manifest.FileInfo{Name: name, Hash: "sha-" + name, URL: "https://example.invalid/" + name}
+	}
+	return result, nil
+}
+
+// LatestVersion returns the configured version
+func (m *MockInstall) LatestVersion() (*version.Version, error) {
+	v := m.Version
+	return &v, nil
+}
+
+// DownloadAndExtract writes the mock archive files into targetDir
+func (m *MockInstall) DownloadAndExtract(url, targetDir string) error {
+	m.DownloadedURL = url
+	for name, content := range m.Files {
+		fullPath := filepath.Join(targetDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateShortcut records the directory it was asked to link to
+func (m *MockInstall) CreateShortcut(installDir string) error {
+	m.ShortcutCreated = installDir
+	return nil
+}
+
+// EnsureClosed reports MUSHclient as not running
+func (m *MockInstall) EnsureClosed(installDir string) error {
+	return nil
+}
+
+// InstallOptions returns install.Options targeting a directory inside the environment
+func (e *TestEnvironment) InstallOptions(subdir string) install.Options {
+	return install.Options{
+		DefaultDir:   filepath.Join(e.BaseDir, subdir),
+		Channel:      "stable",
+		Quiet:        true,
+		ManifestFile: ".manifest",
+		VersionFile:  "version.json",
+		ExcludesFile: ".updater-excludes",
+	}
+}
@@ -1,12 +1,16 @@
 package integration
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
 
+	"github.com/distantorigin/next-launcher/internal/channel"
 	"github.com/distantorigin/next-launcher/internal/github"
 	"github.com/distantorigin/next-launcher/internal/install"
 	"github.com/distantorigin/next-launcher/internal/manifest"
+	"github.com/distantorigin/next-launcher/internal/version"
 )
 
 // TestFreshInstallation_CompleteFlow tests a complete fresh installation
@@ -33,9 +37,16 @@ func TestFreshInstallation_CompleteFlow(t *testing.T) {
 		t.Fatal("directory should not be marked as installed initially")
 	}
 
-	// Step 2: Convert GitHub tree to manifest tree items
-	manifestTree := make([]manifest.TreeItem, len(githubTree))
-	for i, item := range githubTree {
+	// Step 2: Fetch the tree from the mock server and convert it to manifest tree items
+	fetched, err := env.GitHubClient.GetTree(context.Background(), "stable")
+	if err != nil {
+		t.Fatalf("GetTree() error = %v", err)
+	}
+	if len(fetched.Tree) != len(githubTree) {
+		t.Fatalf("GetTree() returned %d items, want %d", len(fetched.Tree), len(githubTree))
+	}
+	manifestTree := make([]manifest.TreeItem, len(fetched.Tree))
+	for i, item := range fetched.Tree {
 		manifestTree[i] = manifest.TreeItem{
 			Path: item.Path,
 			Type: item.Type,
@@ -202,3 +213,85 @@ func TestFreshInstallation_UserConfigPreservation(t *testing.T) {
 		t.Error("user file was modified")
 	}
 }
+
+// TestInstallRun_DirectoryState drives the real install flow with mocks and checks the result on disk
+func TestInstallRun_DirectoryState(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	mock := &MockInstall{
+		Files: map[string]string{
+			"README.md":                 "readme",
+			"worlds/plugins/plugin.xml": "<plugin/>",
+		},
+		Version:       version.Version{Major: 1, Minor: 2, Patch: 3},
+		ConfirmAnswer: false, // Keep the default location
+	}
+	opts := env.InstallOptions("Miriani-Next")
+	opts.NonInteractive = true
+
+	installDir, err := install.Run(opts, mock.InstallDeps())
+	if err != nil {
+		t.Fatalf("install.Run() error = %v", err)
+	}
+	if installDir != opts.DefaultDir {
+		t.Errorf("install.Run() dir = %q, want %q", installDir, opts.DefaultDir)
+	}
+
+	for _, name := range []string{
+		"Miriani-Next/README.md",
+		"Miriani-Next/worlds/plugins/plugin.xml",
+		"Miriani-Next/.manifest",
+		"Miriani-Next/version.json",
+		"Miriani-Next/.updater-excludes",
+		"Miriani-Next/.update-channel",
+	} {
+		env.AssertFileExists(name)
+	}
+
+	if mock.ShortcutCreated != installDir {
+		t.Errorf("shortcut created for %q, want %q", mock.ShortcutCreated, installDir)
+	}
+
+	loaded, err := version.LoadLocal(installDir, "version.json")
+	if err != nil {
+		t.Fatalf("version.LoadLocal() error = %v", err)
+	}
+	if loaded.String() != mock.Version.String() {
+		t.Errorf("installed version = %s, want %s", loaded.String(), mock.Version.String())
+	}
+
+	ch, err := channel.Load(installDir)
+	if err != nil || ch != "stable" {
+		t.Errorf("channel.Load() = %q, %v; want stable", ch, err)
+	}
+}
+
+// TestInstallRun_Cancelled tests that declining the install leaves nothing behind
+func TestInstallRun_Cancelled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	mock := &MockInstall{
+		Files:         map[string]string{"README.md": "readme"},
+		ConfirmAnswer: false,
+	}
+	opts := env.InstallOptions("Miriani-Next")
+
+	_, err := install.Run(opts, mock.InstallDeps())
+	if !errors.Is(err, install.ErrCancelled) {
+		t.Fatalf("install.Run() error = %v, want ErrCancelled", err)
+	}
+	if mock.DownloadedURL != "" {
+		t.Error("archive should not be downloaded after cancelling")
+	}
+	env.AssertFileNotExists("Miriani-Next")
+}
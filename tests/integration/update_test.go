@@ -2,6 +2,7 @@ package integration
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/distantorigin/next-launcher/internal/manifest"
@@ -189,3 +190,82 @@ func TestNormalUpdate_UserConfigPreserved(t *testing.T) {
 		}
 	}
 }
+
+// TestNormalUpdate_PreviousStateSnapshot tests that the manifest and version
+// from before each update are kept in .old/ for rollback. Each run follows the
+// updater's sequence: compare the manifests, stop if there is nothing to
+// apply, otherwise clear .old/ and snapshot at the start of performUpdates.
+func TestNormalUpdate_PreviousStateSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	env := SetupTestEnvironment(t)
+	defer env.Cleanup()
+
+	originalDir, _ := os.Getwd()
+	os.Chdir(env.BaseDir)
+	defer os.Chdir(originalDir)
+
+	normalize := func(p string) string { return p }
+	notExcluded := func(string) bool { return false }
+	oldDir := filepath.Join(env.BaseDir, ".old")
+
+	// run applies the remote file, manifest and version as performUpdates and
+	// the save step after it do, unless the manifests already match
+	run := func(hash, content, version string) {
+		t.Helper()
+		remote := map[string]manifest.FileInfo{"file1.txt": {Name: "file1.txt", Hash: hash, URL: "url1"}}
+		local, err := env.ManifestMgr.LoadLocal()
+		if err != nil {
+			local = map[string]manifest.FileInfo{}
+		}
+		diff := manifest.Compare(local, remote, normalize, notExcluded)
+		if len(diff.Added) == 0 && len(diff.Changed) == 0 && len(diff.Removed) == 0 {
+			return
+		}
+
+		if err := env.ManifestMgr.SnapshotPrevious(env.BaseDir, oldDir, "version.json"); err != nil {
+			t.Fatalf("SnapshotPrevious() error = %v", err)
+		}
+		if err := env.CreateFile("file1.txt", content); err != nil {
+			t.Fatalf("failed to update file: %v", err)
+		}
+		if err := env.ManifestMgr.Save(remote, normalize); err != nil {
+			t.Fatalf("failed to save manifest: %v", err)
+		}
+		if err := env.CreateFile("version.json", version); err != nil {
+			t.Fatalf("failed to save version.json: %v", err)
+		}
+	}
+
+	// A fresh install has nothing to snapshot
+	run("hash1", "v1", `{"major": 1, "minor": 0, "patch": 0}`)
+	if _, err := os.Stat(filepath.Join(oldDir, ".manifest.prev")); !os.IsNotExist(err) {
+		t.Errorf("first install should leave no .manifest.prev, stat error = %v", err)
+	}
+	manifestV1, _ := env.ReadFile(".manifest")
+
+	// Something a previous update removed is cleared by the next real update
+	if err := env.CreateFile(filepath.Join(".old", "removed.txt"), "old"); err != nil {
+		t.Fatalf("failed to create removed file: %v", err)
+	}
+
+	run("hash2", "v2", `{"major": 1, "minor": 1, "patch": 0}`)
+	env.AssertFileContent(filepath.Join(".old", ".manifest.prev"), manifestV1)
+	env.AssertFileContent(filepath.Join(".old", "version.json.prev"), `{"major": 1, "minor": 0, "patch": 0}`)
+	if _, err := os.Stat(filepath.Join(oldDir, "removed.txt")); !os.IsNotExist(err) {
+		t.Errorf(".old/removed.txt should be cleared by the update, stat error = %v", err)
+	}
+
+	// A run with nothing to apply keeps the snapshot for a rollback
+	run("hash2", "v2", `{"major": 1, "minor": 1, "patch": 0}`)
+	env.AssertFileContent(filepath.Join(".old", ".manifest.prev"), manifestV1)
+	env.AssertFileContent(filepath.Join(".old", "version.json.prev"), `{"major": 1, "minor": 0, "patch": 0}`)
+
+	// The next update replaces the snapshot with the state it started from
+	manifestV2, _ := env.ReadFile(".manifest")
+	run("hash3", "v3", `{"major": 1, "minor": 2, "patch": 0}`)
+	env.AssertFileContent(filepath.Join(".old", ".manifest.prev"), manifestV2)
+	env.AssertFileContent(filepath.Join(".old", "version.json.prev"), `{"major": 1, "minor": 1, "patch": 0}`)
+}
@@ -4,6 +4,7 @@ package main
 
 import (
 	"archive/zip"
+	"context"
 	"crypto/sha1"
 	"embed"
 	"encoding/json"
@@ -15,9 +16,13 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -28,9 +33,13 @@ import (
 	"github.com/distantorigin/next-launcher/internal/audio"
 	"github.com/distantorigin/next-launcher/internal/changelog"
 	"github.com/distantorigin/next-launcher/internal/channel"
-	"github.com/distantorigin/next-launcher/internal/embedded"
+	"github.com/distantorigin/next-launcher/internal/config"
 	"github.com/distantorigin/next-launcher/internal/console"
+	"github.com/distantorigin/next-launcher/internal/download"
+	"github.com/distantorigin/next-launcher/internal/embedded"
+	"github.com/distantorigin/next-launcher/internal/events"
 	"github.com/distantorigin/next-launcher/internal/github"
+	"github.com/distantorigin/next-launcher/internal/i18n"
 	"github.com/distantorigin/next-launcher/internal/install"
 	"github.com/distantorigin/next-launcher/internal/manifest"
 	"github.com/distantorigin/next-launcher/internal/paths"
@@ -47,7 +56,9 @@ import (
 // Core functionality is delegated to internal packages:
 //   - internal/audio: Sound playback
 //   - internal/channel: Update channel persistence
+//   - internal/config: .updater-config preferences
 //   - internal/console: Console I/O and title
+//   - internal/events: Structured NDJSON event log
 //   - internal/github: GitHub API client
 //   - internal/install: Installation, world files, batch scripts
 //   - internal/manifest: Manifest management
@@ -59,56 +70,79 @@ import (
 // Use this index to navigate to major sections:
 //
 // 1. AUDIO/SOUND SYSTEM (wrappers for internal/audio)
+//    - resolveVolume, resolveMutedSounds
 //
 // 2. CONSOLE/UI (wrappers for internal/console)
-//    - initConsole, waitForUser, confirmAction
+//    - initConsole, handleInterrupts, waitForUser, confirmAction,
+//      askChoice
 //
 // 3. GITHUB API (wrappers for internal/github)
-//    - getLatestCommit, compareCommits, getLastCommitDate, validateChannelSwitch,
-//      getLatestTag, getZipURLForChannel, getGitHubTree, getRawURLForTag
+//    - loadGitHubToken, getLatestCommit, compareCommits, getLastCommitDate,
+//      validateChannelSwitch, getLatestTag, getZipURLForChannel, getGitHubTree,
+//      getRawURLForTag
 //
 // 4. MANIFEST MANAGEMENT
 //    - loadRemoteManifest, saveManifest
 //
 // 5. UPDATE OPERATIONS
-//    - getPendingUpdates, printCheckOutput, performUpdates, downloadFile,
-//      downloadAndExtractZip, downloadZipAndExtract
+//    - getPendingUpdates, diffManifests, runManifestDiff, printDryRun,
+//      runVerify, auditSkip, findUntrackedFiles, offerMoveUntracked,
+//      runRepair, runSelfUpdateDryRun, runConnectionTest,
+//      printCheckSummary, buildCheckResult, printCheckJSON, runStatus,
+//      printCheckOutput, performUpdates,
+//      verifyAppliedUpdates, failureListLimit, resolveMaxRate, downloadFile,
+//      downloadAndExtractZip, archiveDownloadPath, fetchArchive,
+//      downloadZipAndExtract
 //
 // 6. INSTALLATION
-//    - handleInstallation, copyUpdaterToInstallation
+//    - defaultInstallDir, handleInstallation, copyUpdaterToInstallation
 //
 // 7. PROCESS DETECTION (uses internal/process)
-//    - isProxianiRunning, isMUDMixerRunning, isMUSHClientRunning
+//    - offerProxyConfiguration, offerProxyDisconnect, proxyDetectionEnabled,
+//      saveProxyDetectSetting, isProxianiRunning, proxyIsLocal, loadProxyConfig,
+//      isMUDMixerRunning, isMUSHClientRunning
 //
 // 8. WORLD FILE UPDATES (uses internal/install)
-//    - updateWorldFile, updateWorldFileForProxiani, updateWorldFileForMUDMixer
+//    - locateWorldFiles, runConfigCommand, applyUpdaterConfig,
+//      printEffectiveConfig, updateWorldFile,
+//      restoreDirectConnection, updateWorldFileForProxiani,
+//      updateWorldFileForMUDMixer
 //
 // 9. VERSION MANAGEMENT (uses internal/version)
 //    - getLatestVersion, getLocalVersion
 //
 // 10. CHANNEL MANAGEMENT (uses internal/channel)
-//     - saveChannel, loadChannel, isValidChannel, promptForChannel,
+//     - saveChannel, loadChannel, channelRef, previewChannelSwitch, isValidChannel,
+//       gatherChannels, runChannelList, shortRef, promptForChannel,
 //       promptForBranch
 //
 // 11. INSTALLATION DETECTION (uses internal/install)
 //     - isInstalled, hasWorldFilesInCurrentDir, detectToastushInstallation,
-//       getDesktopPath, checkDesktopShortcut, getShortcutTarget
+//       knownInstallations, warnSharedPrefs, getDesktopPath, checkDesktopShortcut,
+//       desktopShortcutFor, getShortcutTarget
 //
 // 12. FILE OPERATIONS (uses internal/paths)
-//     - loadExcludes, moveToOldFolder, cleanOldFolder, hashFile
+//     - inOnlyScope, loadExcludes, reportExcludeChanges, isProtectedConfig,
+//       moveToOldFolder, snapshotPreviousState, findManaged, runStateCommand,
+//       runUninstall, scheduleRemoval, hashFile
 //
 // 13. PROMPTING/MENUS
 //     - promptForInstallFolder, promptInstallationMenu
 //
 // 14. CHANGELOG/RELEASE NOTES
-//     - buildChangelog, showChangelog
+//     - buildChangelog, stableReleaseNotes, changelogCommits, showChangelog
+//     - changelogHistoryLimit
+//     - runChangelogHistory, openChangelogFile
 //
 // 15. MIGRATION
-//     - handleToastushMigration
+//     - migrationTarget, sameVolume, handleToastushMigration
 //
 // 16. MISCELLANEOUS
-//     - needsMUSHClientRestart, launchMUSHClient, fatalError,
-//       createUpdaterExcludes, writeUpdateSuccess
+//     - needsMUSHClientRestart, loadRestartPatterns, launchMUSHClient, fatalError,
+//       reportFatal,
+//       createUpdaterExcludes, writeUpdateSuccess, writeUpdateFailure,
+//       recordApplied, writeUpdatePending,
+//       clearUpdatePending
 //
 // 17. MAIN
 //     - main (primary entry point)
@@ -149,24 +183,88 @@ var (
 // Wrapper functions for audio package - these exist so we don't have to
 // update every call site in the codebase
 
-func playSound(soundData []byte) {
-	audio.Play(soundData)
+// resolveVolume picks the master sound volume: -volume if given (saved for
+// the install in the current directory), otherwise the saved setting
+func resolveVolume() (int, error) {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return audio.DefaultVolume, err
+	}
+
+	if volumeFlag < 0 {
+		volume, err := audio.LoadVolume(baseDir)
+		if os.IsNotExist(err) {
+			if updaterConfig.Volume != nil {
+				return *updaterConfig.Volume, nil
+			}
+			return audio.DefaultVolume, nil
+		}
+		if err != nil {
+			return audio.DefaultVolume, fmt.Errorf("ignoring saved volume: %w", err)
+		}
+		return volume, nil
+	}
+
+	if err := audio.ValidateVolume(volumeFlag); err != nil {
+		return audio.DefaultVolume, err
+	}
+	if install.IsInstalled(baseDir) {
+		if err := audio.SaveVolume(baseDir, volumeFlag); err != nil {
+			return volumeFlag, fmt.Errorf("failed to save volume: %w", err)
+		}
+	}
+	return volumeFlag, nil
+}
+
+// resolveMutedSounds picks the sound categories to mute: -mute if given
+// (saved for the install in the current directory), otherwise the saved setting
+func resolveMutedSounds() ([]audio.Category, error) {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	if muteFlag == "" {
+		categories, err := audio.LoadMuted(baseDir)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ignoring saved muted sounds: %w", err)
+		}
+		return categories, nil
+	}
+
+	categories, err := audio.ParseCategories(muteFlag)
+	if err != nil {
+		return nil, err
+	}
+	if install.IsInstalled(baseDir) {
+		if err := audio.SaveMuted(baseDir, categories); err != nil {
+			return categories, fmt.Errorf("failed to save muted sounds: %w", err)
+		}
+	}
+	return categories, nil
+}
+
+func playSound(c audio.Category, soundData []byte) {
+	audio.Play(c, soundData)
 }
 
 func stopAllSounds() {
 	audio.StopAll()
 }
 
-func playSoundAsync(soundData []byte, volumeDB float64) {
-	audio.PlayAsync(soundData, volumeDB)
+func playSoundAsync(c audio.Category, soundData []byte, volumeDB float64) {
+	audio.PlayAsync(c, soundData, volumeDB)
 }
 
-func playSoundAsyncLoop(soundData []byte, volumeDB float64, loop bool) {
-	audio.PlayAsyncLoop(soundData, volumeDB, loop)
+func playSoundAsyncLoop(c audio.Category, soundData []byte, volumeDB float64, loop bool) {
+	audio.PlayAsyncLoop(c, soundData, volumeDB, loop)
 }
 
-func playSoundWithDucking(soundData []byte, foregroundVolumeDB float64) {
-	audio.PlayWithDucking(soundData, foregroundVolumeDB)
+func playSoundWithDucking(c audio.Category, soundData []byte, foregroundVolumeDB float64) {
+	audio.PlayWithDucking(c, soundData, foregroundVolumeDB)
 }
 
 // soundAdapter implements prompt.SoundPlayer
@@ -175,22 +273,22 @@ type soundAdapter struct{}
 func (s soundAdapter) Play(name string) {
 	switch name {
 	case "select":
-		playSound(selectSound)
+		playSound(audio.CategoryUI, selectSound)
 	case "success":
-		playSound(successSound)
+		playSound(audio.CategoryUI, successSound)
 	case "error":
-		playSound(errorSound)
+		playSound(audio.CategoryError, errorSound)
 	}
 }
 
 func (s soundAdapter) PlayAsync(name string) {
 	switch name {
 	case "select":
-		playSoundAsync(selectSound, 0.0)
+		playSoundAsync(audio.CategoryUI, selectSound, 0.0)
 	case "success":
-		playSoundAsync(successSound, 0.0)
+		playSoundAsync(audio.CategoryUI, successSound, 0.0)
 	case "error":
-		playSoundAsync(errorSound, 0.0)
+		playSoundAsync(audio.CategoryError, errorSound, 0.0)
 	}
 }
 
@@ -198,6 +296,7 @@ func (s soundAdapter) PlayAsync(name string) {
 func promptConfig() prompt.Config {
 	return prompt.Config{
 		NonInteractive:   nonInteractive,
+		AssumeYes:        yesFlag,
 		Sound:            soundAdapter{},
 		GetConsoleWindow: console.GetWindow,
 	}
@@ -208,7 +307,31 @@ func promptConfig() prompt.Config {
 // ============================================================================
 
 func initConsole() bool {
-	return console.Attach()
+	if !console.Attach() {
+		return false
+	}
+	if !noColorFlag {
+		console.EnableColor()
+	}
+	return true
+}
+
+// handleInterrupts cancels runCtx on Ctrl+C or SIGTERM so in-flight requests,
+// downloads and extraction stop at a safe point. Only the first signal is
+// caught: a second one ends the process immediately, which also gets the user
+// out of a prompt that isn't waiting on runCtx.
+func handleInterrupts() {
+	ctx, cancel := context.WithCancel(context.Background())
+	runCtx = ctx
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		console.Warn("\nCancelling... press Ctrl+C again to quit immediately.")
+		cancel()
+	}()
 }
 
 // appVersion is set via linker flags: -ldflags "-X main.appVersion=1.3.2"
@@ -221,10 +344,48 @@ const (
 	versionFile  = "version.json"
 	excludesFile = ".updater-excludes"
 	channelFile  = ".update-channel"
+	resultFile   = ".update-result"
+	pendingFile  = ".update-pending"
+	oldFolder    = ".old"
 	zipThreshold = 30
 	fileWorkers  = 6
 	title        = "Miriani"
 
+	// restartPathsFile lists paths whose update requires restarting MUSHclient
+	restartPathsFile = ".restart-paths"
+
+	// releaseNotesFile is the bundled changelog, used for stable releases
+	// when GitHub's release notes can't be fetched
+	releaseNotesFile = "docs/changelog.txt"
+
+	// githubTokenFile optionally holds a personal access token (GITHUB_TOKEN takes precedence)
+	githubTokenFile = ".github-token"
+
+	// proxyDetectFile holds "off" when proxy detection is disabled for an install
+	proxyDetectFile = ".proxy-detect"
+
+	// proxyConfigFile optionally overrides the proxy host and ports for an install
+	proxyConfigFile = ".proxy-config"
+
+	// githubCacheFile keeps GitHub ETags and responses between runs for conditional requests
+	githubCacheFile = ".github-cache.json"
+
+	// selfUpdateLogFile records each background self-update attempt, next to the updater
+	selfUpdateLogFile = ".selfupdate-log"
+
+	// exitPartialUpdate is the exit status when -best-effort applied only some files
+	exitPartialUpdate = 2
+
+	// exitVerifyProblems is the exit status when verify finds missing or modified files
+	exitVerifyProblems = 1
+
+	// exitVerifyUntracked is the exit status when verify finds only files the
+	// manifest doesn't track
+	exitVerifyUntracked = 3
+
+	// maxListedFailures caps how many failed files are printed without -verbose
+	maxListedFailures = 10
+
 	// World file and directory names
 	worldFileName = "miriani.mcl"
 	worldsDir     = "worlds"
@@ -232,9 +393,10 @@ const (
 
 	// Server addresses
 	defaultServer = "toastsoft.net"
+	defaultPort   = "1234"
 	localServer   = "localhost"
 
-	// Port numbers for Proxiani and MUDMixer
+	// Default port numbers for Proxiani and MUDMixer (see proxyConfigFile)
 	proxianiPort = "1234"
 	mudMixerPort = "7788"
 
@@ -245,6 +407,13 @@ const (
 	DETACHED_PROCESS = 0x00000008
 )
 
+// allowedDownloadHosts lists the hosts manifest URLs may point at.
+// Anything else is refused, so a tampered .manifest can't redirect downloads.
+var allowedDownloadHosts = []string{
+	"raw.githubusercontent.com",
+	"github.com",
+}
+
 var (
 	// baseURL is dynamically constructed based on channel
 	baseURL string
@@ -254,6 +423,11 @@ var (
 	ghClient *github.Client
 	// manifestManager handles manifest operations
 	manifestManager *manifest.Manager
+	// eventLog receives structured progress events (nil unless -event-log is set)
+	eventLog *events.Log
+	// runCtx is cancelled on Ctrl+C or SIGTERM so GitHub requests and downloads
+	// stop cleanly instead of the process dying mid-write
+	runCtx = context.Background()
 )
 
 var (
@@ -268,11 +442,58 @@ var (
 	channelExplicitlySet    bool
 	allowRestartFlag        bool
 	selfUpdateCheckFlag     bool
+	dryRunFlag              bool
+	bestEffortFlag          bool
+	noChangelogFlag         bool
+	changelogOutFlag        string
+	changelogViewerFlag     string
+	summaryOnlyFlag         bool
+	progressIntervalFlag    time.Duration
+	previewFlag             bool
+	noProxyDetectFlag       bool
+	siteFlag                string
+	portFlag                int
+	configArgs              []string
+	noAutoManifestFlag      bool
+	verifyAfterFlag         bool
+	onlyFlag                stringListFlag
+	overwriteExistingFlag   bool
+	yesFlag                 bool
+	listChannelsJSONFlag    bool
+	downloadModeFlag        string
+	langFlag                string
+	apiRetriesFlag          int
+	elevateFlag             bool
+	installTargetFlag       string
+	installDirFlag          string
+	eventLogFlag            string
+	volumeFlag              int
+	muteFlag                string
+	noColorFlag             bool
+	maxRateFlag             int
+	jsonFlag                bool
+	statusCheckFlag         bool
+	confirmFlag             bool
+	purgeFlag               bool
+	uninstallAllFlag        bool
 	subcommand              string // Current subcommand being executed
+
+	// updaterConfig holds the .updater-config preferences; explicitFlags
+	// names the flags given on the command line, which take precedence
+	updaterConfig config.Config
+	explicitFlags = map[string]bool{}
+
+	// userExcludes are the .updater-excludes patterns, loaded with the
+	// manifest manager
+	userExcludes paths.Excludes
 )
 
 // ErrUserCancelled is returned when the user cancels an operation
-var ErrUserCancelled = fmt.Errorf("operation cancelled by user")
+var ErrUserCancelled = install.ErrCancelled
+
+// errElevated is returned once an elevated copy of the updater has been
+// started to carry on; the caller returns to main without reporting an error
+var errElevated = errors.New("relaunched as administrator")
 
 // Version is an alias for version.Version for backwards compatibility
 type Version = version.Version
@@ -290,27 +511,52 @@ func versionString(v Version) string {
 // SECTION 3: GITHUB API
 // ============================================================================
 
+// loadGitHubToken returns the API token from GITHUB_TOKEN or, failing that,
+// the .github-token file in the install directory. Empty means unauthenticated.
+func loadGitHubToken() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	data, err := os.ReadFile(githubTokenFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// latestCommits remembers each ref's head commit for the rest of the run, so
+// repeated version lookups cost one API call per ref
+var (
+	latestCommitsMu sync.Mutex
+	latestCommits   = make(map[string]*github.Commit)
+)
+
 func getLatestCommit(ref string) (*github.Commit, error) {
-	return ghClient.GetLatestCommit(ref)
+	latestCommitsMu.Lock()
+	defer latestCommitsMu.Unlock()
+	if commit, ok := latestCommits[ref]; ok {
+		return commit, nil
+	}
+
+	commit, err := ghClient.GetLatestCommit(runCtx, ref)
+	if err != nil {
+		return nil, err
+	}
+	latestCommits[ref] = commit
+	return commit, nil
 }
 
 func compareCommits(base, head string) (*github.Comparison, error) {
-	return ghClient.CompareCommits(base, head)
+	return ghClient.CompareCommits(runCtx, base, head)
 }
 
 func getLastCommitDate(ref string) (string, error) {
-	dateStr, err := ghClient.GetLastCommitDate(ref)
+	dateStr, err := ghClient.GetLastCommitDate(runCtx, ref)
 	if err != nil {
 		return "", err
 	}
 
-	// Parse and format the date
-	t, err := time.Parse(time.RFC3339, dateStr)
-	if err != nil {
-		return dateStr, nil // Return raw if parsing fails
-	}
-
-	return t.Format("Jan 2, 2006"), nil
+	return github.FormatCommitDate(dateStr), nil
 }
 
 // validateChannelSwitch validates switching from one channel to another
@@ -331,6 +577,31 @@ func validateChannelSwitch(fromChannel, toChannel string) error {
 			return fmt.Errorf("failed to get latest stable tag: %w", err)
 		}
 
+		refuseDowngrade := func(detail string) error {
+			fmt.Printf("\nCannot switch to stable - it is older than your current version.\n")
+			fmt.Println(detail)
+			fmt.Println("\nThis would downgrade your installation, which could cause issues.")
+			fmt.Println("\nPlease wait for the next stable release before switching.")
+			playSoundAsync(audio.CategoryError, errorSound, 0.0)
+			return fmt.Errorf("stable is behind %s, refusing downgrade", fromChannel)
+		}
+
+		// Different version numbers settle it; only the same version needs
+		// GitHub to count commits
+		if stable, err := version.FromTag(latestTag); err == nil {
+			if local, err := getLocalVersion(); err == nil {
+				switch stable.Compare(*local) {
+				case 1:
+					if !quietFlag {
+						fmt.Printf("Stable (%s) is newer than your installed %s. Safe to switch.\n", latestTag, local.String())
+					}
+					return nil
+				case -1:
+					return refuseDowngrade(fmt.Sprintf("Stable (%s) is older than your installed %s.", latestTag, local.String()))
+				}
+			}
+		}
+
 		compareBranch := "main"
 		if fromChannel != "dev" {
 			compareBranch = fromChannel
@@ -342,12 +613,7 @@ func validateChannelSwitch(fromChannel, toChannel string) error {
 		}
 
 		if comparison.BehindBy > 0 {
-			fmt.Printf("\nCannot switch to stable - it is older than your current version.\n")
-			fmt.Printf("Stable (%s) is %d commits behind %s.\n", latestTag, comparison.BehindBy, fromChannel)
-			fmt.Println("\nThis would downgrade your installation, which could cause issues.")
-			fmt.Println("\nPlease wait for the next stable release before switching.")
-			playSoundAsync(errorSound, 0.0)
-			return fmt.Errorf("stable is behind %s, refusing downgrade", fromChannel)
+			return refuseDowngrade(fmt.Sprintf("Stable (%s) is %d commits behind %s.", latestTag, comparison.BehindBy, fromChannel))
 		}
 
 		if comparison.AheadBy > 0 {
@@ -371,7 +637,7 @@ func validateChannelSwitch(fromChannel, toChannel string) error {
 		latestTag, err := getLatestTag()
 		if err != nil {
 			if !quietFlag {
-				fmt.Println("Warning: couldn't check stable version for comparison")
+				console.Warn("Warning: couldn't check stable version for comparison")
 			}
 			return nil
 		}
@@ -379,7 +645,7 @@ func validateChannelSwitch(fromChannel, toChannel string) error {
 		comparison, err := compareCommits("main", latestTag)
 		if err != nil {
 			if !quietFlag {
-				fmt.Println("Warning: couldn't compare dev to stable")
+				console.Warn("Warning: couldn't compare dev to stable")
 			}
 			return nil
 		}
@@ -420,7 +686,7 @@ func validateChannelSwitch(fromChannel, toChannel string) error {
 		if err != nil {
 			// Non-fatal, just warn
 			if !quietFlag {
-				fmt.Printf("Warning: couldn't compare %s to %s\n", toChannel, fromChannel)
+				console.Warn("Warning: couldn't compare %s to %s", toChannel, fromChannel)
 			}
 			return nil
 		}
@@ -443,7 +709,7 @@ func validateChannelSwitch(fromChannel, toChannel string) error {
 }
 
 func getLatestTag() (string, error) {
-	return ghClient.GetLatestTag()
+	return ghClient.GetLatestTag(runCtx)
 }
 
 func getZipURLForChannel() (string, error) {
@@ -461,7 +727,7 @@ func getZipURLForChannel() (string, error) {
 }
 
 func getGitHubTree(ref string) (*github.Tree, error) {
-	return ghClient.GetTree(ref)
+	return ghClient.GetTree(runCtx, ref)
 }
 
 func getRawURLForTag(tag string, path string) string {
@@ -473,15 +739,172 @@ func getRawURLForTag(tag string, path string) string {
 // ============================================================================
 
 type UpdateResult struct {
-	Result       string   `json:"result"`                  // "success" or "failure"
+	Result       string   `json:"result"`                  // "success", "partial" or "failure"
 	Message      string   `json:"message,omitempty"`       // Error message if failure
 	Version      string   `json:"version,omitempty"`       // Full version string if success
-	FilesAdded   []string `json:"files_added,omitempty"`   // Array of added/updated file paths
+	FilesAdded   []string `json:"files_added,omitempty"`   // Array of added/updated file paths (on failure, the ones written before it)
 	FilesDeleted []string `json:"files_deleted,omitempty"` // Array of deleted file paths
+	FilesFailed  []string `json:"files_failed,omitempty"`  // Array of file paths that failed
 	Restarted    bool     `json:"restarted"`               // Whether MUSHclient was restarted
+
+	Failures []FileFailure `json:"failures,omitempty"` // Each failed file with the reason it failed
+
+	Verified        *bool    `json:"verified,omitempty"`         // Post-update check outcome; omitted if it didn't run
+	FilesUnverified []string `json:"files_unverified,omitempty"` // Files whose hash didn't match after updating
+}
+
+// FileFailure is one file an update couldn't apply, in .update-result
+type FileFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// CheckResult is what `check -json` prints, for tools that would otherwise
+// parse the text output of printCheckOutput
+type CheckResult struct {
+	Status          string   `json:"status"`                    // "update-available", "up-to-date", "not-installed", "channel-invalid" or "error"
+	UpdateAvailable bool     `json:"update_available"`          // Whether running the updater would change anything
+	Channel         string   `json:"channel"`                   // Channel that was checked
+	CurrentVersion  string   `json:"current_version,omitempty"` // Installed version, if known
+	LatestVersion   string   `json:"latest_version,omitempty"`  // Version on the channel, if known
+	RestartRequired bool     `json:"restart_required"`          // Whether MUSHclient must be closed to update
+	Updates         int      `json:"updates"`                   // Number of files to add or update
+	Deletions       int      `json:"deletions"`                 // Number of files to remove
+	DownloadSize    int64    `json:"download_size,omitempty"`   // Total bytes to download, if the manifest records sizes
+	FilesAdded      []string `json:"files_added,omitempty"`     // Array of added/updated file paths
+	FilesDeleted    []string `json:"files_deleted,omitempty"`   // Array of deleted file paths
+	Error           string   `json:"error,omitempty"`           // Error message if the check failed
+}
+
+// updateVerification is the outcome of verifyAppliedUpdates, recorded in
+// .update-result. Nil when the check didn't run.
+var updateVerification *UpdateResult
+
+// updateFailures lists the files a failed update couldn't download, recorded
+// in .update-result by writeUpdateFailure
+var updateFailures *partialUpdateError
+
+// appliedFiles collects the files written this run, so a run that fails part
+// way can report which ones had already been updated
+var appliedFiles struct {
+	sync.Mutex
+	names []string
+}
+
+// recordApplied adds a file to appliedFiles. Safe for concurrent use.
+func recordApplied(name string) {
+	appliedFiles.Lock()
+	defer appliedFiles.Unlock()
+	appliedFiles.names = append(appliedFiles.names, name)
 }
 
 func writeUpdateSuccess(updates []manifest.FileInfo, deletedFiles []string, wasRestarted bool) error {
+	return writeUpdateResult(UpdateResult{Result: "success", Restarted: wasRestarted}, updates, deletedFiles)
+}
+
+// writeUpdateFailure records a failed run in .update-result, with the files
+// that were written before the failure and any that failed to download. The
+// manifest isn't updated on failure, so the next run checks them all again.
+func writeUpdateFailure(message string) error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	result := UpdateResult{Result: "failure", Message: message}
+	appliedFiles.Lock()
+	result.FilesAdded = append([]string(nil), appliedFiles.names...)
+	appliedFiles.Unlock()
+	sort.Strings(result.FilesAdded)
+	if updateFailures != nil {
+		result.FilesFailed = updateFailures.Failed
+		result.Failures = updateFailures.failures()
+	}
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal update result: %w", err)
+	}
+
+	return paths.WriteFileAtomic(filepath.Join(baseDir, resultFile), append(jsonData, '\n'), 0644)
+}
+
+// UpdatePending is written to .update-pending when a non-interactive run finds
+// an update it can't apply until MUSHclient restarts, so the game can tell the
+// player an update is ready
+type UpdatePending struct {
+	Version      string   `json:"version"`                 // Version the update would install
+	Summary      string   `json:"summary"`                 // One-line description of the changes
+	FilesChanged []string `json:"files_changed,omitempty"` // Paths that would be added or updated
+	FilesDeleted []string `json:"files_deleted,omitempty"` // Paths that would be removed
+}
+
+// writeUpdatePending records a deferred update in .update-pending
+func writeUpdatePending(updates []manifest.FileInfo, deletedFiles []string) error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	pending := UpdatePending{
+		Version:      "unknown",
+		Summary:      fmt.Sprintf("%d files to update, %d to remove; restart MUSHclient to apply", len(updates), len(deletedFiles)),
+		FilesDeleted: deletedFiles,
+	}
+	if latestVer, err := getLatestVersion(); err == nil {
+		pending.Version = latestVer.String()
+	}
+	for _, u := range updates {
+		pending.FilesChanged = append(pending.FilesChanged, u.Name)
+	}
+
+	jsonData, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending update: %w", err)
+	}
+	return paths.WriteFileAtomic(filepath.Join(baseDir, pendingFile), append(jsonData, '\n'), 0644)
+}
+
+// clearUpdatePending removes .update-pending once there's nothing left to apply
+func clearUpdatePending() {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if err := os.Remove(filepath.Join(baseDir, pendingFile)); err != nil && !os.IsNotExist(err) {
+		console.Log("Warning: failed to remove %s: %v", pendingFile, err)
+	}
+}
+
+// shouldWriteResult reports whether this run records its outcome in .update-result.
+// Quiet runs print nothing, so embedding callers rely on the file there too.
+func shouldWriteResult() bool {
+	return nonInteractive || quietFlag
+}
+
+// writeUpdatePartial records a -best-effort update where some files failed
+func writeUpdatePartial(updates []manifest.FileInfo, deletedFiles []string, partial *partialUpdateError, wasRestarted bool) error {
+	failed := make(map[string]bool, len(partial.Failed))
+	for _, name := range partial.Failed {
+		failed[name] = true
+	}
+	var applied []manifest.FileInfo
+	for _, u := range updates {
+		if !failed[u.Name] {
+			applied = append(applied, u)
+		}
+	}
+
+	return writeUpdateResult(UpdateResult{
+		Result:      "partial",
+		Message:     partial.Error(),
+		FilesFailed: partial.Failed,
+		Failures:    partial.failures(),
+		Restarted:   wasRestarted,
+	}, applied, deletedFiles)
+}
+
+// writeUpdateResult fills in the version and file lists and writes .update-result
+func writeUpdateResult(result UpdateResult, updates []manifest.FileInfo, deletedFiles []string) error {
 	baseDir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -499,12 +922,12 @@ func writeUpdateSuccess(updates []manifest.FileInfo, deletedFiles []string, wasR
 		filesAdded = append(filesAdded, update.Name)
 	}
 
-	result := UpdateResult{
-		Result:       "success",
-		Version:      versionStr,
-		FilesAdded:   filesAdded,
-		FilesDeleted: deletedFiles,
-		Restarted:    wasRestarted,
+	result.Version = versionStr
+	result.FilesAdded = filesAdded
+	result.FilesDeleted = deletedFiles
+	if updateVerification != nil {
+		result.Verified = updateVerification.Verified
+		result.FilesUnverified = updateVerification.FilesUnverified
 	}
 
 	jsonData, err := json.MarshalIndent(result, "", "  ")
@@ -512,8 +935,8 @@ func writeUpdateSuccess(updates []manifest.FileInfo, deletedFiles []string, wasR
 		return fmt.Errorf("failed to marshal update result: %w", err)
 	}
 
-	resultPath := filepath.Join(baseDir, ".update-result")
-	return os.WriteFile(resultPath, append(jsonData, '\n'), 0644)
+	resultPath := filepath.Join(baseDir, resultFile)
+	return paths.WriteFileAtomic(resultPath, append(jsonData, '\n'), 0644)
 }
 
 // ============================================================================
@@ -521,23 +944,40 @@ func writeUpdateSuccess(updates []manifest.FileInfo, deletedFiles []string, wasR
 // ============================================================================
 
 func main() {
-	// Global panic handler to prevent path leakage in error messages
+	// Every fatal error ends here: fatalError unwinds to this handler, which
+	// reports it once the deferred cleanup above it has run. Other panics are
+	// reported without their stack, to prevent path leakage in error messages.
 	defer func() {
-		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "\nAn unexpected error occurred: %v\n", r)
-			fmt.Fprintln(os.Stderr, "Please report this issue to the developers.")
-			playSound(errorSound)
-			if !nonInteractive {
-				waitForUser("\nPress Enter to exit...")
+		r := recover()
+		if r == nil {
+			return
+		}
+		if fatal, ok := r.(fatalExit); ok {
+			code := reportFatal(fatal.message)
+			eventLog.Close()
+			os.Exit(code)
+		}
+		fmt.Fprintf(os.Stderr, "\nAn unexpected error occurred: %v\n", r)
+		fmt.Fprintln(os.Stderr, "Please report this issue to the developers.")
+		eventLog.Result("failure", fmt.Sprintf("unexpected error: %v", r))
+		if shouldWriteResult() {
+			if err := writeUpdateFailure(fmt.Sprintf("unexpected error: %v", r)); err != nil {
+				console.Log("Warning: failed to write .update-result: %v", err)
 			}
-			os.Exit(1)
 		}
+		playSound(audio.CategoryError, errorSound)
+		if !nonInteractive {
+			waitForUser("\n" + i18n.T("press_enter_exit"))
+		}
+		eventLog.Close()
+		os.Exit(1)
 	}()
 
 	// Configure log package to not include file paths
 	log.SetFlags(0)
 
-	// Clean up old updater binary if we just self-updated
+	// Tell the updater that restarted us we came up, then clean up its binary
+	selfupdate.ConfirmStarted()
 	selfupdate.CleanupOld()
 
 	// Normalize double-dash flags to single-dash (Go's flag package uses single dash)
@@ -565,6 +1005,40 @@ func main() {
 	flag.BoolVar(&nonInteractive, "non-interactive", false, "Non-interactive mode: log to file, no prompts, write .update-success")
 	flag.BoolVar(&allowRestartFlag, "allow-restart", false, "Allow restart in non-interactive mode (use with -non-interactive)")
 	flag.BoolVar(&selfUpdateCheckFlag, "self-update-check", false, "Internal: Check for updater self-update (spawned in background)")
+	flag.BoolVar(&bestEffortFlag, "best-effort", false, "Apply the files that downloaded even if some fail, and exit with status 2")
+	flag.BoolVar(&noChangelogFlag, "no-changelog", false, "Don't offer to show the changelog after updating")
+	flag.DurationVar(&progressIntervalFlag, "progress-interval", 100*time.Millisecond, "Minimum time between progress redraws (e.g. 500ms, 2s)")
+	flag.BoolVar(&noAutoManifestFlag, "no-auto-manifest", false, "Fail instead of regenerating a missing or corrupt local manifest")
+	flag.BoolVar(&listChannelsJSONFlag, "list-channels-json", false, "Print the available update channels as JSON and exit")
+	flag.BoolVar(&yesFlag, "yes", false, "Answer yes to every yes/no question, keeping the rest of the interactive run")
+	flag.BoolVar(&yesFlag, "y", false, "Shorthand for -yes")
+	flag.BoolVar(&overwriteExistingFlag, "overwrite-existing", false, "Let a non-interactive migration move an existing Miriani-Next folder to a backup")
+	flag.Var(&onlyFlag, "only", "Only update files under this path prefix (repeatable)")
+	flag.BoolVar(&verifyAfterFlag, "verify-after", false, "Re-hash updated files after applying them (always on with -non-interactive)")
+	flag.StringVar(&siteFlag, "site", "", "With config connection, the host the world file should connect to")
+	flag.IntVar(&portFlag, "port", 0, "With config connection, the port the world file should connect to")
+	flag.BoolVar(&noProxyDetectFlag, "no-proxy-detect", false, "Don't look for Proxiani or MUDMixer during install (remembered for the install)")
+	flag.BoolVar(&previewFlag, "preview", false, "With switch, show what switching would change without saving the channel")
+	flag.BoolVar(&summaryOnlyFlag, "summary-only", false, "With check, print a single status token and skip version lookups")
+	flag.StringVar(&changelogOutFlag, "changelog-out", "", "Write the changelog of every update to this file")
+	flag.StringVar(&changelogViewerFlag, "changelog-viewer", "", "How to show the changelog after updating: console (paged in this window) or notepad (default: ask)")
+	flag.StringVar(&downloadModeFlag, "download-mode", "auto", "Download strategy: auto, files (individual downloads) or zip (full archive)")
+	flag.StringVar(&langFlag, "lang", "", "Language for messages, e.g. en (default: system locale)")
+	flag.IntVar(&apiRetriesFlag, "api-retries", github.DefaultRetries, "How many times to retry failed GitHub API requests")
+	flag.BoolVar(&elevateFlag, "elevate", false, "Allow relaunching as administrator in non-interactive mode when the target folder requires it")
+	flag.StringVar(&installTargetFlag, "install-target", "", "Internal: installation folder chosen before relaunching elevated")
+	flag.StringVar(&installDirFlag, "install-dir", "", "Absolute folder to install to, add the updater to, or migrate Toastush into (skips the folder dialog)")
+	flag.StringVar(&eventLogFlag, "event-log", "", "Write newline-delimited JSON progress events to this file or named pipe")
+	flag.BoolVar(&dryRunFlag, "dry-run", false, "List what an update would change without touching any files (with selfupdate-check, report what the self-update would do)")
+	flag.IntVar(&volumeFlag, "volume", -1, "Sound volume from 0 to 100 (remembered for the install)")
+	flag.StringVar(&muteFlag, "mute", "", "Comma-separated sound categories to mute: ui, progress, status, or none (remembered for the install)")
+	flag.IntVar(&maxRateFlag, "max-rate", -1, "Cap download speed in KB/s, 0 for unlimited (remembered for the install)")
+	flag.BoolVar(&noColorFlag, "no-color", false, "Print plain text without ANSI colors (also set by the NO_COLOR environment variable)")
+	flag.BoolVar(&jsonFlag, "json", false, "With check or status, print the result as a single JSON object")
+	flag.BoolVar(&statusCheckFlag, "check", false, "With status, also contact GitHub to validate the channel and look for updates")
+	flag.BoolVar(&confirmFlag, "confirm", false, "With uninstall, proceed without asking (required with -non-interactive)")
+	flag.BoolVar(&uninstallAllFlag, "all", false, "With uninstall, remove the whole installation, not just the updater's files")
+	flag.BoolVar(&purgeFlag, "purge", false, "With uninstall -all, also remove user settings (MUSHclient.ini and the preferences database)")
 
 	// Only parse flags if not using subcommand syntax
 	if subcommand == "" {
@@ -574,14 +1048,48 @@ func main() {
 		flag.CommandLine.Parse(subcommandArgs)
 	}
 
+	// Preferences from .updater-config fill in any flag not given explicitly
+	configErr := applyUpdaterConfig()
+
+	// Select the message catalog before anything user-facing is printed
+	langErr := i18n.Init(langFlag)
+
 	// Initialize console and audio packages
 	console.Init(quietFlag)
-	audio.Init(quietFlag, verboseFlag, func(format string, args ...interface{}) {
+	volume, volumeErr := resolveVolume()
+	audio.Init(quietFlag, verboseFlag, volume, func(format string, args ...interface{}) {
 		log.Printf(format, args...)
 	})
+	mutedSounds, muteErr := resolveMutedSounds()
+	audio.SetMuted(mutedSounds)
+	maxRate, maxRateErr := resolveMaxRate()
+	download.SetMaxRate(maxRate)
 
 	// Attach to or create console for output
 	initConsole()
+	handleInterrupts()
+	if configErr != nil {
+		console.Warn("Warning: ignoring %v", configErr)
+	}
+	if langErr != nil {
+		console.Warn("Warning: %v", langErr)
+	}
+	if volumeErr != nil {
+		console.Warn("Warning: %v", volumeErr)
+	}
+	if muteErr != nil {
+		console.Warn("Warning: %v", muteErr)
+	}
+	if maxRateErr != nil {
+		console.Warn("Warning: %v", maxRateErr)
+	}
+
+	if eventLogFlag != "" {
+		var err error
+		if eventLog, err = events.Open(eventLogFlag); err != nil {
+			console.Warn("Warning: %v", err)
+		}
+	}
 
 	console.SetTitle(title)
 	// Clean up old updater binary if this is a post-update restart
@@ -610,10 +1118,39 @@ func main() {
 		// Get channel from first remaining arg after flags
 		if len(flag.Args()) > 0 {
 			switchChannel = flag.Args()[0]
+			// Allow flags after the channel too (switch stable -preview)
+			flag.CommandLine.Parse(flag.Args()[1:])
 		} else {
 			switchChannel = "" // Will prompt interactively
 		}
 		switchChannelSubcommand = true
+	case "selfupdate-check":
+		// Self-update dry run - handled after initialization
+	case "test-connection":
+		// Connectivity check - handled after httpClient init
+	case "manifest-diff":
+		// Manifest comparison - handled after channel load
+	case "channels", "list-channels":
+		// Channel listing - handled after channel load
+	case "repair":
+		// Re-download drifted files - handled after manifest manager init
+	case "verify":
+		// Read-only integrity audit - handled after manifest manager init
+	case "state":
+		// Updater state listing - handled with config (no network)
+	case "status":
+		// Installation overview - handled after channel load
+	case "uninstall":
+		// Removes the updater's files (or the install) - handled with config (no network)
+	case "changelog":
+		// Changelog history - handled with config (no network)
+	case "config":
+		// Setting name first, then its flags (config connection -site host -port 1234)
+		if len(flag.Args()) > 0 {
+			configArgs = flag.Args()[:1]
+			flag.CommandLine.Parse(flag.Args()[1:])
+			configArgs = append(configArgs, flag.Args()...)
+		}
 	case "":
 		// No subcommand, continue normally
 	default:
@@ -621,10 +1158,113 @@ func main() {
 		fmt.Println("\nAvailable subcommands:")
 		fmt.Println("  check                    Check for updates only")
 		fmt.Println("  switch [stable|dev]      Switch update channel (prompts if no channel specified)")
+		fmt.Println("  selfupdate-check         Test the updater self-update without replacing it")
+		fmt.Println("  test-connection          Check that every host the updater needs is reachable")
+		fmt.Println("  config connection        Point the world file at -site <host> -port <port>")
+		fmt.Println("  config proxy none        Stop using Proxiani or MUDMixer and connect directly")
+		fmt.Println("  manifest-diff            Show how the local manifest differs from the remote one")
+		fmt.Println("  channels                 List the update channels and which one is active")
+		fmt.Println("  list-channels            Same as channels")
+		fmt.Println("  repair                   Re-download installed files that no longer match the manifest")
+		fmt.Println("  verify                   Check installed files against the manifest without changing anything")
+		fmt.Println("  status                   Summarize the installation (add -check to look for updates)")
+		fmt.Println("  uninstall                Remove the updater's files (-all removes the whole installation)")
+		fmt.Println("  state list               List the files and folders the updater keeps in the install")
+		fmt.Println("  changelog [N]            Show the changelogs of the last N updates (default 5)")
 		fmt.Println("\nOr run without subcommand to update")
 		os.Exit(1)
 	}
 
+	// -dry-run applies to the normal update and to the self-update check
+	if dryRunFlag && ((subcommand != "" && subcommand != "selfupdate-check") || generateManifest || switchChannelSubcommand) {
+		fmt.Println("The -dry-run flag can only be used with a normal update or selfupdate-check")
+		os.Exit(1)
+	}
+
+	if progressIntervalFlag <= 0 {
+		fmt.Printf("Invalid -progress-interval %s: must be greater than zero\n", progressIntervalFlag)
+		os.Exit(1)
+	}
+
+	if previewFlag && subcommand != "switch" {
+		fmt.Println("The -preview flag can only be used with switch")
+		os.Exit(1)
+	}
+
+	if len(onlyFlag) > 0 && subcommand != "" && subcommand != "check" {
+		fmt.Println("The -only flag can only be used when updating or with check")
+		os.Exit(1)
+	}
+
+	if summaryOnlyFlag && subcommand != "check" {
+		fmt.Println("The -summary-only flag can only be used with check")
+		os.Exit(1)
+	}
+
+	if jsonFlag && ((subcommand != "check" && subcommand != "status") || summaryOnlyFlag) {
+		fmt.Println("The -json flag can only be used with check or status, and not with -summary-only")
+		os.Exit(1)
+	}
+
+	if statusCheckFlag && subcommand != "status" {
+		fmt.Println("The -check flag can only be used with status")
+		os.Exit(1)
+	}
+
+	if (confirmFlag || uninstallAllFlag || purgeFlag) && subcommand != "uninstall" {
+		fmt.Println("The -confirm, -all and -purge flags can only be used with uninstall")
+		os.Exit(1)
+	}
+
+	if explicitFlags["install-dir"] && subcommand != "" {
+		fmt.Println("The -install-dir flag can only be used when installing")
+		os.Exit(1)
+	}
+	if installDirFlag != "" && !filepath.IsAbs(installDirFlag) {
+		fmt.Printf("Invalid -install-dir %q: must be an absolute path\n", installDirFlag)
+		os.Exit(1)
+	}
+
+	switch changelogViewerFlag {
+	case "", "console", "notepad":
+	default:
+		fmt.Printf("Invalid -changelog-viewer %q: must be console or notepad\n", changelogViewerFlag)
+		os.Exit(1)
+	}
+
+	switch downloadModeFlag {
+	case "auto", "files", "zip":
+	default:
+		fmt.Printf("Invalid -download-mode %q: must be auto, files or zip\n", downloadModeFlag)
+		os.Exit(1)
+	}
+
+	// Config changes are local and don't need the network
+	if subcommand == "config" {
+		if err := runConfigCommand(configArgs); err != nil {
+			fatalError("%v", err)
+		}
+		return
+	}
+	if subcommand == "state" {
+		if err := runStateCommand(flag.Args()); err != nil {
+			fatalError("%v", err)
+		}
+		return
+	}
+	if subcommand == "changelog" {
+		if err := runChangelogHistory(flag.Args()); err != nil {
+			fatalError("%v", err)
+		}
+		return
+	}
+	if subcommand == "uninstall" {
+		if err := runUninstall(uninstallAllFlag, purgeFlag); err != nil {
+			fatalError("Uninstall failed: %v", err)
+		}
+		return
+	}
+
 	// Check if channel was explicitly set
 	channelExplicitlySet = false
 	flag.Visit(func(f *flag.Flag) {
@@ -651,8 +1291,22 @@ func main() {
 
 	// Initialize GitHub API client
 	ghClient = github.NewClient(githubOwner, githubRepo, httpClient)
+	ghClient.SetRetryPolicy(apiRetriesFlag, github.DefaultBackoff)
+	ghClient.SetToken(loadGitHubToken())
+	// Only cache inside an existing install, so fresh installs don't leave it behind
+	if cwd, err := os.Getwd(); err == nil && install.IsInstalled(cwd) {
+		ghClient.SetCacheFile(filepath.Join(cwd, githubCacheFile))
+	}
+
+	if subcommand == "test-connection" {
+		if !runConnectionTest() {
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Initialize manifest manager
+	userExcludes = loadExcludes()
 	manifestManager = manifest.NewManager(manifest.Config{
 		ManifestFile: manifestFile,
 		WorldsDir:    worldsDir,
@@ -660,8 +1314,27 @@ func main() {
 		ChannelFlag:  channelFlag,
 		QuietFlag:    quietFlag,
 		VerboseFlag:  verboseFlag,
+		Excludes:     userExcludes,
 	})
 
+	// Repair and verify work from the local manifest alone, so the channel doesn't matter
+	if subcommand == "verify" {
+		code, err := runVerify()
+		if err != nil {
+			fatalError("Error verifying: %v", err)
+		}
+		if code != 0 {
+			os.Exit(code)
+		}
+		return
+	}
+	if subcommand == "repair" {
+		if err := runRepair(); err != nil && !errors.Is(err, errElevated) {
+			fatalError("Error repairing: %v", err)
+		}
+		return
+	}
+
 	// Load channel before check command (so check uses correct channel)
 	if !channelExplicitlySet {
 		if loadedChannel, err := loadChannel(); err == nil {
@@ -672,8 +1345,26 @@ func main() {
 		}
 	}
 
-	// Validate channel BEFORE check command (so invalid channels get fixed)
-	if channelFlag != "stable" && channelFlag != "dev" {
+	// Channel listings report the saved channel as-is, before any fallback below
+	if listChannelsJSONFlag || subcommand == "channels" || subcommand == "list-channels" {
+		if err := runChannelList(listChannelsJSONFlag); err != nil {
+			fatalError("Error listing channels: %v", err)
+		}
+		return
+	}
+
+	// Status reports the saved channel as-is and stays offline without -check
+	if subcommand == "status" {
+		if err := runStatus(statusCheckFlag); err != nil {
+			fatalError("Error reading status: %v", err)
+		}
+		return
+	}
+
+	// Validate channel BEFORE check command (so invalid channels get fixed).
+	// check -json prints nothing but its JSON and reports a missing branch as
+	// channel-invalid instead of switching to dev.
+	if channelFlag != "stable" && channelFlag != "dev" && !jsonFlag {
 		// Check if it's a valid branch
 		if !isValidChannel(channelFlag) {
 			// Branch doesn't exist, fall back to dev
@@ -682,13 +1373,14 @@ func main() {
 
 			// Save the fallback channel immediately
 			if err := saveChannel(channelFlag); err != nil {
-				fmt.Printf("Warning: failed to save channel preference: %v\n", err)
+				console.Warn("Warning: failed to save channel preference: %v", err)
 			} else {
 				// Only print success message if save worked
 				if !quietFlag {
 					fmt.Printf("\nThe experimental branch '%s' no longer exists!\n", oldChannel)
 					fmt.Printf("Automatically switching you to the 'dev' channel.\n")
-					fmt.Printf("You'll now receive updates from the main development branch.\n\n")
+					fmt.Printf("You'll now receive updates from the main development branch.\n")
+					fmt.Printf("Run 'update list-channels' to see the exact names of the branches that exist.\n\n")
 				}
 			}
 		} else {
@@ -699,13 +1391,40 @@ func main() {
 		}
 	}
 
+	if subcommand == "manifest-diff" {
+		if err := runManifestDiff(); err != nil {
+			fatalError("%s", i18n.T("error_checking_updates", err))
+		}
+		return
+	}
+
 	// Handle check subcommand early (after httpClient init and channel load)
 	if subcommand == "check" {
+		if jsonFlag && channelFlag != "stable" && channelFlag != "dev" && !isValidChannel(channelFlag) {
+			printCheckJSON(CheckResult{
+				Status:  "channel-invalid",
+				Channel: channelFlag,
+				Error:   fmt.Sprintf("branch %q does not exist; run update list-channels to see the ones that do", channelFlag),
+			})
+			os.Exit(1)
+		}
 		updates, deletedFiles, err := getPendingUpdates()
 		if err != nil {
-			fatalError("Error checking updates: %v", err)
+			if jsonFlag {
+				printCheckJSON(CheckResult{Status: "error", Channel: channelFlag, Error: err.Error()})
+				os.Exit(1)
+			}
+			fatalError("%s", i18n.T("error_checking_updates", err))
+		}
+		switch {
+		case jsonFlag:
+			printCheckJSON(buildCheckResult(updates, deletedFiles))
+		case summaryOnlyFlag:
+			printCheckSummary(updates, deletedFiles)
+			return
+		default:
+			printCheckOutput(updates, deletedFiles)
 		}
-		printCheckOutput(updates, deletedFiles)
 
 		// Spawn detached self-update check before exiting
 		exePath, err := os.Executable()
@@ -733,10 +1452,22 @@ func main() {
 		return
 	}
 
+	// Self-update dry run reports what the self-update would do and exits
+	if subcommand == "selfupdate-check" || (selfUpdateCheckFlag && dryRunFlag) {
+		if err := runSelfUpdateDryRun(); err != nil {
+			fatalError("Self-update check failed: %v", err)
+		}
+		return
+	}
+
 	// If self-update check flag is set, wait briefly then check for updates
 	if selfUpdateCheckFlag {
 		time.Sleep(500 * time.Millisecond) // Wait for parent process to exit
-		_ = selfupdate.Check(selfupdate.DefaultConfig(appVersion))
+		cfg := selfupdate.DefaultConfig(appVersion)
+		if exePath, err := os.Executable(); err == nil {
+			cfg.LogPath = filepath.Join(filepath.Dir(exePath), selfUpdateLogFile)
+		}
+		_ = selfupdate.Check(cfg)
 		return
 	}
 
@@ -759,7 +1490,7 @@ func main() {
 			// No value provided
 			if nonInteractive {
 				// In non-interactive mode, require channel to be specified
-				fmt.Println("Error: Channel must be specified in non-interactive mode.")
+				console.Error("Error: Channel must be specified in non-interactive mode.")
 				fmt.Println("Usage: updater switch <stable|dev>")
 				os.Exit(1)
 			}
@@ -767,19 +1498,31 @@ func main() {
 			newChannel = promptForChannel()
 		} else {
 			// Invalid value provided
-			fmt.Printf("Error: Invalid channel '%s'. Must be 'stable' or 'dev'.\n", switchChannel)
-			playSoundAsync(errorSound, 0.0)
+			console.Error("Error: Invalid channel '%s'. Must be 'stable' or 'dev'.", switchChannel)
+			playSoundAsync(audio.CategoryError, errorSound, 0.0)
 			if !nonInteractive {
-				waitForUser("\nPress Enter to exit...")
+				waitForUser("\n" + i18n.T("press_enter_exit"))
 			}
 			os.Exit(1)
 		}
 
 		// Load current channel and validate the switch
 		currentChannel, _ := loadChannel()
+		if previewFlag {
+			if currentChannel == "" {
+				currentChannel = channelFlag
+			}
+			if err := previewChannelSwitch(currentChannel, newChannel); err != nil {
+				fatalError("Failed to preview channel switch: %v", err)
+			}
+			if !nonInteractive {
+				waitForUser("\n" + i18n.T("press_enter_exit"))
+			}
+			return
+		}
 		if err := validateChannelSwitch(currentChannel, newChannel); err != nil {
 			if !nonInteractive {
-				waitForUser("\nPress Enter to exit...")
+				waitForUser("\n" + i18n.T("press_enter_exit"))
 			}
 			os.Exit(1)
 		}
@@ -790,7 +1533,7 @@ func main() {
 		fmt.Println("Run the updater again to update using the new channel.")
 
 		if !nonInteractive {
-			waitForUser("\nPress Enter to exit...")
+			waitForUser("\n" + i18n.T("press_enter_exit"))
 		}
 		return
 
@@ -830,10 +1573,17 @@ func main() {
 		}
 	}
 
+	if dryRunFlag && !isInstalled() {
+		fmt.Println("Nothing to compare: no installation found in the current directory.")
+		return
+	}
+
 	if !isInstalled() {
 		// Not installed in current directory
-		usr, _ := os.UserHomeDir()
-		expectedInstallDir := filepath.Join(usr, "Documents", "Miriani-Next")
+		expectedInstallDir, err := defaultInstallDir()
+		if err != nil {
+			fatalError("%v", err)
+		}
 
 		// Check if installation exists in expected location
 		existingInstallFound := false
@@ -844,10 +1594,12 @@ func main() {
 		// Check for a Toastush installation
 		toastushPath := detectToastushInstallation()
 
-		playSoundAsync(startSound, 0.0)
+		playSoundAsync(audio.CategoryStatus, startSound, 0.0)
 
 		var choice string
-		if !nonInteractive {
+		if installTargetFlag != "" {
+			choice = "1" // Relaunched elevated to finish a fresh install
+		} else if !nonInteractive {
 			choice = promptInstallationMenu(existingInstallFound, expectedInstallDir, toastushPath)
 		} else {
 			// Non-interactive mode: auto-detect behavior
@@ -871,6 +1623,9 @@ func main() {
 					time.Sleep(3 * time.Second)
 					return
 				}
+				if errors.Is(err, errElevated) {
+					return
+				}
 				// Other errors are fatal
 				fatalError("Installation failed: %v", err)
 			}
@@ -881,12 +1636,12 @@ func main() {
 			time.Sleep(500 * time.Millisecond)
 
 			// Play success sound (blocks until sound finishes)
-			playSound(successSound)
+			playSound(audio.CategoryStatus, successSound)
 
 			// Change to install directory and launch
 			if err := os.Chdir(installDir); err != nil {
 				if !quietFlag && verboseFlag {
-					fmt.Printf("Warning: couldn't change to install directory: %v\n", err)
+					console.Warn("Warning: couldn't change to install directory: %v", err)
 				}
 			}
 
@@ -897,7 +1652,7 @@ func main() {
 			if err := launchMUSHClient(); err != nil {
 				fmt.Printf("Failed to launch MUSHclient: %v\n", err)
 				fmt.Printf("Working directory: %s\n", installDir)
-				waitForUser("\nPress Enter to exit...")
+				waitForUser("\n" + i18n.T("press_enter_exit"))
 				return
 			}
 			return
@@ -913,7 +1668,7 @@ func main() {
 					selectedDir, err := promptForInstallFolder(expectedInstallDir)
 					if err != nil {
 						fmt.Printf("Error selecting folder: %v\n", err)
-						waitForUser("\nPress Enter to exit...")
+						waitForUser("\n" + i18n.T("press_enter_exit"))
 						return
 					}
 					installDir = selectedDir
@@ -922,8 +1677,8 @@ func main() {
 					if _, err := os.Stat(filepath.Join(installDir, "MUSHclient.exe")); os.IsNotExist(err) {
 						fmt.Printf("\nMUSHclient.exe not found in: %s\n", installDir)
 						fmt.Println("This doesn't appear to be a valid Miriani-Next installation.")
-						playSound(errorSound)
-						waitForUser("\nPress Enter to exit...")
+						playSound(audio.CategoryError, errorSound)
+						waitForUser("\n" + i18n.T("press_enter_exit"))
 						return
 					}
 				} else {
@@ -932,15 +1687,16 @@ func main() {
 					return
 				}
 			} else {
-				// Auto-detected installation - confirm with user
-				if !nonInteractive {
+				// Auto-detected installation - confirm with user unless
+				// -install-dir already named it
+				if !nonInteractive && installDirFlag == "" {
 					fmt.Printf("\nFound existing installation at: %s\n", installDir)
 					if !confirmAction("Install updater to this location?") {
 						fmt.Println("\nLocate your Miriani-Next installation")
 						selectedDir, err := promptForInstallFolder(expectedInstallDir)
 						if err != nil {
 							fmt.Printf("Error selecting folder: %v\n", err)
-							waitForUser("\nPress Enter to exit...")
+							waitForUser("\n" + i18n.T("press_enter_exit"))
 							return
 						}
 						installDir = selectedDir
@@ -949,8 +1705,8 @@ func main() {
 						if _, err := os.Stat(filepath.Join(installDir, "MUSHclient.exe")); os.IsNotExist(err) {
 							fmt.Printf("\nMUSHclient.exe not found in: %s\n", installDir)
 							fmt.Println("This doesn't appear to be a valid Miriani-Next installation.")
-							playSound(errorSound)
-							waitForUser("\nPress Enter to exit...")
+							playSound(audio.CategoryError, errorSound)
+							waitForUser("\n" + i18n.T("press_enter_exit"))
 							return
 						}
 					}
@@ -962,8 +1718,8 @@ func main() {
 			if _, err := os.Stat(updaterInInstallDir); err == nil {
 				fmt.Printf("\nUpdater already exists at: %s\n", installDir)
 				fmt.Println("Please run the updater from that directory.")
-				playSound(errorSound)
-				waitForUser("\nPress Enter to exit...")
+				playSound(audio.CategoryError, errorSound)
+				waitForUser("\n" + i18n.T("press_enter_exit"))
 				return
 			}
 
@@ -978,8 +1734,8 @@ func main() {
 			// Copy updater to installation
 			if err := copyUpdaterToInstallation(installDir); err != nil {
 				fmt.Printf("Error copying updater: %v\n", err)
-				playSound(errorSound)
-				waitForUser("\nPress Enter to exit...")
+				playSound(audio.CategoryError, errorSound)
+				waitForUser("\n" + i18n.T("press_enter_exit"))
 				return
 			}
 
@@ -992,9 +1748,9 @@ func main() {
 				// Change to install directory to generate manifest
 				if err := os.Chdir(installDir); err == nil {
 					if err := saveManifest(); err != nil {
-						fmt.Printf("Warning: failed to generate manifest: %v\n", err)
+						console.Warn("Warning: failed to generate manifest: %v", err)
 					} else if !quietFlag {
-						fmt.Println("Manifest generated successfully!")
+						console.Success("Manifest generated successfully!")
 					}
 				}
 			}
@@ -1002,29 +1758,32 @@ func main() {
 			// Change to install directory
 			originalDir, _ := os.Getwd()
 			if err := os.Chdir(installDir); err != nil {
-				fmt.Printf("Warning: failed to change to install directory: %v\n", err)
+				console.Warn("Warning: failed to change to install directory: %v", err)
 			}
 
 			// Save channel preference
 			if err := saveChannel(channelFlag); err != nil {
-				fmt.Printf("Warning: failed to save channel preference: %v\n", err)
+				console.Warn("Warning: failed to save channel preference: %v", err)
 			}
 
-			// Create .updater-excludes file to protect user configuration
-			if err := createUpdaterExcludes(); err != nil {
-				fmt.Printf("Warning: failed to create .updater-excludes: %v\n", err)
-			} else if !quietFlag && verboseFlag {
-				fmt.Println("Created .updater-excludes file to protect user configuration")
+			// Create .updater-excludes file to protect user configuration,
+			// keeping any patterns the user already has
+			if _, err := os.Stat(filepath.Join(installDir, excludesFile)); os.IsNotExist(err) {
+				if err := createUpdaterExcludes(); err != nil {
+					console.Warn("Warning: failed to create .updater-excludes: %v", err)
+				} else if !quietFlag && verboseFlag {
+					fmt.Println("Created .updater-excludes file to protect user configuration")
+				}
 			}
 
 			// Create channel switching batch files
 			if err := install.CreateChannelSwitchBatchFiles(installDir); err != nil {
-				fmt.Printf("Warning: failed to create channel switch batch files: %v\n", err)
+				console.Warn("Warning: failed to create channel switch batch files: %v", err)
 			} else if !quietFlag {
 				fmt.Println("Created channel switching batch files")
 			}
 
-			fmt.Printf("\nUpdater installed successfully to: %s\n", installDir)
+			console.Success("\nUpdater installed successfully to: %s", installDir)
 
 			// Run the updater from the new location to get them up to date
 			if !nonInteractive {
@@ -1036,9 +1795,9 @@ func main() {
 				cmd.Stderr = os.Stderr
 				cmd.Stdin = os.Stdin
 				if err := cmd.Run(); err != nil {
-					fmt.Printf("Warning: failed to run updater: %v\n", err)
-					playSoundAsync(errorSound, 0.0)
-					waitForUser("\nPress Enter to exit...")
+					console.Warn("Warning: failed to run updater: %v", err)
+					playSoundAsync(audio.CategoryError, errorSound, 0.0)
+					waitForUser("\n" + i18n.T("press_enter_exit"))
 				}
 				return
 			}
@@ -1048,8 +1807,8 @@ func main() {
 				os.Chdir(originalDir)
 			}
 
-			playSound(successSound)
-			waitForUser("\nPress Enter to exit...")
+			playSound(audio.CategoryStatus, successSound)
+			waitForUser("\n" + i18n.T("press_enter_exit"))
 			return
 
 		case "3":
@@ -1066,22 +1825,22 @@ func main() {
 			}
 
 			// Get the new installation directory (after rename)
-			installDir := filepath.Join(usr, "Documents", "Miriani-Next")
+			installDir := expectedInstallDir
 			if toastushPath != "" {
 				// Use the renamed directory
-				installDir = filepath.Join(filepath.Dir(toastushPath), "Miriani-Next")
+				installDir = migrationTarget(toastushPath)
 			}
 
 			// Give a moment for background sounds to finish
 			time.Sleep(500 * time.Millisecond)
 
 			// Play success sound
-			playSound(successSound)
+			playSound(audio.CategoryStatus, successSound)
 
 			// Change to install directory and launch
 			if err := os.Chdir(installDir); err != nil {
 				if !quietFlag && verboseFlag {
-					fmt.Printf("Warning: couldn't change to install directory: %v\n", err)
+					console.Warn("Warning: couldn't change to install directory: %v", err)
 				}
 			}
 
@@ -1092,40 +1851,50 @@ func main() {
 			if err := launchMUSHClient(); err != nil {
 				fmt.Printf("Failed to launch MUSHclient: %v\n", err)
 				fmt.Printf("Working directory: %s\n", installDir)
-				waitForUser("\nPress Enter to exit...")
+				waitForUser("\n" + i18n.T("press_enter_exit"))
 				return
 			}
 			return
 
 		default:
-			fmt.Println("Installation cancelled.")
-			waitForUser("\nPress Enter to exit...")
+			fmt.Println(i18n.T("installation_cancelled"))
+			waitForUser("\n" + i18n.T("press_enter_exit"))
 			return
 		}
 	}
 
-	if err := cleanOldFolder(); err != nil {
-		if !quietFlag && verboseFlag {
-			fmt.Printf("Warning: failed to clean .old directory: %v\n", err)
-		}
-	}
-
 	// Check if we're switching channels and if it would be a downgrade
 	if err := validateChannelSwitch(savedChannel, channelFlag); err != nil {
-		waitForUser("\nPress Enter to exit...")
+		waitForUser("\n" + i18n.T("press_enter_exit"))
 		return
 	}
 
+	eventLog.Phase("check")
 	updates, deletedFiles, err := getPendingUpdates()
 	if err != nil {
-		fatalError("Error checking updates: %v", err)
-		waitForUser("Press enter to exit...\n")
+		fatalError("%s", i18n.T("error_checking_updates", err))
+		waitForUser(i18n.T("press_enter_exit") + "\n")
+	}
+
+	// A dry run stops here, before anything on disk is touched
+	if dryRunFlag {
+		printDryRun(updates, deletedFiles)
+		eventLog.Result("success", "dry run")
+		return
 	}
 
 	if len(updates) == 0 && len(deletedFiles) == 0 {
-		fmt.Println("Already up to date!")
+		console.Success("%s", i18n.T("already_up_to_date"))
+		eventLog.Result("success", "already up to date")
+		if len(onlyFlag) == 0 {
+			clearUpdatePending()
+		}
 		if !quietFlag {
-			playSoundAsync(upToDateSound, 0.0)
+			playSoundAsync(audio.CategoryStatus, upToDateSound, 0.0)
+			if modified, err := mushClientExeModified(); err == nil && modified {
+				fmt.Println("\nNote: MUSHclient.exe differs from the official build. It may have been")
+				fmt.Println("replaced by hand or altered by antivirus software, which can cause odd behavior.")
+			}
 		}
 
 		// Spawn detached self-update check before exiting
@@ -1145,13 +1914,16 @@ func main() {
 			}
 		}
 
-		waitForUser("\nPress Enter to exit...")
+		waitForUser("\n" + i18n.T("press_enter_exit"))
 		return
 	}
 
 	if !quietFlag && !nonInteractive {
 		totalChanges := len(updates) + len(deletedFiles)
-		fmt.Printf("\n%d files will be changed (%d updates, %d deletions).\n", totalChanges, len(updates), len(deletedFiles))
+		fmt.Println("\n" + i18n.T("files_will_change", totalChanges, len(updates), len(deletedFiles)))
+		if size := manifest.TotalSize(updates); size > 0 {
+			fmt.Println(i18n.T("download_size", formatBytes(size)))
+		}
 	}
 
 	// Track whether we killed MUSHclient so we know to restart it later
@@ -1163,6 +1935,9 @@ func main() {
 	if nonInteractive && restartRequired && !allowRestartFlag {
 		// Check if MUSHclient is running
 		if isMUSHClientRunning() {
+			if err := writeUpdatePending(updates, deletedFiles); err != nil {
+				console.Log("Warning: failed to write %s: %v", pendingFile, err)
+			}
 			fmt.Println("restart required")
 			return
 		}
@@ -1179,7 +1954,7 @@ func main() {
 				}
 				mushWasRunning = true
 				console.Log("MUSHclient killed successfully. Proceeding with update...")
-				playSoundAsync(successSound, 0.0)
+				playSoundAsync(audio.CategoryStatus, successSound, 0.0)
 				// Wait for process to fully terminate
 				if !process.WaitForTermination("MUSHclient.exe", 5*time.Second) {
 					console.Log("Warning: MUSHclient may not have fully terminated")
@@ -1191,33 +1966,68 @@ func main() {
 			}
 		} else {
 			// In interactive mode, tell user to close it
-			fmt.Println("\nMUSHclient is running and needs to be closed to update it.")
+			fmt.Println("\n" + i18n.T("mushclient_must_close"))
 			fmt.Println("MUSHclient.exe needs to be updated, but it is currently running.")
-			fmt.Println("Please close MUSHclient and run the updater again.")
-			playSoundAsync(errorSound, 0.0)
-			waitForUser("\nPress Enter to exit...")
+			fmt.Println(i18n.T("mushclient_close_and_rerun"))
+			playSoundAsync(audio.CategoryError, errorSound, 0.0)
+			waitForUser("\n" + i18n.T("press_enter_exit"))
 			return
 		}
 	}
 
 	// Ask for confirmation before updating
-	if !confirmAction("Do you want to proceed with the update?") {
-		fmt.Println("Update cancelled.")
+	if !confirmAction(i18n.T("confirm_proceed_update")) {
+		fmt.Println(i18n.T("update_cancelled"))
 		return
 	}
 
-	if err := performUpdates(updates); err != nil {
-		fatalError("Error updating: %v", err)
+	// Installs in protected folders need administrator rights to update
+	if cwd, err := os.Getwd(); err == nil {
+		if err := ensureWritable(cwd, nil); errors.Is(err, errElevated) {
+			return
+		} else if err != nil {
+			fatalError("Error updating: %v", err)
+		}
+	}
+
+	// The changelog lists the commits between this and the new head
+	if localVer, err := getLocalVersion(); err == nil {
+		changelogBase = localVer.Head
+	}
+
+	updateStart := time.Now()
+	eventLog.Phase("download")
+	var partial *partialUpdateError
+	if err := performUpdates(updates); err != nil && !errors.As(err, &partial) {
+		var failed *failedUpdateError
+		if errors.As(err, &failed) {
+			for _, err := range failed.Errs {
+				eventLog.Warn("%v", err)
+			}
+			updateFailures = failed.partialUpdateError
+			// Quiet runs keep the one-line summary; the full list is in .update-result
+			if !quietFlag {
+				err = fmt.Errorf("failed to update %d files:\n%s", len(failed.Failed), failed.details(failureListLimit()))
+			}
+		}
+		fatalError("%s", i18n.T("error_updating", err))
+	}
+	if verifyAfterFlag || nonInteractive {
+		partial = verifyAppliedUpdates(updates, partial)
 	}
 
 	// Perform deletions for files that are no longer in the manifest
 	baseDir, err := os.Getwd()
 	if err != nil {
-		fatalError("Error getting working directory: %v", err)
+		fatalError("%s", i18n.T("error_working_dir", err))
+	}
+	if len(deletedFiles) > 0 {
+		eventLog.Phase("delete")
 	}
 	for _, path := range deletedFiles {
 		filePath := filepath.Join(baseDir, paths.Denormalize(path))
 		if err := moveToOldFolder(filePath, path); err == nil {
+			eventLog.File(path, "deleted")
 			if !quietFlag && verboseFlag && !nonInteractive {
 				fmt.Printf("Removed: %s (moved to .old/)\n", path)
 			}
@@ -1226,15 +2036,48 @@ func main() {
 
 	// Save current version after successful update
 	// This updates the local .current_version file to match what we just downloaded
-	if latestVer, err := getLatestVersion(); err == nil {
+	// A corrupt version.json doesn't stop the update; it's replaced here
+	_, localVerErr := getLocalVersion()
+	latestVer, latestErr := getLatestVersion()
+	// A -only update leaves the install partly on the old version, so keep it
+	if latestErr == nil && partial == nil && len(onlyFlag) == 0 {
 		if versionData, err := json.MarshalIndent(latestVer, "", "  "); err == nil {
-			os.WriteFile(versionFile, versionData, 0644)
+			if err := paths.WriteFileAtomic(versionFile, versionData, 0644); err != nil {
+				console.Log("Warning: failed to save version file: %v", err)
+			} else if errors.Is(localVerErr, version.ErrCorrupt) {
+				console.Log("Replaced corrupt %s with version %s", versionFile, latestVer.String())
+			}
 		}
 	}
 
-	// Show changelog
-	if (len(updates) > 0 || len(deletedFiles) > 0) && !quietFlag && !nonInteractive {
-		showChangelog(updates, deletedFiles)
+	// Save and show changelog
+	changed := (len(updates) > 0 || len(deletedFiles) > 0) && partial == nil
+	var changelogContent string
+	if changed && changelogOutFlag != "" {
+		changelogContent = buildChangelog(updates, deletedFiles, true)
+		if err := os.WriteFile(changelogOutFlag, []byte(changelogContent), 0644); err != nil {
+			console.Log("Warning: failed to write changelog to %s: %v", changelogOutFlag, err)
+			eventLog.Warn("failed to write changelog: %v", err)
+		}
+	}
+	if changed && !quietFlag && !nonInteractive && !noChangelogFlag {
+		changelogContent = showChangelog(updates, deletedFiles, changelogContent)
+	}
+	// The history reuses a changelog built above. Otherwise it records the file
+	// list without the GitHub sections, so unattended runs (started by MUSHclient
+	// with -quiet or -non-interactive) make no extra API requests for it.
+	if historyLimit := changelogHistoryLimit(); changed && historyLimit > 0 {
+		if changelogContent == "" {
+			changelogContent = buildChangelog(updates, deletedFiles, false)
+		}
+		historyVersion := "unknown"
+		if latestErr == nil {
+			historyVersion = latestVer.String()
+		}
+		if err := changelog.AppendHistory(baseDir, changelogContent, historyVersion, historyLimit, time.Now()); err != nil {
+			console.Log("Warning: failed to save changelog history: %v", err)
+			eventLog.Warn("failed to save changelog history: %v", err)
+		}
 	}
 
 	// After update, restart MUSHclient if we killed it
@@ -1242,8 +2085,9 @@ func main() {
 		console.Log("Restarting MUSHclient...")
 		if err := launchMUSHClient(); err != nil {
 			console.Log("Warning: failed to restart MUSHclient: %v", err)
+			eventLog.Warn("failed to restart MUSHclient: %v", err)
 			if !quietFlag && !nonInteractive {
-				fmt.Printf("Warning: failed to restart MUSHclient: %v\n", err)
+				console.Warn("Warning: failed to restart MUSHclient: %v", err)
 			}
 		} else {
 			console.Log("MUSHclient restarted successfully.")
@@ -1253,13 +2097,23 @@ func main() {
 		}
 	}
 
-	playSound(successSound)
+	if partial != nil {
+		reportPartialUpdate(updates, deletedFiles, partial, mushWasRunning)
+		os.Exit(exitPartialUpdate)
+	}
+
+	eventLog.Result("success", "")
+	if len(onlyFlag) == 0 {
+		clearUpdatePending()
+	}
+	playSound(audio.CategoryStatus, successSound)
 	if !quietFlag && !nonInteractive {
-		fmt.Println("\nUpdate complete!")
+		console.Success("%s", "\n"+i18n.T("update_complete"))
+		fmt.Println(updateSummary(len(updates)+len(deletedFiles), downloadedBytes.Load(), time.Since(updateStart)))
 	}
 
-	// Write .update-result file in non-interactive mode
-	if nonInteractive {
+	// Write .update-result file in non-interactive and quiet modes
+	if shouldWriteResult() {
 		if err := writeUpdateSuccess(updates, deletedFiles, mushWasRunning); err != nil {
 			console.Log("Warning: failed to write .update-result: %v", err)
 		}
@@ -1282,9 +2136,42 @@ func main() {
 // ============================================================================
 
 func getPendingUpdates() ([]manifest.FileInfo, []string, error) {
+	diff, err := diffManifests()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Updates are files in remote that are new or changed
+	updates := append(diff.Added, diff.Changed...)
+	if len(onlyFlag) == 0 {
+		return updates, diff.Removed, nil
+	}
+
+	// -only leaves everything outside its prefixes alone, deletions included
+	var scopedUpdates []manifest.FileInfo
+	for _, u := range updates {
+		if inOnlyScope(u.Name) {
+			scopedUpdates = append(scopedUpdates, u)
+		}
+	}
+	var scopedRemoved []string
+	for _, path := range diff.Removed {
+		if inOnlyScope(path) {
+			scopedRemoved = append(scopedRemoved, path)
+		}
+	}
+	return scopedUpdates, scopedRemoved, nil
+}
+
+// diffManifests compares the local manifest (regenerating it if needed) with
+// the remote one for the current channel, honoring .updater-excludes
+func diffManifests() (manifest.Diff, error) {
 	localManifest, err := manifestManager.LoadLocal()
 	if err != nil {
 		// If manifest is missing or corrupted but we're in an installation directory, auto-generate it from local files
+		if noAutoManifestFlag || dryRunFlag {
+			return manifest.Diff{}, fmt.Errorf("local manifest unusable (%w); run 'update -generate-manifest' to rebuild it", err)
+		}
 		if hasWorldFilesInCurrentDir() {
 			if errors.Is(err, os.ErrNotExist) {
 				if !quietFlag {
@@ -1296,1203 +2183,3220 @@ func getPendingUpdates() ([]manifest.FileInfo, []string, error) {
 				}
 			}
 			if err := saveManifest(); err != nil {
-				return nil, nil, fmt.Errorf("failed to generate local manifest: %w", err)
+				return manifest.Diff{}, fmt.Errorf("failed to generate local manifest: %w", err)
 			}
 			// Try loading again after generation
 			localManifest, err = manifestManager.LoadLocal()
 			if err != nil {
-				return nil, nil, err
+				return manifest.Diff{}, err
 			}
 		} else {
-			return nil, nil, err
+			return manifest.Diff{}, err
 		}
 	}
 
 	remoteManifest, err := loadRemoteManifest()
 	if err != nil {
-		return nil, nil, err
+		return manifest.Diff{}, err
 	}
 	excludes := loadExcludes()
-
-	// Normalize both manifests once for efficient comparison
-	normalizedLocal := make(map[string]manifest.FileInfo, len(localManifest))
-	for path, info := range localManifest {
-		normalizedLocal[paths.Normalize(path)] = info
+	if verboseFlag && !quietFlag {
+		reportExcludeChanges(excludes)
 	}
 
-	normalizedRemote := make(map[string]manifest.FileInfo, len(remoteManifest))
-	for path, info := range remoteManifest {
-		normalized := paths.Normalize(path)
-		// Skip excluded files during normalization
-		if paths.MatchesExclusion(normalized, excludes) {
-			if !quietFlag && verboseFlag {
-				fmt.Printf("Skipping excluded file: %s\n", normalized)
-			}
-			continue
-		}
-		normalizedRemote[normalized] = info
+	isExcluded := func(path string) bool {
+		return paths.MatchesExclusion(path, excludes)
 	}
+	diff := manifest.Compare(localManifest, remoteManifest, paths.Normalize, isExcluded)
 
-	// Find updates: files in remote that are new or changed
-	var updates []manifest.FileInfo
-	for path, remote := range normalizedRemote {
-		if local, exists := normalizedLocal[path]; !exists || local.Hash != remote.Hash {
-			updates = append(updates, remote)
+	// The remote manifest already leaves out user-excluded paths, so a file
+	// installed before it was excluded shows up as removed. It's the user's
+	// now: keep it rather than deleting it.
+	var removed []string
+	for _, path := range diff.Removed {
+		if isExcluded(path) {
+			diff.Excluded = append(diff.Excluded, path)
+		} else {
+			removed = append(removed, path)
 		}
 	}
-
-	// Find deletions: files in local but not in remote
-	var deletedFiles []string
+	diff.Removed = removed
+	sort.Strings(diff.Excluded)
 	if !quietFlag && verboseFlag {
-		fmt.Println("Checking for removed files...")
-	}
-	for path := range normalizedLocal {
-		if _, exists := normalizedRemote[path]; !exists {
-			deletedFiles = append(deletedFiles, path)
+		for _, path := range diff.Excluded {
+			fmt.Printf("Skipping excluded file: %s\n", path)
 		}
 	}
-
-	return updates, deletedFiles, nil
+	return diff, nil
 }
 
-// printCheckOutput shows what updates are available (either human-readable or machine format)
-func printCheckOutput(updates []manifest.FileInfo, deletedFiles []string) {
-	hasUpdates := len(updates) > 0 || len(deletedFiles) > 0
-	totalChanges := len(updates) + len(deletedFiles)
-	restartRequired := needsMUSHClientRestart(updates)
-
-	// Get version information
-	latestVer, err := getLatestVersion()
-	localVer, localErr := getLocalVersion()
+// runManifestDiff prints the full local/remote manifest comparison without
+// changing anything
+func runManifestDiff() error {
+	diff, err := diffManifests()
+	if err != nil {
+		return err
+	}
 
-	if nonInteractive {
-		if !isInstalled() {
-			fmt.Println("Update available: Unknown")
-			fmt.Println("Status: Not installed")
-		} else if !isValidChannel(channelFlag) {
-			fmt.Println("Status: channel invalid")
-		} else if hasUpdates {
-			fmt.Println("Update available: Yes")
-			if err == nil {
-				fmt.Printf("Version: %s\n", latestVer.String())
-			}
-			if localErr == nil {
-				fmt.Printf("Current version: %s\n", localVer.String())
-			}
-			if restartRequired {
-				fmt.Println("Restart required: Yes")
-			} else {
-				fmt.Println("Restart required: No")
-			}
-			fmt.Printf("Changes: %d\n", totalChanges)
-			fmt.Printf("Updates: %d\n", len(updates))
-			fmt.Printf("Deletions: %d\n", len(deletedFiles))
-			playSoundAsync(upToDateSound, 0.0)
-		} else {
-			// No updates - minimal output: just status and current version
-			fmt.Println("Update available: No")
-			if localErr == nil {
-				fmt.Printf("Version: %s\n", localVer.String())
-			}
+	fmt.Printf("Comparing local manifest with the %s channel\n", channelFlag)
+	section := func(heading string, entries []string) {
+		fmt.Printf("\n%s (%d):\n", heading, len(entries))
+		for _, entry := range entries {
+			fmt.Printf("  %s\n", entry)
 		}
-	} else {
-		// Human-readable output for interactive mode
-		if hasUpdates {
-			fmt.Printf("\nAn update is available with %d total changes.\n", totalChanges)
-			if len(updates) > 0 {
-				fmt.Printf("  • %d files will be updated\n", len(updates))
-			}
-			if len(deletedFiles) > 0 {
-				fmt.Printf("  • %d files will be deleted\n", len(deletedFiles))
-			}
-			if localErr == nil && err == nil {
-				fmt.Printf("\nCurrent version: %s\n", localVer.String())
-				fmt.Printf("New version: %s\n", latestVer.String())
-			}
-			if restartRequired {
-				fmt.Println("\nNote: This update requires MUSHclient to be restarted.")
-			}
-			fmt.Println("\nRun the updater again without 'check' to install the update.")
-		} else {
-			if !quietFlag {
-				playSoundAsync(upToDateSound, 0.0)
-			}
-			fmt.Println("\nAlready up to date!")
-			if localErr == nil {
-				fmt.Printf("Current version: %s\n", localVer.String())
-			}
+	}
+	names := func(files []manifest.FileInfo) []string {
+		out := make([]string, len(files))
+		for i, f := range files {
+			out[i] = f.Name
 		}
+		return out
 	}
-}
-
-func performUpdates(updates []manifest.FileInfo) error {
-	// We already checked if MUSHclient was running earlier in main()
 
-	// If it's a fresh install or lots of files changed, download as one big zip file for speed.
-	// Otherwise, download files individually in parallel.
-	useZip := !isInstalled() || len(updates) > zipThreshold
+	section("Only remote - would be added", names(diff.Added))
+	section("Different hash - would be updated", names(diff.Changed))
+	section("Only local - would be deleted", diff.Removed)
+	section("Skipped by exclusions", diff.Excluded)
+	return nil
+}
 
-	if useZip {
-		return downloadZipAndExtract(updates)
+// printDryRun lists what an update would change, grouped into new, changed,
+// deleted and preserved files, without downloading or writing anything. In
+// verbose mode each download shows its size.
+func printDryRun(updates []manifest.FileInfo, deletedFiles []string) {
+	if len(updates) == 0 && len(deletedFiles) == 0 {
+		fmt.Println("Dry run: already up to date, nothing would change.")
+		return
 	}
 
-	// Download files in parallel (up to fileWorkers at a time)
-	sem := make(chan struct{}, fileWorkers)
-	var wg sync.WaitGroup
-	var updateMutex sync.Mutex
-	var downloadErrors []error
-	var completedCount int
-	total := len(updates)
+	local, _ := manifestManager.LoadLocal()
+	installed := make(map[string]bool, len(local))
+	for path := range local {
+		installed[paths.Normalize(path)] = true
+	}
 
-	if nonInteractive {
-		fmt.Println("Downloading...")
-	} else if !quietFlag {
-		fmt.Printf("\nDownloading %d files...\n", total)
+	var added, changed, preserved []manifest.FileInfo
+	for _, u := range updates {
+		switch {
+		case isProtectedConfig(u.Name):
+			preserved = append(preserved, u)
+		case installed[paths.Normalize(u.Name)]:
+			changed = append(changed, u)
+		default:
+			added = append(added, u)
+		}
 	}
 
-	for i, u := range updates {
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(info manifest.FileInfo, idx int) {
-			defer wg.Done()
-			defer func() { <-sem }()
-			if err := downloadFile(info); err != nil {
-				updateMutex.Lock()
-				downloadErrors = append(downloadErrors, err)
-				updateMutex.Unlock()
+	fmt.Printf("Dry run: %d changes on the %s channel (nothing will be modified)\n", len(updates)+len(deletedFiles), channelFlag)
+	if size := manifest.TotalSize(updates); size > 0 {
+		fmt.Printf("Download size: %s\n", formatBytes(size))
+	}
+	section := func(heading string, files []manifest.FileInfo) {
+		if len(files) == 0 {
+			return
+		}
+		fmt.Printf("\n%s (%d):\n", heading, len(files))
+		for _, f := range files {
+			if verboseFlag && f.Size > 0 {
+				fmt.Printf("  %s (%s)\n", f.Name, formatBytes(f.Size))
 			} else {
-				updateMutex.Lock()
-				completedCount++
-				current := completedCount
-				updateMutex.Unlock()
-
-				percentage := (current * 100) / total
-				// Update title bar with progress
-				console.SetTitle(fmt.Sprintf("%s - Downloading: %d%%", title, percentage))
-
-				if nonInteractive {
-					// In non-interactive mode, only print percentage
-					fmt.Printf("%d%%\n", percentage)
-				} else if !quietFlag {
-					if verboseFlag {
-						fmt.Printf("[%d/%d] (%d%%) %s\n", current, total, percentage, info.Name)
-					} else {
-						// Show progress without individual file names - single line update
-						fmt.Printf("\rProgress: %d/%d (%d%%)    ", current, total, percentage)
-					}
-				}
+				fmt.Printf("  %s\n", f.Name)
 			}
-		}(u, i)
-	}
-	wg.Wait()
-
-	if !quietFlag && !verboseFlag && !nonInteractive {
-		fmt.Printf("\n") // New line after progress
+		}
 	}
-
-	if len(downloadErrors) > 0 {
-		return fmt.Errorf("failed to update %d files: %v", len(downloadErrors), downloadErrors[0])
+	section("New files - would be downloaded", added)
+	section("Changed files - would be replaced", changed)
+	if len(deletedFiles) > 0 {
+		fmt.Printf("\nRemoved files - would be moved to .old (%d):\n", len(deletedFiles))
+		for _, path := range deletedFiles {
+			fmt.Printf("  %s\n", path)
+		}
 	}
-
-	if !quietFlag && !nonInteractive {
-		fmt.Println("Saving manifest...")
+	section("User settings - would be kept as they are", preserved)
+	if needsMUSHClientRestart(updates) {
+		fmt.Println("\nMUSHclient would need to be closed for this update.")
 	}
-	// Reset title
-	console.SetTitle(title)
-	return saveManifest()
 }
 
-// grabClient is a shared grab client with retry and timeout settings
-var grabClient = grab.NewClient()
-
-func downloadFile(info manifest.FileInfo) error {
-	// Never overwrite user configuration files
-	if paths.IsUserConfig(info.Name) {
-		if verboseFlag {
-			log.Printf("Skipping user config file: %s\n", info.Name)
-		}
-		return nil
+// runVerify audits the install against the local manifest and prints missing,
+// modified and extra files. It returns exitVerifyProblems if any file is
+// missing or modified, exitVerifyUntracked if the only findings are extra
+// files (often the user's own additions), and 0 otherwise. Interactive runs
+// offer to move extra files to .old/; nothing else is changed.
+func runVerify() (int, error) {
+	localManifest, err := manifestManager.LoadLocal()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load local manifest: %w", err)
 	}
-
 	baseDir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return 0, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	// Normalize the file path from manifest (forward slashes) to platform format
-	relativePath := paths.Denormalize(info.Name)
-	targetPath := filepath.Join(baseDir, relativePath)
-
-	// Ensure target path doesn't escape the base directory
-	absTargetPath, err := filepath.Abs(targetPath)
-	if err != nil {
-		return fmt.Errorf("failed to resolve path for %s: %w", info.Name, err)
-	}
-	absBaseDir, err := filepath.Abs(baseDir)
+	report, err := manifest.Audit(baseDir, localManifest, paths.Normalize, paths.Denormalize, auditSkip())
 	if err != nil {
-		return fmt.Errorf("failed to resolve base directory: %w", err)
+		return 0, err
 	}
-	if !strings.HasPrefix(absTargetPath, absBaseDir) {
-		return fmt.Errorf("path traversal attempt detected: %s", info.Name)
+	ok := len(report.Missing) == 0 && len(report.Mismatched) == 0
+	code := 0
+	if !ok {
+		code = exitVerifyProblems
+	} else if len(report.Extra) > 0 {
+		code = exitVerifyUntracked
 	}
 
-	// Find actual path in case of case mismatch
-	targetPath, err = paths.FindActual(absTargetPath)
-	if err != nil {
-		return fmt.Errorf("failed to find path for %s: %w", info.Name, err)
+	if nonInteractive {
+		switch code {
+		case 0:
+			fmt.Println("Status: ok")
+		case exitVerifyUntracked:
+			fmt.Println("Status: untracked files found")
+		default:
+			fmt.Println("Status: problems found")
+		}
+		fmt.Printf("Checked: %d\n", report.Checked)
+		fmt.Printf("Missing: %d\n", len(report.Missing))
+		fmt.Printf("Modified: %d\n", len(report.Mismatched))
+		fmt.Printf("Extra: %d\n", len(report.Extra))
+		for _, path := range report.Missing {
+			fmt.Printf("Missing file: %s\n", path)
+		}
+		for _, path := range report.Mismatched {
+			fmt.Printf("Modified file: %s\n", path)
+		}
+		for _, path := range report.Extra {
+			fmt.Printf("Extra file: %s\n", path)
+		}
+		return code, nil
 	}
 
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-		return fmt.Errorf("failed to create directory for %s: %w", info.Name, err)
+	fmt.Printf("Checked %d files against the local manifest\n", report.Checked)
+	section := func(heading string, entries []string) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Printf("\n%s (%d):\n", heading, len(entries))
+		for _, entry := range entries {
+			fmt.Printf("  %s\n", entry)
+		}
 	}
-
-	// Create grab request
-	req, err := grab.NewRequest(targetPath, info.URL)
-	if err != nil {
-		return fmt.Errorf("failed to create request for %s: %w", info.Name, err)
+	section("Missing", report.Missing)
+	section("Modified", report.Mismatched)
+	section("Not in the manifest", report.Extra)
+	if ok {
+		fmt.Println("\nNo problems found.")
+	} else {
+		fmt.Println("\nRun 'update repair' to restore missing and modified files.")
 	}
-	req.NoResume = true // Always overwrite, never resume
-
-	// Download with retry
-	resp := grabClient.Do(req)
-
-	// Wait for completion
-	if err := resp.Err(); err != nil {
-		return fmt.Errorf("failed to download %s: %w", info.Name, err)
+	// Once the untracked files are moved out of the way they're no longer a finding
+	if len(report.Extra) > 0 && offerMoveUntracked(report.Extra) && code == exitVerifyUntracked {
+		code = 0
 	}
+	return code, nil
+}
 
-	return nil
+// auditSkip returns the paths verify and status leave alone: updater state,
+// user config, and anything excluded by the user or the built-in list
+func auditSkip() func(string) bool {
+	managed := make(map[string]bool, len(managedPaths))
+	var patterns paths.Excludes
+	for _, p := range managedPaths {
+		if p.Pattern {
+			patterns = append(patterns, strings.ToLower(p.Path))
+			continue
+		}
+		name := strings.ToLower(paths.Normalize(p.Path))
+		if p.Dir {
+			name += "/"
+		}
+		managed[name] = true
+	}
+	return func(path string) bool {
+		return managed[strings.ToLower(path)] || paths.MatchesExclusion(path, patterns) || paths.IsUserConfig(path) ||
+			paths.MatchesExclusion(path, userExcludes) || manifestManager.ShouldExclude(path, paths.Normalize)
+	}
 }
 
-func downloadAndExtractZip(zipURL string, targetDir string, isInstall bool, filesToExtract []manifest.FileInfo) error {
-	if nonInteractive {
-		fmt.Println("Downloading...")
-	} else if !quietFlag {
-		fmt.Printf("Downloading archive...\n")
-	}
-	// Play downloading sound during fresh installation download
-	if isInstall {
-		playSoundAsyncLoop(downloadingSound, 0.0, true) // Normal volume for downloading sound, looping
-	}
-
-	// Create temp file for download
-	tempFile, err := os.CreateTemp("", "miriani-update-*.zip")
+// findUntrackedFiles lists files in the install that the local manifest
+// doesn't know about, such as leftovers from manual edits or failed updates.
+// Unlike verify it hashes nothing, so it's cheap enough for status.
+func findUntrackedFiles() ([]string, error) {
+	localManifest, err := manifestManager.LoadLocal()
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return nil, fmt.Errorf("failed to load local manifest: %w", err)
 	}
-	tempPath := tempFile.Name()
-	tempFile.Close()
-	defer os.Remove(tempPath) // Clean up temp file when done
-
-	// Create grab request for ZIP download
-	req, err := grab.NewRequest(tempPath, zipURL)
+	baseDir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to create download request: %w", err)
-	}
-	req.NoResume = true // Always overwrite, never resume
-
-	// Start download
-	resp := grabClient.Do(req)
-
-	// Progress reporting loop
-	lastPercentage := -1
-	lastMB := int64(-1)
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-progressLoop:
-	for {
-		select {
-		case <-ticker.C:
-			// Check if we have content length for percentage progress
-			if resp.Size() > 0 {
-				percentage := int(resp.Progress() * 100)
-				if percentage != lastPercentage {
-					console.SetTitle(fmt.Sprintf("%s - Downloading: %d%%", title, percentage))
-					if nonInteractive {
-						fmt.Printf("%d%%\n", percentage)
-					} else if !quietFlag && !verboseFlag {
-						fmt.Printf("\rDownloading: %d%%    ", percentage)
-					}
-					lastPercentage = percentage
-				}
-			} else {
-				// No content length - show MB downloaded instead
-				mb := resp.BytesComplete() / (1024 * 1024)
-				if mb != lastMB {
-					if !quietFlag && !verboseFlag && !nonInteractive {
-						fmt.Printf("\rDownloading: %d MB    ", mb)
-					}
-					lastMB = mb
-				}
-			}
-		case <-resp.Done:
-			break progressLoop
-		}
-	}
-
-	if !quietFlag && !verboseFlag && !nonInteractive {
-		fmt.Printf("\n")
-	}
-
-	// Check for download errors
-	if err := resp.Err(); err != nil {
-		return fmt.Errorf("failed to download archive: %w", err)
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
+	return manifest.Untracked(baseDir, localManifest, paths.Normalize, auditSkip())
+}
 
+// offerMoveUntracked asks whether to move untracked files into .old/, where
+// they stay until the next update clears it. Only interactive runs ask. It
+// reports whether every file was moved.
+func offerMoveUntracked(files []string) bool {
 	if nonInteractive {
-		fmt.Println("Extracting...")
-	} else if !quietFlag {
-		fmt.Printf("Extracting files...\n")
+		return false
 	}
-
-	// Stop any currently playing sounds (like download music) before starting extraction
-	stopAllSounds()
-
-	// Play installing sound during extraction (for fresh installs)
-	if isInstall {
-		playSoundAsyncLoop(installingSound, -1.5, true) // Slightly lower volume for installing sound, looping
+	fmt.Println()
+	if !confirmAction(fmt.Sprintf("Move the %d files not in the manifest to %s/? They are deleted by the next update", len(files), oldFolder)) {
+		return false
 	}
-
-	// Open downloaded ZIP file
-	zipFile, err := os.Open(tempPath)
+	baseDir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to open downloaded archive: %w", err)
+		console.Warn("Warning: failed to get working directory: %v", err)
+		return false
 	}
-	defer zipFile.Close()
-
-	zipStat, err := zipFile.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to stat downloaded archive: %w", err)
+	moved := 0
+	for _, path := range files {
+		if err := moveToOldFolder(filepath.Join(baseDir, paths.Denormalize(path)), path); err != nil {
+			console.Warn("Warning: failed to move %s: %v", path, err)
+			continue
+		}
+		moved++
+		if verboseFlag {
+			fmt.Printf("Moved: %s\n", path)
+		}
 	}
+	console.Success("Moved %d files to %s/", moved, oldFolder)
+	return moved == len(files)
+}
 
-	r, err := zip.NewReader(zipFile, zipStat.Size())
+// runRepair re-hashes every file in the local manifest and re-downloads the
+// ones whose content no longer matches, skipping exclusions and user config
+// (unless .updater-excludes re-includes it, as the update does).
+// Files that fail are reported and recorded in .update-result as a partial run.
+func runRepair() error {
+	localManifest, err := manifestManager.LoadLocal()
 	if err != nil {
-		return fmt.Errorf("failed to parse archive: %w", err)
+		return fmt.Errorf("failed to load local manifest (run the updater first): %w", err)
 	}
-
-	absTargetDir, err := filepath.Abs(targetDir)
+	baseDir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to resolve target directory: %w", err)
+		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	// GitHub ZIP archives include a top-level directory named "repo-branch"
-	// We need to strip this prefix when extracting
-	var stripPrefix string
-	if len(r.File) > 0 {
-		// Detect the strip prefix from the first file
-		firstPath := r.File[0].Name
-		if idx := strings.Index(firstPath, "/"); idx != -1 {
-			stripPrefix = firstPath[:idx+1]
+	excludes := loadExcludes()
+	var candidates []manifest.FileInfo
+	for name, info := range localManifest {
+		if info.Hash == "" || isProtectedConfig(name) || paths.MatchesExclusion(name, excludes) {
+			continue
 		}
+		info.Name = name
+		candidates = append(candidates, info)
 	}
-
-	// Build a map of files to extract for quick lookup (if filtering is enabled)
-	var extractFilter map[string]bool
-	if len(filesToExtract) > 0 {
-		extractFilter = make(map[string]bool, len(filesToExtract))
-		for _, f := range filesToExtract {
-			normalizedPath := paths.Normalize(f.Name)
-			extractFilter[normalizedPath] = true
-		}
+	if !quietFlag && !nonInteractive {
+		fmt.Printf("Checking %d installed files...\n", len(candidates))
 	}
 
-	totalFiles := len(r.File)
-	extractedFiles := 0
-	skippedFiles := 0
-	lastReportedPercentage := -1
-
-	for _, f := range r.File {
-		// Strip the GitHub repo-branch prefix
-		relPath := f.Name
-		if stripPrefix != "" && strings.HasPrefix(relPath, stripPrefix) {
-			relPath = strings.TrimPrefix(relPath, stripPrefix)
-		}
+	byName := make(map[string]manifest.FileInfo, len(candidates))
+	for _, info := range candidates {
+		byName[info.Name] = info
+	}
+	var drifted []manifest.FileInfo
+	for _, name := range manifest.Verify(baseDir, candidates, paths.Denormalize) {
+		drifted = append(drifted, byName[name])
+	}
 
-		// Skip if nothing left after stripping
-		if relPath == "" {
-			continue
+	if len(drifted) == 0 {
+		fmt.Printf("All %d files match the manifest; nothing to repair.\n", len(candidates))
+		if shouldWriteResult() {
+			return writeUpdateSuccess(nil, nil, false)
 		}
+		return nil
+	}
 
-		// If we have a file filter (for updates), skip files not in the filter
-		if extractFilter != nil {
-			normalizedRelPath := paths.Normalize(relPath)
-			if !extractFilter[normalizedRelPath] {
-				skippedFiles++
-				if verboseFlag && !nonInteractive {
-					fmt.Printf("Skipping (not needed): %s\n", relPath)
-				}
-				continue
-			}
+	if !quietFlag {
+		fmt.Printf("%d files differ from the manifest:\n", len(drifted))
+		for _, info := range drifted {
+			fmt.Printf("  %s\n", info.Name)
 		}
+	}
+	if needsMUSHClientRestart(drifted) && isMUSHClientRunning() {
+		return fmt.Errorf("MUSHclient is running; close it and run repair again")
+	}
+	if !confirmAction("Re-download these files?") {
+		fmt.Println("Repair cancelled.")
+		return nil
+	}
+	if err := ensureWritable(baseDir, nil); err != nil {
+		return err
+	}
 
-		// Skip user configuration files during updates (but not during fresh install)
-		if !isInstall && paths.IsUserConfig(relPath) {
-			// Check if file already exists - only skip if it exists
-			filePath := filepath.Join(absTargetDir, paths.Denormalize(relPath))
-			if _, err := os.Stat(filePath); err == nil {
-				if !quietFlag && verboseFlag && !nonInteractive {
-					fmt.Printf("Preserving existing user config file: %s\n", relPath)
-				}
-				continue
-			}
-			// File doesn't exist, install it even though it's a config file
+	var repaired []manifest.FileInfo
+	partial := &partialUpdateError{}
+	for _, info := range drifted {
+		if err := downloadFile(info, nil); err != nil {
+			partial.Failed = append(partial.Failed, info.Name)
+			partial.Errs = append(partial.Errs, err)
+			continue
 		}
+		repaired = append(repaired, info)
+	}
 
-		// Archive paths use forward slashes, normalize to platform format
-		normalizedPath := paths.Denormalize(relPath)
-		fpath := filepath.Join(absTargetDir, normalizedPath)
-
-		// Security: Ensure path doesn't escape base directory
-		absFpath, err := filepath.Abs(fpath)
-		if err != nil {
-			return fmt.Errorf("failed to resolve path for %s: %w", relPath, err)
-		}
-		if !strings.HasPrefix(absFpath, absTargetDir) {
-			return fmt.Errorf("path traversal attempt detected in archive: %s", relPath)
+	fmt.Printf("Repaired %d of %d files.\n", len(repaired), len(drifted))
+	if len(partial.Failed) > 0 {
+		for _, err := range partial.Errs {
+			fmt.Printf("  %v\n", err)
 		}
-
-		if f.FileInfo().IsDir() {
-			if err := os.MkdirAll(absFpath, f.Mode()); err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", absFpath, err)
+		if shouldWriteResult() {
+			if err := writeUpdatePartial(drifted, nil, partial, false); err != nil {
+				console.Log("Warning: failed to write %s: %v", resultFile, err)
 			}
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(absFpath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory for %s: %w", absFpath, err)
 		}
+		os.Exit(exitPartialUpdate)
+	}
+	if shouldWriteResult() {
+		return writeUpdateSuccess(repaired, nil, false)
+	}
+	return nil
+}
 
-		rc, err := f.Open()
-		if err != nil {
-			return fmt.Errorf("failed to open file in archive %s: %w", relPath, err)
+// runSelfUpdateDryRun runs the self-update flow up to (but not including) the
+// binary swap and prints what it found
+func runSelfUpdateDryRun() error {
+	fmt.Println("Checking for updater self-update (dry run)...")
+
+	report, err := selfupdate.DryRun(selfupdate.DefaultConfig(appVersion))
+	if report != nil {
+		fmt.Printf("Current version:  %s\n", appVersion)
+		fmt.Printf("Remote version:   %s\n", report.RemoteVersion)
+		fmt.Printf("Update available: %s\n", yesNo(report.UpdateAvailable))
+		if report.BinarySize > 0 {
+			fmt.Printf("Downloaded:       %s from %s\n", formatBytes(int64(report.BinarySize)), report.BinaryURL)
 		}
-
-		out, err := os.OpenFile(absFpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
-		if err != nil {
-			rc.Close()
-			return fmt.Errorf("failed to create file %s: %w", absFpath, err)
+		if report.Checksum != "" {
+			fmt.Printf("Checksum:         %s\n", report.Checksum)
 		}
-
-		_, err = io.Copy(out, rc)
-		rc.Close()
-		out.Close()
-		if err != nil {
-			return fmt.Errorf("failed to write file %s: %w", absFpath, err)
+		if report.Signature != "" {
+			fmt.Printf("Signature:        %s\n", report.Signature)
 		}
+	}
+	if err != nil {
+		return err
+	}
 
-		extractedFiles++
-		percentage := (extractedFiles * 100) / totalFiles
-		// Update title bar with progress
-		console.SetTitle(fmt.Sprintf("%s - Extracting: %d%%", title, percentage))
-
-		if nonInteractive {
-			// Only print at meaningful intervals to avoid spam
-			// Scale interval based on number of files: more files = finer granularity
-			var interval int
-			if totalFiles < 100 {
-				interval = 25 // 25%, 50%, 75%, 100%
-			} else if totalFiles < 1000 {
-				interval = 10 // 10%, 20%, 30%...
-			} else {
-				interval = 5 // 5%, 10%, 15%...
-			}
+	fmt.Printf("Would update:     %s\n", yesNo(report.WouldUpdate))
+	return nil
+}
 
-			if percentage != lastReportedPercentage && (percentage%interval == 0 || percentage == 100) {
-				fmt.Printf("%d%%\n", percentage)
-				lastReportedPercentage = percentage
-			}
-		} else if !quietFlag {
-			if verboseFlag {
-				fmt.Printf("[%d/%d] (%d%%) %s\n", extractedFiles, totalFiles, percentage, relPath)
-			} else {
-				// Single line progress update
-				fmt.Printf("\rProgress: %d/%d (%d%%)    ", extractedFiles, totalFiles, percentage)
-			}
-		}
+// connectionEndpoints lists one URL on each host the updater talks to
+func connectionEndpoints() []struct{ name, url string } {
+	return []struct{ name, url string }{
+		{"GitHub API", fmt.Sprintf("https://api.github.com/repos/%s/%s", githubOwner, githubRepo)},
+		{"Raw content", getRawURLForTag("main", manifestFile)},
+		{"Archive download", fmt.Sprintf("https://github.com/%s/%s/archive/refs/heads/main.zip", githubOwner, githubRepo)},
+		{"Self-update", selfupdate.DefaultConfig(appVersion).BinaryURL},
 	}
+}
 
-	if !quietFlag && !nonInteractive {
-		if !verboseFlag {
-			fmt.Printf("\n") // New line after progress
-		}
-		if extractFilter != nil {
-			fmt.Printf("Extraction complete! (%d files extracted, %d skipped)\n", extractedFiles, skippedFiles)
+// runConnectionTest probes every endpoint through the shared HTTP client and
+// reports latency or the underlying error. Returns false if any probe failed.
+func runConnectionTest() bool {
+	fmt.Println("Testing connectivity...")
+	ok := true
+	for _, ep := range connectionEndpoints() {
+		result := download.Probe(httpClient, ep.url)
+		if result.Err != nil {
+			ok = false
+			fmt.Printf("  %-18s FAILED  %v\n", ep.name, result.Err)
 		} else {
-			fmt.Println("Extraction complete!")
+			fmt.Printf("  %-18s OK      %dms (HTTP %d)\n", ep.name, result.Latency.Milliseconds(), result.Status)
+		}
+		if verboseFlag {
+			fmt.Printf("  %-18s %s\n", "", ep.url)
 		}
 	}
 
-	// Reset title
-	console.SetTitle(title)
-	return nil
+	if ok {
+		fmt.Println("\nAll endpoints are reachable.")
+	} else {
+		fmt.Println("\nSome endpoints could not be reached. Check your firewall, proxy or antivirus settings.")
+	}
+	return ok
 }
 
-func downloadZipAndExtract(updates []manifest.FileInfo) error {
-	zipURL, err := getZipURLForChannel()
-	if err != nil {
-		return err
+// yesNo formats a boolean for human-readable reports
+func yesNo(b bool) string {
+	if b {
+		return "yes"
 	}
+	return "no"
+}
 
-	baseDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+// printCheckSummary prints one token for launchers that poll frequently:
+// update-available, up-to-date or not-installed. It makes no API calls beyond
+// the ones needed to compare manifests.
+func printCheckSummary(updates []manifest.FileInfo, deletedFiles []string) {
+	switch {
+	case !isInstalled():
+		fmt.Println("not-installed")
+	case len(updates) > 0 || len(deletedFiles) > 0:
+		fmt.Println("update-available")
+	default:
+		fmt.Println("up-to-date")
 	}
+}
 
-	if err := downloadAndExtractZip(zipURL, baseDir, false, updates); err != nil {
-		return err
+// buildCheckResult collects the same information printCheckOutput shows
+func buildCheckResult(updates []manifest.FileInfo, deletedFiles []string) CheckResult {
+	result := CheckResult{
+		Channel:         channelFlag,
+		UpdateAvailable: len(updates) > 0 || len(deletedFiles) > 0,
+		RestartRequired: needsMUSHClientRestart(updates),
+		Updates:         len(updates),
+		Deletions:       len(deletedFiles),
+		DownloadSize:    manifest.TotalSize(updates),
+		FilesDeleted:    deletedFiles,
+	}
+	for _, u := range updates {
+		result.FilesAdded = append(result.FilesAdded, u.Name)
+	}
+	if localVer, err := getLocalVersion(); err == nil {
+		result.CurrentVersion = localVer.String()
+	}
+	if latestVer, err := getLatestVersion(); err == nil && result.UpdateAvailable {
+		result.LatestVersion = latestVer.String()
+	}
+
+	switch {
+	case !isInstalled():
+		result.Status = "not-installed"
+		result.UpdateAvailable = false
+	case !isValidChannel(channelFlag):
+		result.Status = "channel-invalid"
+	case result.UpdateAvailable:
+		result.Status = "update-available"
+	default:
+		result.Status = "up-to-date"
+		result.LatestVersion = result.CurrentVersion
 	}
+	return result
+}
 
-	if !quietFlag && !nonInteractive {
-		fmt.Println("Saving manifest...")
+// printCheckJSON prints result as one JSON object on stdout
+func printCheckJSON(result CheckResult) {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fatalError("Failed to encode check result: %v", err)
 	}
-	return saveManifest()
+	fmt.Println(string(data))
 }
 
-// ============================================================================
-// SECTION 4: MANIFEST MANAGEMENT
-// ============================================================================
-
-func loadRemoteManifest() (map[string]manifest.FileInfo, error) {
-	var ref string
+// InstallStatus is what `status -json` prints
+type InstallStatus struct {
+	Installed         bool   `json:"installed"`                  // Whether the current directory is an installation
+	Version           string `json:"version,omitempty"`          // Installed version, if known
+	Channel           string `json:"channel"`                    // Active update channel
+	ChannelValid      *bool  `json:"channel_valid,omitempty"`    // Omitted for a branch channel unless -check was given
+	LastUpdated       string `json:"last_updated,omitempty"`     // When the manifest was last written (RFC 3339)
+	TrackedFiles      int    `json:"tracked_files"`              // Number of files in the local manifest
+	MUSHclientRunning bool   `json:"mushclient_running"`         // Whether MUSHclient is running from this installation
+	UpdateAvailable   *bool  `json:"update_available,omitempty"` // Only set with -check
+	Changes           int    `json:"changes,omitempty"`          // Files to update or remove (with -check)
+
+	UntrackedFiles []string `json:"untracked_files,omitempty"` // Files on disk that aren't in the manifest, user config or excluded
+}
 
-	if channelFlag == "stable" {
-		// For stable, get latest tag
-		tag, err := getLatestTag()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get latest tag: %w", err)
-		}
-		ref = tag
-		if !quietFlag && verboseFlag {
-			fmt.Printf("Using stable tag: %s\n", tag)
+// runStatus summarizes the installation in the current directory. Only with
+// check does it contact GitHub, to validate a branch channel and look for
+// updates; otherwise everything comes from local files.
+func runStatus(check bool) error {
+	status := InstallStatus{Installed: isInstalled(), Channel: channelFlag}
+	if status.Installed {
+		if v, err := getLocalVersion(); err == nil {
+			status.Version = v.String()
 		}
-	} else if channelFlag == "dev" {
-		// For dev, use main branch (latest commit)
-		ref = "main"
-		if !quietFlag && verboseFlag {
-			fmt.Printf("Using dev: main branch (latest commit)\n")
+		if local, err := manifestManager.LoadLocal(); err == nil {
+			status.TrackedFiles = len(local)
+			if untracked, err := findUntrackedFiles(); err == nil {
+				status.UntrackedFiles = untracked
+			}
 		}
-	} else {
-		// For custom branches, use the branch name directly
-		ref = channelFlag
-		if !quietFlag && verboseFlag {
-			fmt.Printf("Using experimental branch: %s\n", ref)
+		if info, err := os.Stat(manifestFile); err == nil {
+			status.LastUpdated = info.ModTime().Format(time.RFC3339)
 		}
+		status.MUSHclientRunning = isMUSHClientRunning()
 	}
 
-	// Get tree from GitHub API
-	tree, err := getGitHubTree(ref)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get file tree: %w", err)
+	if channelFlag == "stable" || channelFlag == "dev" {
+		valid := true
+		status.ChannelValid = &valid
+	} else if check {
+		valid := isValidChannel(channelFlag)
+		status.ChannelValid = &valid
 	}
 
-	// Convert tree to manifest format
-	fileManifest := make(map[string]manifest.FileInfo)
-	for _, item := range tree.Tree {
-		// Only include files (blobs), not directories (trees)
-		if item.Type != "blob" {
-			continue
+	// The manifest must already exist: status never regenerates it
+	if check && status.Installed && status.TrackedFiles > 0 {
+		updates, deletedFiles, err := getPendingUpdates()
+		if err != nil {
+			return fmt.Errorf("failed to check for updates: %w", err)
 		}
+		available := len(updates) > 0 || len(deletedFiles) > 0
+		status.UpdateAvailable = &available
+		status.Changes = len(updates) + len(deletedFiles)
+	}
 
-		// Skip excluded files
-		if manifestManager.ShouldExclude(item.Path, paths.Normalize) {
-			continue
+	if jsonFlag {
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
 		}
+		fmt.Println(string(data))
+		return nil
+	}
 
-		// Normalize path
-		normalizedPath := paths.Normalize(item.Path)
-
-		// Generate raw URL
-		rawURL := getRawURLForTag(ref, item.Path)
-
-		fileManifest[normalizedPath] = manifest.FileInfo{
-			Name: normalizedPath,
-			Hash: item.SHA, // Git SHA-1 hash from GitHub API
-			URL:  rawURL,
+	yesNo := func(b bool) string {
+		if b {
+			return "Yes"
 		}
+		return "No"
 	}
-
-	if !quietFlag && verboseFlag {
-		fmt.Printf("Found %d files in repository\n", len(fileManifest))
+	if !status.Installed {
+		fmt.Println("Installed: No")
+		fmt.Printf("Channel: %s\n", status.Channel)
+		return nil
 	}
-
-	return fileManifest, nil
-}
-
-func saveManifest() error {
-	// Get remote manifest (from GitHub API)
-	remoteManifest, err := loadRemoteManifest()
-	if err != nil {
-		return fmt.Errorf("failed to load remote manifest: %w", err)
+	fmt.Println("Installed: Yes")
+	if status.Version != "" {
+		fmt.Printf("Version: %s\n", status.Version)
+	} else {
+		fmt.Println("Version: unknown")
 	}
-
-	baseDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+	switch {
+	case status.ChannelValid == nil:
+		fmt.Printf("Channel: %s (not checked; use -check)\n", status.Channel)
+	case *status.ChannelValid:
+		fmt.Printf("Channel: %s\n", status.Channel)
+	default:
+		fmt.Printf("Channel: %s (branch no longer exists)\n", status.Channel)
 	}
-
-	// Only save files to local manifest that exist both in remote AND locally on disk
-	// This ensures the local manifest accurately represents what's actually installed
-	localManifest := make(map[string]manifest.FileInfo)
-	for path, info := range remoteManifest {
-		filePath := filepath.Join(baseDir, paths.Denormalize(path))
-		if _, err := os.Stat(filePath); err == nil {
-			// File exists locally, include it in the local manifest
-			localManifest[path] = info
+	if info, err := os.Stat(manifestFile); err == nil {
+		fmt.Printf("Last updated: %s\n", info.ModTime().Format("2006-01-02 15:04"))
+	} else {
+		fmt.Println("Last updated: unknown")
+	}
+	fmt.Printf("Tracked files: %d\n", status.TrackedFiles)
+	if len(status.UntrackedFiles) > 0 {
+		fmt.Printf("Untracked files: %d (run 'update verify' to review or move them)\n", len(status.UntrackedFiles))
+		if verboseFlag {
+			for _, path := range status.UntrackedFiles {
+				fmt.Printf("  %s\n", path)
+			}
 		}
 	}
-
-	// Save to local file
-	data, err := json.MarshalIndent(localManifest, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal manifest: %w", err)
+	fmt.Printf("MUSHclient running: %s\n", yesNo(status.MUSHclientRunning))
+	if status.UpdateAvailable != nil {
+		if *status.UpdateAvailable {
+			fmt.Printf("Update available: Yes (%d changes)\n", status.Changes)
+		} else {
+			fmt.Println("Update available: No")
+		}
 	}
+	return nil
+}
 
-	if err := os.WriteFile(filepath.Join(baseDir, manifestFile), append(data, '\n'), 0644); err != nil {
-		return fmt.Errorf("failed to save manifest: %w", err)
-	}
+// printCheckOutput shows what updates are available (either human-readable or machine format)
+func printCheckOutput(updates []manifest.FileInfo, deletedFiles []string) {
+	hasUpdates := len(updates) > 0 || len(deletedFiles) > 0
+	totalChanges := len(updates) + len(deletedFiles)
+	restartRequired := needsMUSHClientRestart(updates)
 
-	return nil
+	// Get version information
+	latestVer, err := getLatestVersion()
+	localVer, localErr := getLocalVersion()
+
+	if nonInteractive {
+		if !isInstalled() {
+			fmt.Println("Update available: Unknown")
+			fmt.Println("Status: Not installed")
+		} else if !isValidChannel(channelFlag) {
+			fmt.Println("Status: channel invalid")
+		} else if hasUpdates {
+			fmt.Println("Update available: Yes")
+			if err == nil {
+				fmt.Printf("Version: %s\n", latestVer.String())
+			}
+			if localErr == nil {
+				fmt.Printf("Current version: %s\n", localVer.String())
+			}
+			if restartRequired {
+				fmt.Println("Restart required: Yes")
+			} else {
+				fmt.Println("Restart required: No")
+			}
+			fmt.Printf("Changes: %d\n", totalChanges)
+			fmt.Printf("Updates: %d\n", len(updates))
+			fmt.Printf("Deletions: %d\n", len(deletedFiles))
+			if size := manifest.TotalSize(updates); size > 0 {
+				fmt.Printf("Download size: %s\n", formatBytes(size))
+			}
+			playSoundAsync(audio.CategoryStatus, upToDateSound, 0.0)
+		} else {
+			// No updates - minimal output: just status and current version
+			fmt.Println("Update available: No")
+			if localErr == nil {
+				fmt.Printf("Version: %s\n", localVer.String())
+			}
+		}
+	} else {
+		// Human-readable output for interactive mode
+		if hasUpdates {
+			fmt.Printf("\nAn update is available with %d total changes.\n", totalChanges)
+			if len(updates) > 0 {
+				if size := manifest.TotalSize(updates); size > 0 {
+					fmt.Printf("  • %d files will be updated (%s)\n", len(updates), formatBytes(size))
+				} else {
+					fmt.Printf("  • %d files will be updated\n", len(updates))
+				}
+			}
+			if len(deletedFiles) > 0 {
+				fmt.Printf("  • %d files will be deleted\n", len(deletedFiles))
+			}
+			if localErr == nil && err == nil {
+				fmt.Printf("\nCurrent version: %s\n", localVer.String())
+				fmt.Printf("New version: %s\n", latestVer.String())
+			}
+			if restartRequired {
+				fmt.Println("\nNote: This update requires MUSHclient to be restarted.")
+			}
+			fmt.Println("\nRun the updater again without 'check' to install the update.")
+		} else {
+			if !quietFlag {
+				playSoundAsync(audio.CategoryStatus, upToDateSound, 0.0)
+			}
+			console.Success("\nAlready up to date!")
+			if localErr == nil {
+				fmt.Printf("Current version: %s\n", localVer.String())
+			}
+		}
+	}
 }
 
-// ============================================================================
-// SECTION 6: INSTALLATION
-// ============================================================================
+func performUpdates(updates []manifest.FileInfo) error {
+	// We already checked if MUSHclient was running earlier in main()
 
-func handleInstallation() (string, error) {
-	// Determine default installation directory
-	usr, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	if err := snapshotPreviousState(); err != nil {
+		console.Log("Warning: failed to snapshot previous state: %v", err)
 	}
-	defaultInstallDir := filepath.Join(usr, "Documents", "Miriani-Next")
 
-	fmt.Println("Welcome to the Miriani-Next installer.")
+	// If it's a fresh install or lots of files changed, download as one big zip file for speed.
+	// Otherwise, download files individually in parallel.
+	useZip, reason := chooseDownloadStrategy(downloadModeFlag, isInstalled(), len(updates))
+	if verboseFlag && !quietFlag {
+		fmt.Printf("Download strategy: %s\n", reason)
+	}
 
-	// Check for embedded data early
-	hasEmbedded := embedded.HasData()
-	embeddedVersion := ""
-	if hasEmbedded {
-		embeddedVersion = embedded.GetVersion()
-		fmt.Printf("\nInstalling v%s\n", embeddedVersion)
+	if useZip {
+		return downloadZipAndExtract(updates)
 	}
 
-	// If no channel was explicitly set, prompt for selection during fresh install
-	if !channelExplicitlySet && !nonInteractive {
-		channelFlag = promptForChannelWithOptions(hasEmbedded)
+	// Stop before downloading anything if the files can't fit
+	if baseDir, err := os.Getwd(); err == nil {
+		if err := checkDiskSpace(map[string]int64{baseDir: manifest.TotalSize(updates)}); err != nil {
+			return err
+		}
 	}
 
-	// Determine installation directory
-	installDir := defaultInstallDir
+	// Download files in parallel (up to fileWorkers at a time)
+	sem := make(chan struct{}, fileWorkers)
+	var wg sync.WaitGroup
+	var updateMutex sync.Mutex
+	failed := &partialUpdateError{}
+	var completedCount int
+	total := len(updates)
 
-	// Ask if user wants to change the default location
-	if !nonInteractive {
-		fmt.Printf("\nDefault installation location: %s\n", defaultInstallDir)
-		if confirmAction("Do you want to change the installation location?") {
-			selectedDir, err := promptForInstallFolder(defaultInstallDir)
-			if err != nil {
-				fmt.Printf("Error selecting folder: %v\n", err)
-				fmt.Printf("Using default location: %s\n", defaultInstallDir)
-			} else {
-				installDir = selectedDir
+	if nonInteractive {
+		console.Info("%s", i18n.T("downloading"))
+	} else if !quietFlag {
+		fmt.Println("\n" + i18n.T("downloading_files", total))
+	}
+
+	// Progress is measured in bytes when the manifest records sizes: files
+	// that finished plus what each worker's current download has written so
+	// far. Without sizes it falls back to counting files.
+	totalBytes := manifest.TotalSize(updates)
+	var doneBytes int64
+	inFlight := make(map[string]int64)
+	lastPercentage := -1
+
+	progress := &progressThrottle{interval: progressIntervalFlag}
+	// showProgress redraws the progress line. The caller holds updateMutex.
+	showProgress := func(final bool) {
+		percentage := (completedCount * 100) / total
+		bytes := doneBytes
+		if totalBytes > 0 {
+			for _, n := range inFlight {
+				bytes += n
 			}
+			percentage = int(min(bytes*100/totalBytes, 100))
+		}
+		if !progress.allow(final) {
+			return
 		}
-	}
 
-	fmt.Printf("\nThis will install the %s version to: %s\n", channelFlag, installDir)
+		// Update title bar with progress
+		console.SetTitle(fmt.Sprintf("%s - Downloading: %d%%", title, percentage))
 
-	// Check if MUSHclient is running before installation
-	if process.IsMUSHClientRunningInDir(installDir) {
 		if nonInteractive {
-			// In non-interactive mode, kill MUSHclient before installing
-			console.Log("MUSHclient is running. Killing MUSHclient before installation...")
-			if err := exec.Command("taskkill", "/IM", "MUSHclient.exe", "/F").Run(); err != nil {
-				console.Log("Error: failed to kill MUSHclient: %v", err)
-				return "", fmt.Errorf("failed to kill MUSHclient: %w", err)
+			// In non-interactive mode, only print percentage
+			if percentage != lastPercentage {
+				fmt.Printf("%d%%\n", percentage)
 			}
-			console.Log("MUSHclient killed successfully. Proceeding with installation...")
-			// Wait for process to fully terminate
-			if !process.WaitForTermination("MUSHclient.exe", 5*time.Second) {
-				console.Log("Warning: MUSHclient may not have fully terminated")
+		} else if !quietFlag && !verboseFlag {
+			// Show progress without individual file names - single line update
+			if totalBytes > 0 {
+				fmt.Printf("\rDownloading: %d%% (%s of %s, %d/%d files)    ", percentage, formatBytes(bytes), formatBytes(totalBytes), completedCount, total)
+			} else {
+				fmt.Printf("\rProgress: %d/%d (%d%%)    ", completedCount, total, percentage)
 			}
-		} else {
-			// In interactive mode, tell user to close it
-			fmt.Println("\nMUSHclient is running and needs to be closed to update it.")
-			fmt.Println("Please close MUSHclient before proceeding with installation.")
-			playSound(errorSound)
-			waitForUser("\nPress Enter to exit...")
-			return "", fmt.Errorf("MUSHclient is running")
 		}
+		lastPercentage = percentage
 	}
 
-	if !confirmAction("Do you want to proceed with the installation?") {
-		fmt.Println("Installation cancelled.")
-		return "", ErrUserCancelled
-	}
+	for i, u := range updates {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(info manifest.FileInfo, idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var onProgress download.ProgressCallback
+			if totalBytes > 0 {
+				onProgress = func(bytesComplete, _ int64, _ int) {
+					updateMutex.Lock()
+					defer updateMutex.Unlock()
+					inFlight[info.Name] = bytesComplete
+					showProgress(false)
+				}
+			}
+			if err := downloadFile(info, onProgress); err != nil {
+				updateMutex.Lock()
+				delete(inFlight, info.Name)
+				failed.Failed = append(failed.Failed, info.Name)
+				failed.Errs = append(failed.Errs, err)
+				updateMutex.Unlock()
+			} else {
+				updateMutex.Lock()
+				defer updateMutex.Unlock()
+				delete(inFlight, info.Name)
+				doneBytes += info.Size
+				completedCount++
+				current := completedCount
 
-	// Create installation directory
-	if err := os.MkdirAll(installDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create installation directory: %w", err)
+				if verboseFlag && !quietFlag && !nonInteractive {
+					// Per-file log lines are output, not a redraw, so they aren't throttled
+					percentage := (current * 100) / total
+					fmt.Printf("[%d/%d] (%d%%) %s\n", current, total, percentage, info.Name)
+				}
+				showProgress(current == total)
+			}
+		}(u, i)
 	}
+	wg.Wait()
 
-	if !quietFlag {
-		fmt.Printf("\nInstalling to: %s\n", installDir)
+	if !quietFlag && !verboseFlag && !nonInteractive {
+		fmt.Printf("\n") // New line after progress
 	}
 
-	// Use embedded data if available (offline installer)
-	if hasEmbedded {
-		return installFromEmbedded(installDir, embeddedVersion)
+	// Workers finish in any order; list the failures by path
+	failed.sort()
+	if len(failed.Failed) > 0 && !bestEffortFlag {
+		return &failedUpdateError{failed}
 	}
 
-	// Get the appropriate zipball
-	zipURL, err := getZipURLForChannel()
-	if err != nil {
-		return "", err
+	if !quietFlag && !nonInteractive {
+		console.Info("%s", i18n.T("saving_manifest"))
 	}
+	// Reset title
+	console.SetTitle(title)
+	eventLog.Phase("manifest")
 
-	if !quietFlag && verboseFlag {
-		if channelFlag == "stable" {
-			tag, _ := getLatestTag()
-			fmt.Printf("Installing from tag: %s\n", tag)
-		} else if channelFlag == "dev" {
-			fmt.Println("Installing from main branch (latest commit)")
-		} else {
-			fmt.Printf("Installing from experimental branch: %s\n", channelFlag)
+	if len(failed.Failed) > 0 {
+		// Keep the old manifest entries for failed files so the next run retries them
+		if err := saveManifestExcept(failed.Failed); err != nil {
+			return err
 		}
+		return failed
 	}
+	return saveManifest()
+}
 
-	// Download and extract the archive (isInstall = true, no file filter = extract all)
-	if err := downloadAndExtractZip(zipURL, installDir, true, nil); err != nil {
-		return "", fmt.Errorf("failed to download installation: %w", err)
-	}
+// progressThrottle limits how often a progress line is redrawn. It is safe
+// for concurrent use by the download workers.
+type progressThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
 
-	// Change to installation directory for manifest save
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("failed to get current directory: %w", err)
-	}
-	if err := os.Chdir(installDir); err != nil {
-		return "", fmt.Errorf("failed to change to installation directory: %w", err)
+// allow reports whether a redraw may happen now. final redraws (100%) are
+// always allowed so the last state shown is accurate.
+func (t *progressThrottle) allow(final bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if !final && now.Sub(t.last) < t.interval {
+		return false
 	}
+	t.last = now
+	return true
+}
 
-	// Save a local manifest for future updates
-	if !quietFlag {
-		fmt.Println("Saving manifest...")
+// chooseDownloadStrategy decides between the full archive and individual
+// downloads, returning a human-readable reason for verbose output.
+// mode is the -download-mode flag: "files" or "zip" override the automatic choice.
+func chooseDownloadStrategy(mode string, installed bool, fileCount int) (useZip bool, reason string) {
+	switch mode {
+	case "files":
+		return false, fmt.Sprintf("%d files -> individual downloads (forced by -download-mode)", fileCount)
+	case "zip":
+		return true, "full archive (forced by -download-mode)"
 	}
-	if err := saveManifest(); err != nil {
-		// Non-fatal - just warn
-		fmt.Printf("Warning: failed to save manifest: %v\n", err)
+	if !installed {
+		return true, "not yet installed -> full archive"
 	}
-
-	// Save channel preference
-	if err := saveChannel(channelFlag); err != nil {
-		// Non-fatal - just warn
-		fmt.Printf("Warning: failed to save channel preference: %v\n", err)
-	} else if !quietFlag && verboseFlag {
-		fmt.Printf("Saved channel preference: %s\n", channelFlag)
+	if fileCount > zipThreshold {
+		return true, fmt.Sprintf("%d files > threshold %d -> full archive", fileCount, zipThreshold)
 	}
+	return false, fmt.Sprintf("%d files -> individual downloads", fileCount)
+}
 
-	// Save version.json with the installed version
-	if latestVer, err := getLatestVersion(); err == nil {
-		if versionData, err := json.MarshalIndent(latestVer, "", "  "); err == nil {
-			if err := os.WriteFile(versionFile, versionData, 0644); err != nil {
-				fmt.Printf("Warning: failed to save version file: %v\n", err)
-			} else if !quietFlag && verboseFlag {
-				fmt.Printf("Saved version: %s\n", latestVer.String())
-			}
+// verifyAppliedUpdates re-hashes the files performUpdates wrote and compares
+// them to the manifest. Mismatches are treated like failed downloads: their
+// old manifest entries are kept so the next run retries them, and they're
+// added to the returned partial error (which is nil if everything landed).
+func verifyAppliedUpdates(updates []manifest.FileInfo, partial *partialUpdateError) *partialUpdateError {
+	failed := make(map[string]bool)
+	if partial != nil {
+		for _, name := range partial.Failed {
+			failed[name] = true
 		}
 	}
-
-	// Create .updater-excludes file to protect user configuration
-	if err := createUpdaterExcludes(); err != nil {
-		// Non-fatal - just warn
-		fmt.Printf("Warning: failed to create .updater-excludes: %v\n", err)
-	} else if !quietFlag && verboseFlag {
-		fmt.Println("Created .updater-excludes file")
+	var applied []manifest.FileInfo
+	for _, u := range updates {
+		if !failed[u.Name] {
+			applied = append(applied, u)
+		}
 	}
 
-	// Create channel switching batch files
-	if err := install.CreateChannelSwitchBatchFiles(installDir); err != nil {
-		// Non-fatal - just warn
-		fmt.Printf("Warning: failed to create channel switch batch files: %v\n", err)
-	} else if !quietFlag && verboseFlag {
-		fmt.Println("Created channel switching batch files (switch-to-stable.bat, switch-to-dev.bat)")
+	baseDir, err := os.Getwd()
+	if err != nil {
+		console.Log("Warning: skipping verification: %v", err)
+		return partial
+	}
+	mismatched := manifest.Verify(baseDir, applied, paths.Denormalize)
+	verified := len(mismatched) == 0
+	updateVerification = &UpdateResult{Verified: &verified, FilesUnverified: mismatched}
+	if verified {
+		if verboseFlag && !quietFlag && !nonInteractive {
+			fmt.Printf("Verified %d updated files.\n", len(applied))
+		}
+		return partial
 	}
 
-	if !quietFlag {
-		fmt.Println("\nInstallation complete!")
-		fmt.Println("Location:", installDir)
+	if partial == nil {
+		partial = &partialUpdateError{}
 	}
+	for _, name := range mismatched {
+		err := fmt.Errorf("%s did not match the manifest after updating", name)
+		console.Log("Verification failed: %v", err)
+		partial.Failed = append(partial.Failed, name)
+		partial.Errs = append(partial.Errs, err)
+	}
+	partial.sort()
+	if err := saveManifestExcept(partial.Failed); err != nil {
+		console.Log("Warning: failed to save manifest: %v", err)
+	}
+	return partial
+}
 
-	// Check for MUDMixer or Proxiani and offer to configure world file
-	// Prioritize MUDMixer if both are running
-	proxianiDetected := isProxianiRunning()
-	mudmixerDetected := isMUDMixerRunning()
+// partialUpdateError is returned by performUpdates in -best-effort mode when
+// some files failed to download but the rest were applied, and by
+// verifyAppliedUpdates when applied files didn't match the manifest.
+// Errs[i] is the reason Failed[i] failed.
+type partialUpdateError struct {
+	Failed []string
+	Errs   []error
+}
 
-	if (proxianiDetected || mudmixerDetected) && !nonInteractive {
-		if mudmixerDetected {
-			// Play sound first, then wait before showing messages
-			go playSoundWithDucking(proxianiSound, 0.3)
-			time.Sleep(300 * time.Millisecond)
+func (e *partialUpdateError) Error() string {
+	return fmt.Sprintf("failed to update %d files: %v", len(e.Failed), e.Errs[0])
+}
 
-			fmt.Println("\nMUDMixer detected!")
-			fmt.Println("MUDMixer is a local proxy server that can provide additional features.")
-			fmt.Println("Would you like to configure Miriani-Next to connect through MUDMixer?")
-			fmt.Println("(This changes the connection from " + defaultServer + " to " + localServer + ":" + mudMixerPort + ")")
+// sort orders the failures by path, keeping each error with its file
+func (e *partialUpdateError) sort() {
+	order := make([]int, len(e.Failed))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return e.Failed[order[a]] < e.Failed[order[b]] })
+	failed := make([]string, len(order))
+	errs := make([]error, len(order))
+	for i, j := range order {
+		failed[i], errs[i] = e.Failed[j], e.Errs[j]
+	}
+	e.Failed, e.Errs = failed, errs
+}
 
-			if confirmAction("Configure Miriani to use MUDMixer?") {
-				worldFilePath := filepath.Join(installDir, worldsDir, worldFileName)
-				if err := updateWorldFileForMUDMixer(worldFilePath); err != nil {
-					fmt.Printf("Warning: failed to update world file for MUDMixer: %v\n", err)
-				} else {
-					fmt.Println("World file updated successfully!")
-					fmt.Println("Miriani-Next will now connect through MUDMixer (" + localServer + ":" + mudMixerPort + ")")
+// details lists every failure's reason on its own indented line. Past limit
+// (0 for no limit) the rest are counted rather than listed.
+func (e *partialUpdateError) details(limit int) string {
+	var b strings.Builder
+	for i, err := range e.Errs {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(&b, "  ...and %d more (run with -verbose to list them all)\n", len(e.Errs)-limit)
+			break
+		}
+		fmt.Fprintf(&b, "  %v\n", err)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// failures pairs each failed file with its reason for .update-result
+func (e *partialUpdateError) failures() []FileFailure {
+	failures := make([]FileFailure, len(e.Failed))
+	for i, name := range e.Failed {
+		failures[i] = FileFailure{Path: name, Error: e.Errs[i].Error()}
+	}
+	return failures
+}
+
+// failedUpdateError is returned by performUpdates when files failed to
+// download without -best-effort, so nothing was recorded in the manifest
+type failedUpdateError struct {
+	*partialUpdateError
+}
+
+// failureListLimit is how many failed files to print: all of them with
+// -verbose, otherwise maxListedFailures
+func failureListLimit() int {
+	if verboseFlag {
+		return 0
+	}
+	return maxListedFailures
+}
+
+// resolveMaxRate picks the download speed cap in KB/s: -max-rate if given
+// (saved for the install in the current directory), otherwise the saved
+// setting. 0 means unlimited.
+func resolveMaxRate() (int, error) {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+
+	if maxRateFlag < 0 {
+		rate, err := download.LoadMaxRate(baseDir)
+		if os.IsNotExist(err) {
+			if updaterConfig.MaxRate != nil {
+				return *updaterConfig.MaxRate, nil
+			}
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("ignoring saved download rate: %w", err)
+		}
+		return rate, nil
+	}
+
+	if install.IsInstalled(baseDir) {
+		if err := download.SaveMaxRate(baseDir, maxRateFlag); err != nil {
+			return maxRateFlag, fmt.Errorf("failed to save download rate: %w", err)
+		}
+	}
+	return maxRateFlag, nil
+}
+
+// grabClient is a shared grab client with retry and timeout settings
+var grabClient = grab.NewClient()
+
+// downloadedBytes accumulates the bytes fetched during this run for the
+// closing summary. Updated atomically by the parallel download workers.
+var downloadedBytes atomic.Int64
+
+// downloadFile fetches one manifest entry into the working directory. If
+// onProgress is set it receives the bytes written so far by each attempt.
+func downloadFile(info manifest.FileInfo, onProgress download.ProgressCallback) error {
+	// Never overwrite user configuration files
+	if isProtectedConfig(info.Name) {
+		if verboseFlag {
+			log.Printf("Skipping user config file: %s\n", info.Name)
+		}
+		return nil
+	}
+
+	// Refuse URLs outside the expected hosts before touching disk
+	if err := download.ValidateURL(info.URL, allowedDownloadHosts); err != nil {
+		return fmt.Errorf("refusing to download %s: %w", info.Name, err)
+	}
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	// Normalize the file path from manifest (forward slashes) to platform format
+	relativePath := paths.Denormalize(info.Name)
+	targetPath := filepath.Join(baseDir, relativePath)
+
+	// Ensure target path doesn't escape the base directory
+	absTargetPath, err := filepath.Abs(targetPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path for %s: %w", info.Name, err)
+	}
+	absBaseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+	if !strings.HasPrefix(absTargetPath, absBaseDir) {
+		return fmt.Errorf("path traversal attempt detected: %s", info.Name)
+	}
+
+	// Find actual path in case of case mismatch
+	targetPath, err = paths.FindActual(absTargetPath)
+	if err != nil {
+		return fmt.Errorf("failed to find path for %s: %w", info.Name, err)
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", info.Name, err)
+	}
+
+	// Transient failures (network errors, HTTP 429 and 5xx) are retried with
+	// backoff, so one bad response doesn't fail a large parallel update
+	onRetry := func(attempt int, wait time.Duration, err error) {
+		if !verboseFlag || quietFlag {
+			return
+		}
+		if errors.Is(err, download.ErrHashMismatch) {
+			log.Printf("Hash mismatch for %s, downloading again\n", info.Name)
+			return
+		}
+		log.Printf("Retrying %s in %s (attempt %d of %d): %v\n", info.Name, wait.Round(time.Millisecond), attempt, download.RetryAttempts, err)
+	}
+
+	// Download, retrying if the size on disk doesn't match the Content-Length
+	// or the size the manifest records (older manifests have none). This is a
+	// cheap check before the content is compared with the manifest's git blob
+	// SHA; a mismatch there is deleted and downloaded once more before giving up.
+	n, err := download.FileWithHash(runCtx, info.URL, targetPath, info.Size, info.Hash, onProgress, onRetry)
+	downloadedBytes.Add(n)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", info.Name, err)
+	}
+	eventLog.File(info.Name, "updated")
+	recordApplied(info.Name)
+
+	return nil
+}
+
+func downloadAndExtractZip(zipURL string, targetDir string, isInstall bool, filesToExtract []manifest.FileInfo) error {
+	if nonInteractive {
+		console.Info("%s", i18n.T("downloading"))
+	} else if !quietFlag {
+		console.Info("%s", i18n.T("downloading_archive"))
+	}
+	// Play downloading sound during fresh installation download
+	if isInstall {
+		playSoundAsyncLoop(audio.CategoryProgress, downloadingSound, 0.0, true) // Normal volume for downloading sound, looping
+	}
+
+	// Partial archives from runs that never finished are dropped after a day
+	download.RemoveStale(filepath.Join(os.TempDir(), archivePrefix+"*.zip"), staleArchiveAge)
+
+	// Download to a path that's the same on every run for the same content,
+	// so an interrupted download picks up where it stopped. Without one, use
+	// a fresh temp file as before.
+	tempPath, resumable := archiveDownloadPath(zipURL)
+	if !resumable {
+		tempFile, err := os.CreateTemp("", archivePrefix+"*.zip")
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
+		tempPath = tempFile.Name()
+		tempFile.Close()
+		defer os.Remove(tempPath) // Clean up temp file when done
+	}
+
+	// Updates know the size of the files they extract; check that they fit
+	// before downloading. Installs are checked once the archive is here.
+	extractSize := manifest.TotalSize(filesToExtract)
+	if err := checkDiskSpace(map[string]int64{targetDir: extractSize}); err != nil {
+		return err
+	}
+
+	// A resumed archive that turns out corrupt is downloaded again from scratch
+	for attempt := 1; ; attempt++ {
+		if err := fetchArchive(zipURL, tempPath, resumable && attempt == 1, targetDir, extractSize); err != nil {
+			return err
+		}
+		err := download.ValidateArchive(tempPath)
+		if err == nil {
+			break
+		}
+		os.Remove(tempPath)
+		if !resumable || attempt == 2 {
+			return fmt.Errorf("downloaded archive is corrupt: %w", err)
+		}
+		if verboseFlag && !quietFlag {
+			log.Printf("Downloaded archive is corrupt (%v), downloading it again\n", err)
+		}
+	}
+
+	if nonInteractive {
+		fmt.Println("Extracting...")
+	} else if !quietFlag {
+		fmt.Printf("Extracting files...\n")
+	}
+
+	// Stop any currently playing sounds (like download music) before starting extraction
+	stopAllSounds()
+
+	// Play installing sound during extraction (for fresh installs)
+	if isInstall {
+		playSoundAsyncLoop(audio.CategoryProgress, installingSound, -1.5, true) // Slightly lower volume for installing sound, looping
+	}
+
+	// Open downloaded ZIP file
+	zipFile, err := os.Open(tempPath)
+	if err != nil {
+		return fmt.Errorf("failed to open downloaded archive: %w", err)
+	}
+	defer zipFile.Close()
+
+	zipStat, err := zipFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat downloaded archive: %w", err)
+	}
+
+	r, err := zip.NewReader(zipFile, zipStat.Size())
+	if err != nil {
+		return fmt.Errorf("failed to parse archive: %w", err)
+	}
+
+	absTargetDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve target directory: %w", err)
+	}
+
+	// GitHub ZIP archives include a top-level directory named "repo-branch"
+	// We need to strip this prefix when extracting
+	var stripPrefix string
+	if len(r.File) > 0 {
+		// Detect the strip prefix from the first file
+		firstPath := r.File[0].Name
+		if idx := strings.Index(firstPath, "/"); idx != -1 {
+			stripPrefix = firstPath[:idx+1]
+		}
+	}
+
+	// Build a map of files to extract for quick lookup (if filtering is enabled)
+	var extractFilter map[string]bool
+	if len(filesToExtract) > 0 {
+		extractFilter = make(map[string]bool, len(filesToExtract))
+		for _, f := range filesToExtract {
+			normalizedPath := paths.Normalize(f.Name)
+			extractFilter[normalizedPath] = true
+		}
+	}
+
+	// Decide what to extract first, so the security checks and user-config
+	// preservation run before anything is written
+	type extractJob struct {
+		file    *zip.File
+		relPath string
+		absPath string
+	}
+	var jobs []extractJob
+	dirs := make(map[string]bool)
+	skippedFiles := 0
+	for _, f := range r.File {
+		// Strip the GitHub repo-branch prefix
+		relPath := f.Name
+		if stripPrefix != "" && strings.HasPrefix(relPath, stripPrefix) {
+			relPath = strings.TrimPrefix(relPath, stripPrefix)
+		}
+
+		// Skip if nothing left after stripping. Directory entries are handled
+		// below: folders with files are created along with the files, and
+		// empty ones are created after extraction
+		if relPath == "" || f.FileInfo().IsDir() {
+			continue
+		}
+
+		// If we have a file filter (for updates), skip files not in the filter
+		if extractFilter != nil {
+			normalizedRelPath := paths.Normalize(relPath)
+			if !extractFilter[normalizedRelPath] {
+				skippedFiles++
+				if verboseFlag && !nonInteractive {
+					fmt.Printf("Skipping (not needed): %s\n", relPath)
 				}
-			} else {
-				fmt.Println("Skipping MUDMixer configuration. You can manually change this later.")
+				continue
 			}
-		} else if proxianiDetected {
-			// Play sound first, then wait before showing messages
-			go playSoundWithDucking(proxianiSound, 0.3)
-			time.Sleep(300 * time.Millisecond)
+		}
+
+		// Skip user configuration files during updates (but not during fresh install)
+		if !isInstall && isProtectedConfig(relPath) {
+			// Check if file already exists - only skip if it exists
+			filePath := filepath.Join(absTargetDir, paths.Denormalize(relPath))
+			if _, err := os.Stat(filePath); err == nil {
+				if !quietFlag && verboseFlag && !nonInteractive {
+					fmt.Printf("Preserving existing user config file: %s\n", relPath)
+				}
+				continue
+			}
+			// File doesn't exist, install it even though it's a config file
+		}
+
+		// Archive paths use forward slashes, normalize to platform format
+		normalizedPath := paths.Denormalize(relPath)
+		fpath := filepath.Join(absTargetDir, normalizedPath)
+
+		// Security: Ensure path doesn't escape base directory
+		absFpath, err := filepath.Abs(fpath)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path for %s: %w", relPath, err)
+		}
+		if !strings.HasPrefix(absFpath, absTargetDir) {
+			return fmt.Errorf("path traversal attempt detected in archive: %s", relPath)
+		}
+
+		jobs = append(jobs, extractJob{file: f, relPath: relPath, absPath: absFpath})
+		dirs[filepath.Dir(absFpath)] = true
+	}
+
+	// Check the extracted files fit before writing any of them, so a full
+	// disk doesn't leave the install half updated
+	var uncompressed int64
+	for _, job := range jobs {
+		uncompressed += int64(job.file.UncompressedSize64)
+	}
+	if err := checkDiskSpace(map[string]int64{absTargetDir: uncompressed}); err != nil {
+		return err
+	}
+
+	// Create every folder up front so the workers never race on MkdirAll
+	for dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	// Extract in parallel (up to fileWorkers at a time); zip.File.Open is
+	// safe for concurrent use. The first failure stops the remaining files.
+	totalFiles := len(r.File)
+	extractedFiles := 0
+	lastReportedPercentage := -1
+	var extractMutex sync.Mutex
+	var extractErr error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fileWorkers)
+
+	for _, job := range jobs {
+		extractMutex.Lock()
+		failed := extractErr != nil
+		extractMutex.Unlock()
+		if failed {
+			break
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(job extractJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// Stop between files when cancelled so none is left half-written
+			err := runCtx.Err()
+			if err == nil {
+				err = download.ExtractFile(job.file, job.absPath)
+			}
+
+			extractMutex.Lock()
+			defer extractMutex.Unlock()
+			if err != nil {
+				if extractErr == nil {
+					if runCtx.Err() != nil {
+						extractErr = fmt.Errorf("extraction cancelled after %d files: %w", extractedFiles, runCtx.Err())
+					} else {
+						extractErr = err
+					}
+				}
+				return
+			}
+			eventLog.File(paths.Normalize(job.relPath), "updated")
+			recordApplied(paths.Normalize(job.relPath))
+
+			extractedFiles++
+			percentage := (extractedFiles * 100) / totalFiles
+			// Update title bar with progress
+			console.SetTitle(fmt.Sprintf("%s - Extracting: %d%%", title, percentage))
+
+			if nonInteractive {
+				// Only print at meaningful intervals to avoid spam
+				// Scale interval based on number of files: more files = finer granularity
+				var interval int
+				if totalFiles < 100 {
+					interval = 25 // 25%, 50%, 75%, 100%
+				} else if totalFiles < 1000 {
+					interval = 10 // 10%, 20%, 30%...
+				} else {
+					interval = 5 // 5%, 10%, 15%...
+				}
+
+				if percentage != lastReportedPercentage && (percentage%interval == 0 || percentage == 100) {
+					fmt.Printf("%d%%\n", percentage)
+					lastReportedPercentage = percentage
+				}
+			} else if !quietFlag {
+				if verboseFlag {
+					fmt.Printf("[%d/%d] (%d%%) %s\n", extractedFiles, totalFiles, percentage, job.relPath)
+				} else {
+					// Single line progress update
+					fmt.Printf("\rProgress: %d/%d (%d%%)    ", extractedFiles, totalFiles, percentage)
+				}
+			}
+		}(job)
+	}
+	wg.Wait()
+	if extractErr != nil {
+		return extractErr
+	}
+
+	// Archives can carry folders the install needs that have no files (such as
+	// plugin scaffolding); create any that are missing, except excluded ones
+	excludes := loadExcludes()
+	for _, dir := range download.ArchiveEmptyDirs(r, stripPrefix) {
+		if paths.MatchesExclusion(dir, excludes) || paths.MatchesExclusion(dir+"/", excludes) {
+			continue
+		}
+		absDir, err := download.ValidatePath(absTargetDir, filepath.Join(absTargetDir, paths.Denormalize(dir)))
+		if err != nil {
+			return fmt.Errorf("invalid directory in archive %s: %w", dir, err)
+		}
+		if _, err := os.Stat(absDir); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(absDir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", absDir, err)
+		}
+		if verboseFlag && !quietFlag && !nonInteractive {
+			fmt.Printf("Created empty directory: %s\n", dir)
+		}
+	}
+
+	if !quietFlag && !nonInteractive {
+		if !verboseFlag {
+			fmt.Printf("\n") // New line after progress
+		}
+		if extractFilter != nil {
+			console.Success("Extraction complete! (%d files extracted, %d skipped)", extractedFiles, skippedFiles)
+		} else {
+			console.Success("Extraction complete!")
+		}
+	}
+
+	// A resumable archive is kept until it has been extracted
+	if resumable {
+		zipFile.Close()
+		os.Remove(tempPath)
+	}
+
+	// Reset title
+	console.SetTitle(title)
+	return nil
+}
+
+// archivePrefix starts the names of archive downloads in the temp folder
+const archivePrefix = "miriani-update-"
+
+// staleArchiveAge is how long a partial archive is kept for resuming
+const staleArchiveAge = 24 * time.Hour
+
+// archiveDownloadPath returns where zipURL downloads to, named after the
+// content so a later run for the same content resumes it. Tag archives never
+// change, so the URL is enough; a branch archive also needs the commit the
+// branch is at, or a resume would stitch two commits together. ok is false
+// if that commit can't be looked up.
+func archiveDownloadPath(zipURL string) (path string, ok bool) {
+	key := zipURL
+	if _, branch, isBranch := strings.Cut(zipURL, "/archive/refs/heads/"); isBranch {
+		commit, err := getLatestCommit(strings.TrimSuffix(branch, ".zip"))
+		if err != nil {
+			return "", false
+		}
+		key += "@" + commit.SHA
+	}
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s%x.zip", archivePrefix, sum[:8])), true
+}
+
+// fetchArchive downloads zipURL to path, showing progress. With resume, a
+// partial file already at path is continued where the server allows it.
+// A cancelled download is left in place for the next run to resume. Once the
+// archive's size is known, the download stops if it and extractSize bytes
+// for targetDir won't fit.
+func fetchArchive(zipURL, path string, resume bool, targetDir string, extractSize int64) error {
+	var resumedBytes int64
+	if info, err := os.Stat(path); err == nil && resume {
+		resumedBytes = info.Size()
+	}
+
+	// Create grab request for ZIP download
+	req, err := grab.NewRequest(path, zipURL)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+	req = req.WithContext(runCtx)
+	req.NoResume = !resume
+	download.LimitRequest(req)
+
+	// Start download
+	resp := grabClient.Do(req)
+	if resume && resumedBytes > 0 && verboseFlag && !quietFlag {
+		log.Printf("Resuming archive download from %s\n", formatBytes(resumedBytes))
+	}
+
+	// Progress reporting loop. Speed and time left change on every tick, so
+	// the line is redrawn whenever its text changes; non-interactive output
+	// stays one bare percentage per line.
+	lastPercentage := -1
+	lastLine := ""
+	spaceChecked := false
+	ticker := time.NewTicker(progressIntervalFlag)
+	defer ticker.Stop()
+
+progressLoop:
+	for {
+		select {
+		case <-ticker.C:
+			// The size arrives with the response headers
+			if !spaceChecked && resp.Size() > 0 {
+				spaceChecked = true
+				needs := map[string]int64{filepath.Dir(path): resp.Size() - resp.BytesComplete()}
+				needs[targetDir] += extractSize
+				if err := checkDiskSpace(needs); err != nil {
+					resp.Cancel()
+					if !quietFlag && !verboseFlag && !nonInteractive {
+						fmt.Printf("\n")
+					}
+					return err
+				}
+			}
+
+			var line string
+			// Check if we have content length for percentage progress
+			if resp.Size() > 0 {
+				percentage := int(resp.Progress() * 100)
+				if nonInteractive && percentage != lastPercentage {
+					fmt.Printf("%d%%\n", percentage)
+				}
+				lastPercentage = percentage
+				var left time.Duration
+				if eta := resp.ETA(); !eta.IsZero() {
+					left = time.Until(eta)
+				}
+				line = fmt.Sprintf("Downloading: %d%%", percentage)
+				if rate := transferRate(resp.BytesPerSecond(), left); rate != "" {
+					line += " (" + rate + ")"
+				}
+			} else {
+				// No content length - show MB downloaded instead
+				line = fmt.Sprintf("Downloading: %d MB", resp.BytesComplete()/(1024*1024))
+				if rate := transferRate(resp.BytesPerSecond(), 0); rate != "" {
+					line += " (" + rate + ")"
+				}
+			}
+			if line != lastLine {
+				console.SetTitle(fmt.Sprintf("%s - %s", title, line))
+				if !quietFlag && !verboseFlag && !nonInteractive {
+					// Pad so a shorter line fully covers the previous one
+					fmt.Printf("\r%-*s", len(lastLine), line)
+				}
+				lastLine = line
+			}
+		case <-resp.Done:
+			break progressLoop
+		}
+	}
+
+	if !quietFlag && !verboseFlag && !nonInteractive {
+		fmt.Printf("\n")
+	}
+
+	// Check for download errors
+	if err := resp.Err(); err != nil {
+		if runCtx.Err() != nil {
+			return fmt.Errorf("%w: %w", download.ErrCancelled, runCtx.Err())
+		}
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	if resp.DidResume {
+		downloadedBytes.Add(resp.BytesComplete() - resumedBytes)
+	} else {
+		downloadedBytes.Add(resp.BytesComplete())
+	}
+	return nil
+}
+
+func downloadZipAndExtract(updates []manifest.FileInfo) error {
+	zipURL, err := getZipURLForChannel()
+	if err != nil {
+		return err
+	}
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	if err := downloadAndExtractZip(zipURL, baseDir, false, updates); err != nil {
+		return err
+	}
+	eventLog.Phase("manifest")
+
+	if !quietFlag && !nonInteractive {
+		console.Info("%s", i18n.T("saving_manifest"))
+	}
+	return saveManifest()
+}
+
+// ============================================================================
+// SECTION 4: MANIFEST MANAGEMENT
+// ============================================================================
+
+func loadRemoteManifest() (map[string]manifest.FileInfo, error) {
+	var ref string
+
+	if channelFlag == "stable" {
+		// For stable, get latest tag
+		tag, err := getLatestTag()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest tag: %w", err)
+		}
+		ref = tag
+		if !quietFlag && verboseFlag {
+			fmt.Printf("Using stable tag: %s\n", tag)
+		}
+	} else if channelFlag == "dev" {
+		// For dev, use main branch (latest commit)
+		ref = "main"
+		if !quietFlag && verboseFlag {
+			fmt.Printf("Using dev: main branch (latest commit)\n")
+		}
+	} else {
+		// For custom branches, use the branch name directly
+		ref = channelFlag
+		if !quietFlag && verboseFlag {
+			fmt.Printf("Using experimental branch: %s\n", ref)
+		}
+	}
+
+	// Get tree from GitHub API
+	tree, err := getGitHubTree(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file tree: %w", err)
+	}
+
+	// Convert tree to manifest format
+	fileManifest := make(map[string]manifest.FileInfo)
+	for _, item := range tree.Tree {
+		// Only include files (blobs), not directories (trees)
+		if item.Type != "blob" {
+			continue
+		}
+
+		// Skip excluded files
+		if manifestManager.ShouldExclude(item.Path, paths.Normalize) {
+			continue
+		}
+
+		// Normalize path
+		normalizedPath := paths.Normalize(item.Path)
+
+		// Generate raw URL
+		rawURL := getRawURLForTag(ref, item.Path)
+
+		fileManifest[normalizedPath] = manifest.FileInfo{
+			Name: normalizedPath,
+			Hash: item.SHA, // Git SHA-1 hash from GitHub API
+			URL:  rawURL,
+			Size: int64(item.Size),
+		}
+	}
+
+	if !quietFlag && verboseFlag {
+		fmt.Printf("Found %d files in repository\n", len(fileManifest))
+	}
+
+	return fileManifest, nil
+}
+
+func saveManifest() error {
+	return saveManifestExcept(nil)
+}
+
+// saveManifestExcept saves the local manifest, but for the given paths keeps
+// whatever the previous local manifest recorded (or leaves them out), so a
+// file that failed to update is still seen as outdated on the next run.
+func saveManifestExcept(skip []string) error {
+	// Get remote manifest (from GitHub API)
+	remoteManifest, err := loadRemoteManifest()
+	if err != nil {
+		return fmt.Errorf("failed to load remote manifest: %w", err)
+	}
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	// Only save files to local manifest that exist both in remote AND locally on disk
+	// This ensures the local manifest accurately represents what's actually installed
+	localManifest := make(map[string]manifest.FileInfo)
+	for path, info := range remoteManifest {
+		filePath := filepath.Join(baseDir, paths.Denormalize(path))
+		if _, err := os.Stat(filePath); err == nil {
+			// File exists locally, include it in the local manifest
+			localManifest[path] = info
+		}
+	}
+
+	// Files outside -only weren't touched, so they keep their old entries too
+	if len(onlyFlag) > 0 {
+		previous, _ := manifestManager.LoadLocal()
+		for path := range remoteManifest {
+			if !inOnlyScope(path) {
+				skip = append(skip, path)
+			}
+		}
+		for path := range previous {
+			if _, inRemote := remoteManifest[path]; !inRemote && !inOnlyScope(path) {
+				skip = append(skip, path)
+			}
+		}
+	}
+
+	if len(skip) > 0 {
+		// A missing or unreadable manifest just means failed files are left out
+		previous, _ := manifestManager.LoadLocal()
+		for _, path := range skip {
+			if old, ok := previous[path]; ok {
+				localManifest[path] = old
+			} else {
+				delete(localManifest, path)
+			}
+		}
+	}
+
+	// Save to local file
+	data, err := json.MarshalIndent(localManifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if err := paths.WriteFileAtomic(filepath.Join(baseDir, manifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// SECTION 6: INSTALLATION
+// ============================================================================
+
+// defaultInstallDir is the folder installs target: -install-dir (or
+// install_dir in .updater-config) when set, otherwise Documents\Miriani-Next
+func defaultInstallDir() (string, error) {
+	if installDirFlag != "" {
+		return filepath.Clean(installDirFlag), nil
+	}
+	usr, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(usr, "Documents", "Miriani-Next"), nil
+}
+
+func handleInstallation() (string, error) {
+	// Determine default installation directory
+	targetDir, err := defaultInstallDir()
+	if err != nil {
+		return "", err
+	}
+	if installTargetFlag != "" {
+		targetDir = installTargetFlag
+	}
+
+	fmt.Println("Welcome to the Miriani-Next installer.")
+
+	// Check for embedded data early
+	hasEmbedded := embedded.HasData()
+	embeddedVersion := ""
+	if hasEmbedded {
+		embeddedVersion = embedded.GetVersion()
+		fmt.Printf("\nInstalling v%s\n", embeddedVersion)
+	}
+
+	// If no channel was explicitly set, prompt for selection during fresh install
+	if !channelExplicitlySet && !nonInteractive {
+		channelFlag = promptForChannelWithOptions(hasEmbedded)
+	}
+
+	opts := install.Options{
+		DefaultDir:     targetDir,
+		DirChosen:      installTargetFlag != "" || installDirFlag != "",
+		Channel:        channelFlag,
+		NonInteractive: nonInteractive,
+		Quiet:          quietFlag,
+		Verbose:        verboseFlag,
+		ManifestFile:   manifestFile,
+		VersionFile:    versionFile,
+		ExcludesFile:   excludesFile,
+	}
+	deps := installDeps()
+
+	eventLog.Phase("install")
+	installDir, err := install.Prepare(opts, deps)
+	if err != nil {
+		return "", err
+	}
+	warnSharedPrefs(installDir)
+
+	// Use embedded data if available (offline installer)
+	if hasEmbedded {
+		return installFromEmbedded(installDir, embeddedVersion)
+	}
+
+	// Work from the installation directory from here on;
+	// remember where we started so the updater can be moved afterwards
+	originalDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if err := os.Chdir(installDir); err != nil {
+		return "", fmt.Errorf("failed to change to installation directory: %w", err)
+	}
+
+	if err := install.FromArchive(installDir, opts, deps); err != nil {
+		return "", err
+	}
+
+	// Check for MUDMixer or Proxiani and offer to configure world file
+	if proxyDetectionEnabled(installDir) {
+		offerProxyConfiguration(installDir)
+	} else if noProxyDetectFlag {
+		if err := saveProxyDetectSetting(installDir); err != nil {
+			console.Warn("Warning: failed to save proxy detection setting: %v", err)
+		}
+	}
+
+	// Move updater to installation directory (AFTER everything is done)
+	exePath, err := os.Executable()
+	if err == nil {
+		// Always name the destination file "update.exe"
+		destPath := filepath.Join(installDir, "update.exe")
+		// Only move if not already in install dir with correct name
+		absExePath, _ := filepath.Abs(exePath)
+		absDestPath, _ := filepath.Abs(destPath)
+
+		if absExePath != absDestPath {
+			// Go back to original directory before moving
+			os.Chdir(originalDir)
+
+			// Copy first, then remove original only if copy succeeded
+			data, err := os.ReadFile(exePath)
+			if err == nil {
+				if err := os.WriteFile(destPath, data, 0755); err == nil {
+					// Successfully copied, now safe to remove original
+					os.Remove(exePath)
+				}
+			}
+		}
+	}
+
+	return installDir, nil
+}
+
+// installDeps wires the install flow to the real prompts, GitHub and downloads
+func installDeps() install.Deps {
+	return install.Deps{
+		Prompt:   installPrompter{},
+		Source:   installSource{},
+		Download: installDownloader{},
+		Shortcut: installShortcut{},
+		Client:   installClientChecker{},
+		Elevator: installElevator{},
+	}
+}
+
+// installElevator implements install.Elevator, relaunching the installer
+// elevated with the chosen folder and channel
+type installElevator struct{}
+
+func (installElevator) Elevate(installDir string) error {
+	return requestElevation(installDir, []string{"-install-target", installDir, "-channel", channelFlag})
+}
+
+// installPrompter implements install.Prompter with the console prompts
+type installPrompter struct{}
+
+func (installPrompter) Confirm(p string) bool { return confirmAction(p) }
+
+func (installPrompter) Ask(p string) bool { return askChoice(p) }
+
+func (installPrompter) SelectFolder(defaultPath string) (string, error) {
+	return promptForInstallFolder(defaultPath)
+}
+
+// installSource implements install.Source for the current channel
+type installSource struct{}
+
+func (installSource) ArchiveURL() (string, error) {
+	zipURL, err := getZipURLForChannel()
+	if err != nil {
+		return "", err
+	}
+
+	if !quietFlag && verboseFlag {
+		if channelFlag == "stable" {
+			tag, _ := getLatestTag()
+			fmt.Printf("Installing from tag: %s\n", tag)
+		} else if channelFlag == "dev" {
+			fmt.Println("Installing from main branch (latest commit)")
+		} else {
+			fmt.Printf("Installing from experimental branch: %s\n", channelFlag)
+		}
+	}
+	return zipURL, nil
+}
+
+func (installSource) RemoteManifest() (map[string]manifest.FileInfo, error) {
+	return loadRemoteManifest()
+}
+
+func (installSource) LatestVersion() (*version.Version, error) {
+	return getLatestVersion()
+}
+
+// installDownloader implements install.Downloader with the archive download
+type installDownloader struct{}
+
+func (installDownloader) DownloadAndExtract(url, targetDir string) error {
+	// isInstall = true, no file filter = extract all
+	return downloadAndExtractZip(url, targetDir, true, nil)
+}
+
+// installShortcut implements install.ShortcutCreator via COM
+type installShortcut struct{}
+
+func (installShortcut) CreateShortcut(installDir string) (err error) {
+	// Recover from COM panics so a shortcut failure never aborts the install
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return createDesktopIcon(installDir)
+}
+
+// installClientChecker implements install.ClientChecker: in non-interactive
+// mode MUSHclient is killed, otherwise the user is asked to close it
+type installClientChecker struct{}
+
+func (installClientChecker) EnsureClosed(installDir string) error {
+	if !process.IsMUSHClientRunningInDir(installDir) {
+		return nil
+	}
+
+	if nonInteractive {
+		console.Log("MUSHclient is running. Killing MUSHclient before installation...")
+		if err := exec.Command("taskkill", "/IM", "MUSHclient.exe", "/F").Run(); err != nil {
+			console.Log("Error: failed to kill MUSHclient: %v", err)
+			return fmt.Errorf("failed to kill MUSHclient: %w", err)
+		}
+		console.Log("MUSHclient killed successfully. Proceeding with installation...")
+		// Wait for process to fully terminate
+		if !process.WaitForTermination("MUSHclient.exe", 5*time.Second) {
+			console.Log("Warning: MUSHclient may not have fully terminated")
+		}
+		return nil
+	}
+
+	// In interactive mode, tell user to close it
+	fmt.Println("\n" + i18n.T("mushclient_must_close"))
+	fmt.Println("Please close MUSHclient before proceeding with installation.")
+	playSound(audio.CategoryError, errorSound)
+	waitForUser("\n" + i18n.T("press_enter_exit"))
+	return fmt.Errorf("MUSHclient is running")
+}
+
+// ------------------------
+// UPDATER MANAGEMENT
+// ------------------------
+func copyUpdaterToInstallation(installDir string) error {
+	// Get current executable path
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	// Read current executable
+	data, err := os.ReadFile(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to read updater: %w", err)
+	}
+
+	// Write to installation directory as update.exe
+	destPath := filepath.Join(installDir, "update.exe")
+	if err := os.WriteFile(destPath, data, 0755); err != nil {
+		return fmt.Errorf("failed to write updater: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// SECTION 7: PROCESS DETECTION (delegated to internal/process)
+// ============================================================================
+
+// offerProxyConfiguration detects a running Proxiani or MUDMixer and offers
+// to point the world files at it (automatically in non-interactive mode)
+func offerProxyConfiguration(installDir string) {
+	if err := loadProxyConfig(installDir); err != nil {
+		console.Warn("Warning: ignoring %s: %v", proxyConfigFile, err)
+	}
+
+	// World files that already go through a proxy get the opposite offer
+	if !nonInteractive {
+		var proxied []string
+		all, _ := install.FindWorldFiles(installDir, worldsDir, worldFileName, worldFileExt)
+		for _, path := range all {
+			if ok, err := install.UsesLocalProxy(path, worldFileConfig); err == nil && ok {
+				proxied = append(proxied, path)
+			}
+		}
+		if len(proxied) > 0 {
+			offerProxyDisconnect(proxied)
+			return
+		}
+	}
+
+	// Only ask which world files to change once there's something to change.
+	// Worlds for another server (an alt or test server) are left alone; it's
+	// only an error if that leaves nothing to configure.
+	configureWorldFile := func(update func(string) error) error {
+		worldFiles, err := locateWorldFiles(installDir)
+		if err != nil {
+			return err
+		}
+		var errs, skipped []error
+		for _, path := range worldFiles {
+			err := update(path)
+			switch {
+			case errors.Is(err, install.ErrOtherServer):
+				skipped = append(skipped, fmt.Errorf("%s: %w", filepath.Base(path), err))
+				if verboseFlag && !quietFlag {
+					log.Printf("Skipping %s: it connects to another server", filepath.Base(path))
+				}
+			case err != nil:
+				errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			}
+		}
+		if len(skipped) == len(worldFiles) {
+			return errors.Join(skipped...)
+		}
+		return errors.Join(errs...)
+	}
+
+	// Prioritize MUDMixer if both are running
+	proxianiDetected := isProxianiRunning()
+	mudmixerDetected := isMUDMixerRunning()
+
+	if (proxianiDetected || mudmixerDetected) && !nonInteractive {
+		if mudmixerDetected {
+			// Play sound first, then wait before showing messages
+			go playSoundWithDucking(audio.CategoryStatus, proxianiSound, 0.3)
+			time.Sleep(300 * time.Millisecond)
+
+			fmt.Println("\nMUDMixer detected!")
+			fmt.Println("MUDMixer is a local proxy server that can provide additional features.")
+			fmt.Println("Would you like to configure Miriani-Next to connect through MUDMixer?")
+			fmt.Println("(This changes the connection from " + defaultServer + " to " + worldFileConfig.LocalServer + ":" + worldFileConfig.MUDMixerPort + ")")
+
+			if confirmAction("Configure Miriani to use MUDMixer?") {
+				if err := configureWorldFile(updateWorldFileForMUDMixer); err != nil {
+					console.Warn("Warning: failed to update world file for MUDMixer: %v", err)
+				} else {
+					console.Success("World file updated successfully!")
+					fmt.Println("Miriani-Next will now connect through MUDMixer (" + worldFileConfig.LocalServer + ":" + worldFileConfig.MUDMixerPort + ")")
+				}
+			} else {
+				fmt.Println("Skipping MUDMixer configuration. You can manually change this later.")
+			}
+		} else if proxianiDetected {
+			// Play sound first, then wait before showing messages
+			go playSoundWithDucking(audio.CategoryStatus, proxianiSound, 0.3)
+			time.Sleep(300 * time.Millisecond)
+
+			fmt.Println("\nProxiani detected!")
+			fmt.Println("Proxiani is a local proxy server that can provide additional features.")
+			fmt.Println("Would you like to configure Miriani-Next to connect through Proxiani?")
+			fmt.Println("(This changes the connection from " + defaultServer + " to " + worldFileConfig.LocalServer + ":" + worldFileConfig.ProxianiPort + ")")
+
+			if confirmAction("Configure Miriani to use Proxiani?") {
+				if err := configureWorldFile(updateWorldFileForProxiani); err != nil {
+					console.Warn("Warning: failed to update world file for Proxiani: %v", err)
+				} else {
+					console.Success("World file updated successfully!")
+					fmt.Println("Miriani-Next will now connect through Proxiani (" + worldFileConfig.LocalServer + ":" + worldFileConfig.ProxianiPort + ")")
+				}
+			} else {
+				fmt.Println("Skipping Proxiani configuration. You can manually change this later.")
+			}
+		}
+	} else if (proxianiDetected || mudmixerDetected) && nonInteractive {
+		// In non-interactive mode, auto-configure (prioritize MUDMixer)
+		if mudmixerDetected {
+			console.Log("MUDMixer detected! Auto-configuring world file...")
+			if err := configureWorldFile(updateWorldFileForMUDMixer); err != nil {
+				console.Log("Warning: failed to update world file for MUDMixer: %v", err)
+			} else {
+				console.Log("World file updated successfully for MUDMixer")
+			}
+		} else if proxianiDetected {
+			console.Log("Proxiani detected! Auto-configuring world file...")
+			if err := configureWorldFile(updateWorldFileForProxiani); err != nil {
+				console.Log("Warning: failed to update world file for Proxiani: %v", err)
+			} else {
+				console.Log("World file updated successfully for Proxiani")
+			}
+		}
+	}
+}
+
+// offerProxyDisconnect offers to point world files that connect through
+// Proxiani or MUDMixer back at the game server
+func offerProxyDisconnect(worldFiles []string) {
+	fmt.Println("\nMiriani-Next is set to connect through a proxy (" + worldFileConfig.LocalServer + ").")
+	fmt.Println("If you no longer use Proxiani or MUDMixer, it can connect directly to " + defaultServer + " instead.")
+
+	if !confirmAction("Disconnect from the proxy and connect directly?") {
+		fmt.Println("Keeping the proxy connection. You can change this later with: update config proxy none")
+		return
+	}
+	for _, worldFilePath := range worldFiles {
+		site, port, err := restoreDirectConnection(worldFilePath)
+		if err != nil {
+			console.Warn("Warning: failed to update %s: %v", filepath.Base(worldFilePath), err)
+			continue
+		}
+		fmt.Printf("%s will now connect directly to %s:%s\n", filepath.Base(worldFilePath), site, port)
+	}
+}
+
+// proxyDetectionEnabled reports whether the install should look for a MUD
+// proxy: not when -no-proxy-detect is given or the install has it turned off
+func proxyDetectionEnabled(installDir string) bool {
+	if noProxyDetectFlag {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(installDir, proxyDetectFile))
+	return err != nil || strings.TrimSpace(string(data)) != "off"
+}
+
+// saveProxyDetectSetting remembers that proxy detection is off for installDir
+func saveProxyDetectSetting(installDir string) error {
+	return os.WriteFile(filepath.Join(installDir, proxyDetectFile), []byte("off\n"), 0644)
+}
+
+func isProxianiRunning() bool {
+	if !proxyIsLocal() {
+		return process.IsPortReachable(worldFileConfig.LocalServer, worldFileConfig.ProxianiPort, 2*time.Second)
+	}
+	return process.IsNodeListeningOnPort(worldFileConfig.ProxianiPort)
+}
+
+// proxyIsLocal reports whether the configured proxy host is this machine
+func proxyIsLocal() bool {
+	host := worldFileConfig.LocalServer
+	return host == localServer || host == "127.0.0.1" || host == "::1"
+}
+
+// loadProxyConfig applies the install's .proxy-config, if any, to worldFileConfig
+func loadProxyConfig(installDir string) error {
+	cfg, err := install.LoadProxyConfig(filepath.Join(installDir, proxyConfigFile), worldFileConfig)
+	if err != nil {
+		return err
+	}
+	worldFileConfig = cfg
+	return nil
+}
+
+// ============================================================================
+// SECTION 8: WORLD FILE UPDATES
+// ============================================================================
+
+var worldFileConfig = install.WorldFileConfig{
+	DefaultServer: defaultServer,
+	DefaultPort:   defaultPort,
+	LocalServer:   localServer,
+	ProxianiPort:  proxianiPort,
+	MUDMixerPort:  mudMixerPort,
+}
+
+// locateWorldFiles picks the world files to configure: the only .mcl under
+// worlds/, or the user's choice (one or all) if there are several. Non-interactive
+// runs can't ask, so they get all of them.
+func locateWorldFiles(installDir string) ([]string, error) {
+	candidates, err := install.FindWorldFiles(installDir, worldsDir, worldFileName, worldFileExt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("no %s world file found under %s", worldFileExt, worldsDir)
+	case len(candidates) == 1, nonInteractive:
+		return candidates, nil
+	}
+
+	options := make([]string, len(candidates), len(candidates)+1)
+	for i, c := range candidates {
+		if rel, err := filepath.Rel(installDir, c); err == nil {
+			options[i] = rel
+		} else {
+			options[i] = c
+		}
+	}
+	options = append(options, "All of them")
+	choice := prompt.Choose("Several world files were found. Which one should be configured?", options, promptConfig())
+	switch {
+	case choice < 0:
+		return nil, fmt.Errorf("no world file selected")
+	case choice == len(candidates):
+		return candidates, nil
+	}
+	return candidates[choice : choice+1], nil
+}
+
+func updateWorldFile(worldFilePath string, updatePort bool) error {
+	return install.UpdateWorldFile(worldFilePath, updatePort, worldFileConfig)
+}
+
+func updateWorldFileForProxiani(worldFilePath string) error {
+	return updateWorldFile(worldFilePath, false)
+}
+
+// restoreDirectConnection undoes updateWorldFile, returning the site and port
+// the world file connects to now
+func restoreDirectConnection(worldFilePath string) (string, string, error) {
+	return install.RestoreDirectConnection(worldFilePath, worldFileConfig)
+}
+
+func isMUDMixerRunning() bool {
+	if !proxyIsLocal() {
+		return process.IsPortReachable(worldFileConfig.LocalServer, worldFileConfig.MUDMixerPort, 2*time.Second)
+	}
+	return process.IsPortListening(worldFileConfig.MUDMixerPort)
+}
+
+func updateWorldFileForMUDMixer(worldFilePath string) error {
+	return updateWorldFile(worldFilePath, true)
+}
+
+// runConfigCommand handles the config subcommand; args[0] names the setting
+func runConfigCommand(args []string) error {
+	if len(args) == 0 {
+		return printEffectiveConfig()
+	}
+
+	switch args[0] {
+	case "proxy":
+		if len(args) < 2 || args[1] != "none" {
+			return fmt.Errorf("usage: update config proxy none")
+		}
+		baseDir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if err := loadProxyConfig(baseDir); err != nil {
+			return err
+		}
+		worldFiles, err := locateWorldFiles(baseDir)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, worldFilePath := range worldFiles {
+			site, port, err := restoreDirectConnection(worldFilePath)
+			switch {
+			case errors.Is(err, install.ErrAlreadyDirect):
+				fmt.Printf("%s is already using a direct connection\n", filepath.Base(worldFilePath))
+			case err != nil:
+				errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(worldFilePath), err))
+			default:
+				fmt.Printf("%s now connects directly to %s:%s\n", filepath.Base(worldFilePath), site, port)
+			}
+		}
+		return errors.Join(errs...)
+	case "connection":
+		if siteFlag == "" || portFlag == 0 {
+			return fmt.Errorf("config connection needs both -site and -port")
+		}
+		baseDir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		worldFiles, err := locateWorldFiles(baseDir)
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, worldFilePath := range worldFiles {
+			if err := install.SetWorldConnection(worldFilePath, siteFlag, portFlag); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(worldFilePath), err))
+				continue
+			}
+			fmt.Printf("%s now connects to %s:%d\n", filepath.Base(worldFilePath), siteFlag, portFlag)
+			fmt.Printf("The original is saved as %s.bak\n", filepath.Base(worldFilePath))
+		}
+		return errors.Join(errs...)
+	default:
+		return fmt.Errorf("unknown setting %q (available: connection, proxy; run 'update config' alone to show the current settings)", args[0])
+	}
+}
+
+// applyUpdaterConfig loads .updater-config from the current directory and
+// uses it for every preference not set on the command line. Volume and the
+// download cap are only defaults: the values remembered from -volume and
+// -max-rate win, like .update-channel does for the channel. On error nothing
+// is applied.
+func applyUpdaterConfig() error {
+	flag.Visit(func(f *flag.Flag) {
+		explicitFlags[f.Name] = true
+	})
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		return err
+	}
+	if cfg.ProxyHost != "" && !install.ValidHost(cfg.ProxyHost) {
+		return fmt.Errorf("invalid %s: proxy_host %q", config.File, cfg.ProxyHost)
+	}
+	updaterConfig = cfg
+
+	if cfg.Channel != "" && !explicitFlags["channel"] {
+		channelFlag = cfg.Channel
+	}
+	if cfg.Quiet != nil && !explicitFlags["quiet"] {
+		quietFlag = *cfg.Quiet
+	}
+	if cfg.Verbose != nil && !explicitFlags["verbose"] {
+		verboseFlag = *cfg.Verbose
+	}
+	if cfg.InstallDir != "" && !explicitFlags["install-dir"] {
+		installDirFlag = cfg.InstallDir
+	}
+	if cfg.ChangelogViewer != "" && !explicitFlags["changelog-viewer"] {
+		changelogViewerFlag = cfg.ChangelogViewer
+	}
+	// .proxy-config, loaded later on top of these, still overrides them
+	if cfg.ProxyHost != "" {
+		worldFileConfig.LocalServer = cfg.ProxyHost
+	}
+	if cfg.ProxianiPort != 0 {
+		worldFileConfig.ProxianiPort = strconv.Itoa(cfg.ProxianiPort)
+	}
+	if cfg.MUDMixerPort != 0 {
+		worldFileConfig.MUDMixerPort = strconv.Itoa(cfg.MUDMixerPort)
+	}
+	return nil
+}
+
+// printEffectiveConfig shows each preference after merging the command line,
+// the remembered per-setting files, .updater-config and the defaults, along
+// with where its value came from
+func printEffectiveConfig() error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(baseDir, name))
+		return err == nil
+	}
+	source := func(flagName, savedFile string, inConfig bool) string {
+		switch {
+		case explicitFlags[flagName]:
+			return "command line"
+		case savedFile != "" && exists(savedFile):
+			return savedFile
+		case inConfig:
+			return config.File
+		default:
+			return "default"
+		}
+	}
+
+	ch, chSource := channelFlag, source("channel", "", updaterConfig.Channel != "")
+	if !explicitFlags["channel"] {
+		if saved, err := loadChannel(); err == nil {
+			ch, chSource = saved, channelFile
+		}
+	}
+	volume, _ := resolveVolume()
+	maxRate, _ := resolveMaxRate()
+	if err := loadProxyConfig(baseDir); err != nil {
+		console.Warn("Warning: ignoring %s: %v", proxyConfigFile, err)
+	}
+	proxySource := source("", proxyConfigFile, updaterConfig.ProxyHost != "" || updaterConfig.ProxianiPort != 0 || updaterConfig.MUDMixerPort != 0)
+
+	rate := "unlimited"
+	if maxRate > 0 {
+		rate = fmt.Sprintf("%d KB/s", maxRate)
+	}
+
+	fmt.Printf("Settings for %s:\n", baseDir)
+	fmt.Printf("  %-18s %-24s (%s)\n", "channel", ch, chSource)
+	fmt.Printf("  %-18s %-24t (%s)\n", "quiet", quietFlag, source("quiet", "", updaterConfig.Quiet != nil))
+	fmt.Printf("  %-18s %-24t (%s)\n", "verbose", verboseFlag, source("verbose", "", updaterConfig.Verbose != nil))
+	fmt.Printf("  %-18s %-24d (%s)\n", "volume", volume, source("volume", audio.VolumeFile, updaterConfig.Volume != nil))
+	fmt.Printf("  %-18s %-24s (%s)\n", "max_rate", rate, source("max-rate", download.MaxRateFile, updaterConfig.MaxRate != nil))
+	fmt.Printf("  %-18s %-24s (%s)\n", "proxy_host", worldFileConfig.LocalServer, proxySource)
+	fmt.Printf("  %-18s %-24s (%s)\n", "proxiani_port", worldFileConfig.ProxianiPort, proxySource)
+	fmt.Printf("  %-18s %-24s (%s)\n", "mudmixer_port", worldFileConfig.MUDMixerPort, proxySource)
+	viewer := changelogViewerFlag
+	if viewer == "" {
+		viewer = "ask"
+	}
+	fmt.Printf("  %-18s %-24s (%s)\n", "changelog_viewer", viewer, source("changelog-viewer", "", updaterConfig.ChangelogViewer != ""))
+	fmt.Printf("  %-18s %-24d (%s)\n", "changelog_history", changelogHistoryLimit(), source("", "", updaterConfig.ChangelogHistory != nil))
+	commitLayout := updaterConfig.ChangelogCommits
+	if commitLayout == "" {
+		commitLayout = "grouped"
+	}
+	fmt.Printf("  %-18s %-24s (%s)\n", "changelog_commits", commitLayout, source("", "", updaterConfig.ChangelogCommits != ""))
+	if dir, err := defaultInstallDir(); err == nil {
+		fmt.Printf("  %-18s %-24s (%s)\n", "install_dir", dir, source("install-dir", "", updaterConfig.InstallDir != ""))
+	}
+	if !exists(config.File) {
+		fmt.Printf("\nNo %s found; create one to change these defaults.\n", config.File)
+	}
+	return nil
+}
+
+// ============================================================================
+// SECTION 11: INSTALLATION DETECTION
+// ============================================================================
+
+func isInstalled() bool {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	return install.IsInstalled(baseDir)
+}
+
+func hasWorldFilesInCurrentDir() bool {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	return install.HasWorldFiles(baseDir)
+}
+
+// ============================================================================
+// SECTION 16: MISCELLANEOUS
+// ============================================================================
+
+// needsMUSHClientRestart reports whether any update only takes effect after
+// MUSHclient restarts. The repo can list startup-critical paths in
+// .restart-paths (same pattern syntax as .updater-excludes); without that file,
+// MUSHclient.exe and DLLs are the only such files.
+func needsMUSHClientRestart(updates []manifest.FileInfo) bool {
+	patterns := loadRestartPatterns()
+	for _, file := range updates {
+		if paths.NeedsRestart(file.Name, patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadRestartPatterns reads .restart-paths from the install directory,
+// returning nil if it doesn't exist
+func loadRestartPatterns() paths.Excludes {
+	return paths.LoadRestartPatterns(restartPathsFile)
+}
+
+// mushClientExeModified compares MUSHclient.exe on disk with the hash recorded
+// in the local manifest. Returns false if either is missing.
+func mushClientExeModified() (bool, error) {
+	localManifest, err := manifestManager.LoadLocal()
+	if err != nil {
+		return false, err
+	}
+
+	for name, info := range localManifest {
+		if !strings.EqualFold(name, "MUSHclient.exe") || info.Hash == "" {
+			continue
+		}
+		hash, err := manifest.GitBlobSHAFile(paths.Denormalize(name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return false, nil
+			}
+			return false, err
+		}
+		return hash != info.Hash, nil
+	}
+	return false, nil
+}
+
+func isMUSHClientRunning() bool {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	return process.IsMUSHClientRunningInDir(baseDir)
+}
+
+func launchMUSHClient() error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	// Check if MUSHclient is already running to prevent duplicate instances
+	if process.IsMUSHClientRunningInDir(baseDir) {
+		return fmt.Errorf("MUSHclient is already running")
+	}
+
+	exePath := filepath.Join(baseDir, "MUSHclient.exe")
+	if _, err := os.Stat(exePath); err != nil {
+		return fmt.Errorf("MUSHclient.exe not found: %w", err)
+	}
+
+	if err := exec.Command(exePath).Start(); err != nil {
+		return fmt.Errorf("failed to launch MUSHclient: %w", err)
+	}
+
+	return nil
+}
+
+// stringListFlag is a flag that can be given more than once
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// inOnlyScope reports whether a manifest path falls under one of the -only
+// prefixes; with no -only flags every path is in scope.
+func inOnlyScope(path string) bool {
+	return paths.UnderPrefix(path, onlyFlag)
+}
+
+func loadExcludes() paths.Excludes {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	return paths.LoadExcludes(filepath.Join(baseDir, excludesFile))
+}
+
+// reportExcludeChanges notes where .updater-excludes differs from the file the
+// updater generates. Removed defaults mean user config may be overwritten;
+// added entries mean those files will never be updated.
+func reportExcludeChanges(excludes paths.Excludes) {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, excludesFile)); err != nil {
+		fmt.Printf("Note: %s is missing, so no user files are protected from updates\n", excludesFile)
+		return
+	}
+
+	removed, added := install.CompareExcludes(excludes)
+	for _, entry := range removed {
+		fmt.Printf("Note: default exclusion %q was removed from %s and may be overwritten\n", entry, excludesFile)
+	}
+	for _, entry := range added {
+		if negated, ok := strings.CutPrefix(entry, "!"); ok {
+			fmt.Printf("Note: %s re-includes %q; it will be updated\n", excludesFile, negated)
+			continue
+		}
+		fmt.Printf("Note: %s also excludes %q; it will never be updated\n", excludesFile, entry)
+	}
+}
 
-			fmt.Println("\nProxiani detected!")
-			fmt.Println("Proxiani is a local proxy server that can provide additional features.")
-			fmt.Println("Would you like to configure Miriani-Next to connect through Proxiani?")
-			fmt.Println("(This changes the connection from " + defaultServer + " to " + localServer + ":" + proxianiPort + ")")
+// isProtectedConfig reports whether path is user configuration the updater
+// must not overwrite, unless a negation in .updater-excludes asks for it
+func isProtectedConfig(path string) bool {
+	return paths.IsUserConfig(path) && !userExcludes.Reincludes(path)
+}
 
-			if confirmAction("Configure Miriani to use Proxiani?") {
-				worldFilePath := filepath.Join(installDir, worldsDir, worldFileName)
-				if err := updateWorldFileForProxiani(worldFilePath); err != nil {
-					fmt.Printf("Warning: failed to update world file for Proxiani: %v\n", err)
-				} else {
-					fmt.Println("World file updated successfully!")
-					fmt.Println("Miriani-Next will now connect through Proxiani (" + localServer + ":" + proxianiPort + ")")
-				}
-			} else {
-				fmt.Println("Skipping Proxiani configuration. You can manually change this later.")
-			}
+// ------------------------
+// UTILITIES
+// ------------------------
+
+// formatBytes renders a byte count in human-readable units (e.g. "18.3 MB")
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// checkDiskSpace fails if a volume is short of the space about to be written
+// to it. needs maps each destination folder to the bytes going there; folders
+// on the same drive share its free space. Where free space can't be read the
+// check is skipped, so it never stops an update on its own.
+func checkDiskSpace(needs map[string]int64) error {
+	type volume struct {
+		dir    string
+		needed int64
+	}
+	volumes := make(map[string]*volume)
+	for dir, n := range needs {
+		if n <= 0 {
+			continue
 		}
-	} else if (proxianiDetected || mudmixerDetected) && nonInteractive {
-		// In non-interactive mode, auto-configure (prioritize MUDMixer)
-		if mudmixerDetected {
-			console.Log("MUDMixer detected! Auto-configuring world file...")
-			worldFilePath := filepath.Join(installDir, worldsDir, worldFileName)
-			if err := updateWorldFileForMUDMixer(worldFilePath); err != nil {
-				console.Log("Warning: failed to update world file for MUDMixer: %v", err)
-			} else {
-				console.Log("World file updated successfully for MUDMixer")
-			}
-		} else if proxianiDetected {
-			console.Log("Proxiani detected! Auto-configuring world file...")
-			worldFilePath := filepath.Join(installDir, worldsDir, worldFileName)
-			if err := updateWorldFileForProxiani(worldFilePath); err != nil {
-				console.Log("Warning: failed to update world file for Proxiani: %v", err)
-			} else {
-				console.Log("World file updated successfully for Proxiani")
-			}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		name := strings.ToUpper(filepath.VolumeName(abs))
+		if v, ok := volumes[name]; ok {
+			v.needed += n
+		} else {
+			volumes[name] = &volume{dir: abs, needed: n}
 		}
 	}
 
-	// Create desktop icon (wrapped in panic recovery to prevent COM crashes)
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				if !quietFlag {
-					fmt.Printf("Warning: failed to create desktop icon: %v\n", r)
-				}
-			}
-		}()
-		if err := createDesktopIcon(installDir); err != nil {
-			if !quietFlag {
-				fmt.Printf("Warning: failed to create desktop icon: %v\n", err)
+	for _, v := range volumes {
+		free, err := process.FreeSpace(v.dir)
+		if err != nil {
+			if verboseFlag && !quietFlag {
+				log.Printf("Skipping disk space check for %s: %v\n", v.dir, err)
 			}
-		} else if !quietFlag {
-			fmt.Println("Desktop shortcut created!")
+			continue
 		}
-	}()
+		if uint64(v.needed) > free {
+			return fmt.Errorf("not enough disk space for %s: %s needed, only %s free", v.dir, formatBytes(v.needed), formatBytes(int64(free)))
+		}
+	}
+	return nil
+}
 
-	// Move updater to installation directory (AFTER everything is done)
-	exePath, err := os.Executable()
-	if err == nil {
-		// Always name the destination file "update.exe"
-		destPath := filepath.Join(installDir, "update.exe")
-		// Only move if not already in install dir with correct name
-		absExePath, _ := filepath.Abs(exePath)
-		absDestPath, _ := filepath.Abs(destPath)
+// transferRate describes a download's speed and, when known, the time left,
+// e.g. "1.2 MB/s, ~35s left". It returns "" until there's a speed to show.
+func transferRate(bytesPerSecond float64, left time.Duration) string {
+	if bytesPerSecond < 1 {
+		return ""
+	}
+	rate := formatBytes(int64(bytesPerSecond)) + "/s"
+	if left <= 0 {
+		return rate
+	}
+	if left < time.Second {
+		left = time.Second
+	}
+	return fmt.Sprintf("%s, ~%s left", rate, left.Round(time.Second))
+}
 
-		if absExePath != absDestPath {
-			// Go back to original directory before moving
-			os.Chdir(originalDir)
+// updateSummary builds the closing line shown after an update, e.g.
+// "Updated 42 files (18.3 MB) in 12s."
+func updateSummary(filesChanged int, bytes int64, elapsed time.Duration) string {
+	noun := "files"
+	if filesChanged == 1 {
+		noun = "file"
+	}
+	if elapsed < time.Second {
+		elapsed = elapsed.Round(time.Millisecond)
+	} else {
+		elapsed = elapsed.Round(time.Second)
+	}
+	return fmt.Sprintf("Updated %d %s (%s) in %s.", filesChanged, noun, formatBytes(bytes), elapsed)
+}
 
-			// Copy first, then remove original only if copy succeeded
-			data, err := os.ReadFile(exePath)
-			if err == nil {
-				if err := os.WriteFile(destPath, data, 0755); err == nil {
-					// Successfully copied, now safe to remove original
-					os.Remove(exePath)
-				}
-			}
+// reportPartialUpdate tells the user which files failed in a -best-effort update
+func reportPartialUpdate(updates []manifest.FileInfo, deletedFiles []string, partial *partialUpdateError, wasRestarted bool) {
+	for _, err := range partial.Errs {
+		eventLog.Warn("%v", err)
+	}
+	eventLog.Result("partial", partial.Error())
+	playSoundAsync(audio.CategoryError, errorSound, 0.0)
+	fmt.Printf("\nUpdate partially complete: %d of %d files failed.\n", len(partial.Failed), len(updates))
+	if !quietFlag {
+		fmt.Println(partial.details(failureListLimit()))
+		fmt.Println("Run the updater again to retry the failed files.")
+	}
+
+	if shouldWriteResult() {
+		if err := writeUpdatePartial(updates, deletedFiles, partial, wasRestarted); err != nil {
+			console.Log("Warning: failed to write .update-result: %v", err)
 		}
 	}
+	if !nonInteractive {
+		waitForUser("\n" + i18n.T("press_enter_exit"))
+	}
+}
 
-	return installDir, nil
+// ensureWritable checks that dir can be written and, if it needs administrator
+// rights, offers to relaunch elevated with the same arguments plus extraArgs
+func ensureWritable(dir string, extraArgs []string) error {
+	err := install.CheckWritable(dir)
+	if err == nil || !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	return requestElevation(dir, extraArgs)
 }
 
-// ------------------------
-// UPDATER MANAGEMENT
-// ------------------------
-func copyUpdaterToInstallation(installDir string) error {
-	// Get current executable path
+// requestElevation relaunches the updater via the UAC prompt, in the current
+// working directory. It returns errElevated once the elevated copy has
+// started, or another error if elevation isn't allowed, was declined or
+// failed. Non-interactive runs never elevate unless -elevate was given.
+func requestElevation(dir string, extraArgs []string) error {
+	if nonInteractive && !elevateFlag {
+		return fmt.Errorf("%s requires administrator rights (run with -elevate to allow elevation)", dir)
+	}
+	if !nonInteractive && !confirmAction(fmt.Sprintf("Writing to %s requires administrator rights. Relaunch the updater as administrator?", dir)) {
+		return fmt.Errorf("%s requires administrator rights", dir)
+	}
+
 	exePath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
-
-	// Read current executable
-	data, err := os.ReadFile(exePath)
+	workDir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to read updater: %w", err)
+		return fmt.Errorf("failed to get working directory: %w", err)
 	}
-
-	// Write to installation directory as update.exe
-	destPath := filepath.Join(installDir, "update.exe")
-	if err := os.WriteFile(destPath, data, 0755); err != nil {
-		return fmt.Errorf("failed to write updater: %w", err)
+	args := append(append([]string{}, os.Args[1:]...), extraArgs...)
+	if err := process.StartElevated(exePath, workDir, args); err != nil {
+		return err
 	}
 
-	return nil
+	// The elevated copy carries on from here
+	return errElevated
 }
 
-// ============================================================================
-// SECTION 7: PROCESS DETECTION (delegated to internal/process)
-// ============================================================================
+// fatalExit is raised by fatalError and recovered in main, so deferred
+// cleanup runs and every fatal error leaves through the same path
+type fatalExit struct {
+	message string
+}
 
-func isProxianiRunning() bool {
-	return process.IsNodeListeningOnPort(proxianiPort)
+// fatalError ends the run with an error. It unwinds to main, which reports
+// it with reportFatal and exits with status 1. Only call it from the main
+// goroutine: a panic elsewhere isn't recovered.
+func fatalError(format string, args ...interface{}) {
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	panic(fatalExit{message: message})
 }
 
-// ============================================================================
-// SECTION 8: WORLD FILE UPDATES
-// ============================================================================
+// reportFatal shows an error, plays a sound, records the failure for
+// programmatic callers, and waits for the user to acknowledge in interactive
+// mode. It returns the exit status.
+func reportFatal(message string) int {
+	// Play error sound to notify user
+	playSoundAsync(audio.CategoryError, errorSound, 0.0)
 
-var worldFileConfig = install.WorldFileConfig{
-	DefaultServer: defaultServer,
-	LocalServer:   localServer,
-	ProxianiPort:  proxianiPort,
-	MUDMixerPort:  mudMixerPort,
+	// Display the error message
+	console.Error("%s", message)
+
+	// Record the failure for programmatic callers
+	eventLog.Result("failure", message)
+	if shouldWriteResult() {
+		if err := writeUpdateFailure(message); err != nil {
+			console.Log("Warning: failed to write .update-result: %v", err)
+		}
+	}
+
+	// In interactive mode, wait for user to press Enter
+	if !nonInteractive {
+		waitForUser("\n" + i18n.T("press_enter_exit"))
+	}
+
+	return 1
 }
 
-func updateWorldFile(worldFilePath string, updatePort bool) error {
-	return install.UpdateWorldFile(worldFilePath, updatePort, worldFileConfig)
+// moveToOldFolder moves a file to the .old directory instead of deleting it
+func moveToOldFolder(filePath string, relativePath string) error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	// Create .old directory if it doesn't exist
+	oldDir := filepath.Join(baseDir, oldFolder)
+	if err := os.MkdirAll(oldDir, 0755); err != nil {
+		return err
+	}
+
+	// Create subdirectories in .old if needed
+	oldFilePath := filepath.Join(oldDir, paths.Denormalize(relativePath))
+	if err := os.MkdirAll(filepath.Dir(oldFilePath), 0755); err != nil {
+		return err
+	}
+
+	// Move the file
+	return os.Rename(filePath, oldFilePath)
 }
 
-func updateWorldFileForProxiani(worldFilePath string) error {
-	return updateWorldFile(worldFilePath, false)
+// snapshotPreviousState clears what the previous update left in .old/, then
+// copies the manifest and version.json into it as .manifest.prev and
+// version.json.prev before this update overwrites them. The copies are
+// byte-for-byte, so they have the same format as the originals.
+func snapshotPreviousState() error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	return manifestManager.SnapshotPrevious(baseDir, filepath.Join(baseDir, oldFolder), versionFile)
 }
 
-func isMUDMixerRunning() bool {
-	return process.IsPortListening(mudMixerPort)
+// managedPath is a file or directory in the install that belongs to the updater
+type managedPath struct {
+	Path    string // Relative to the install directory
+	Dir     bool
+	Pattern bool // Path is a pattern in .updater-excludes syntax
+	Purpose string
 }
 
-func updateWorldFileForMUDMixer(worldFilePath string) error {
-	return updateWorldFile(worldFilePath, true)
+// managedPaths is the authoritative list of updater state in an install. The
+// state subcommand reports it and cleanup should work from it, so new state
+// files belong here.
+var managedPaths = []managedPath{
+	{Path: manifestFile, Purpose: "Hashes of the installed files, used to find updates"},
+	{Path: versionFile, Purpose: "Installed version"},
+	{Path: channelFile, Purpose: "Saved update channel"},
+	{Path: config.File, Purpose: "Updater preferences (channel, output, volume, download cap, proxy)"},
+	{Path: audio.VolumeFile, Purpose: "Saved sound volume"},
+	{Path: audio.MuteFile, Purpose: "Saved muted sound categories"},
+	{Path: download.MaxRateFile, Purpose: "Saved download speed cap"},
+	{Path: excludesFile, Purpose: "Paths the updater never touches"},
+	{Path: resultFile, Purpose: "Outcome of the last non-interactive or quiet run"},
+	{Path: pendingFile, Purpose: "Update waiting for MUSHclient to restart"},
+	{Path: proxyDetectFile, Purpose: "Whether to offer proxy configuration"},
+	{Path: proxyConfigFile, Purpose: "Proxy host and ports, when not the defaults"},
+	{Path: githubTokenFile, Purpose: "GitHub token for API requests"},
+	{Path: githubCacheFile, Purpose: "Cached GitHub responses, revalidated with ETags"},
+	{Path: selfUpdateLogFile, Purpose: "Background self-update log (kept next to the updater)"},
+	{Path: worldsDir + "/**/*" + worldFileExt + ".bak", Pattern: true, Purpose: "Original world files, kept before the first proxy or connection change"},
+	{Path: changelog.HistoryFile, Purpose: "Changelogs of recent updates, shown by the changelog subcommand"},
+	{Path: oldFolder, Dir: true, Purpose: "Files removed by the last update, plus the manifest and version from before it"},
+	{Path: "Switch to Stable.bat", Purpose: "Shortcut created at install to switch channels"},
+	{Path: "Switch to Dev.bat", Purpose: "Shortcut created at install to switch channels"},
+	{Path: "Switch to Any Channel.bat", Purpose: "Shortcut created at install to switch channels"},
 }
 
-// ============================================================================
-// SECTION 11: INSTALLATION DETECTION
-// ============================================================================
+// findManaged returns the paths under baseDir that p covers and that exist:
+// p.Path itself, or for a pattern every file below its fixed leading folders
+// that matches it
+func findManaged(baseDir string, p managedPath) []string {
+	if !p.Pattern {
+		path := filepath.Join(baseDir, p.Path)
+		if _, err := os.Stat(path); err != nil {
+			return nil
+		}
+		return []string{path}
+	}
+
+	var root []string
+	for _, segment := range strings.Split(p.Path, "/") {
+		if strings.Contains(segment, "*") {
+			break
+		}
+		root = append(root, segment)
+	}
+	pattern := paths.Excludes{strings.ToLower(p.Path)}
+	var found []string
+	filepath.WalkDir(filepath.Join(baseDir, filepath.Join(root...)), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(baseDir, path); err == nil && paths.MatchesExclusion(rel, pattern) {
+			found = append(found, path)
+		}
+		return nil
+	})
+	return found
+}
+
+// runStateCommand handles the state subcommand; args[0] names the action
+func runStateCommand(args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return fmt.Errorf("usage: update state list")
+	}
 
-func isInstalled() bool {
 	baseDir, err := os.Getwd()
 	if err != nil {
-		return false
+		return fmt.Errorf("failed to get working directory: %w", err)
 	}
-	return install.IsInstalled(baseDir)
+
+	fmt.Printf("Updater state in %s:\n", baseDir)
+	for _, p := range managedPaths {
+		name := p.Path
+		if p.Dir {
+			name += string(filepath.Separator)
+		}
+		status := "missing"
+		if len(findManaged(baseDir, p)) > 0 {
+			status = "present"
+		}
+		fmt.Printf("  %-26s %-8s %s\n", name, status, p.Purpose)
+	}
+	return nil
 }
 
-func hasWorldFilesInCurrentDir() bool {
+// runUninstall removes the updater's files from the install in the current
+// directory, plus the desktop shortcut pointing at it. With all, every other
+// file goes too, except the user's MUSHclient settings unless purge is set.
+// The running update.exe can't delete itself, so that is left to a detached
+// command that runs once this process has exited.
+func runUninstall(all, purge bool) error {
+	if purge && !all {
+		return fmt.Errorf("-purge only applies together with -all")
+	}
+	if !isInstalled() {
+		return fmt.Errorf("no installation found in the current directory")
+	}
 	baseDir, err := os.Getwd()
 	if err != nil {
-		return false
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	if isMUSHClientRunning() {
+		return fmt.Errorf("MUSHclient is running; close it and try again")
+	}
+
+	if nonInteractive && !confirmFlag {
+		return fmt.Errorf("uninstall needs -confirm in non-interactive mode")
+	}
+	if !confirmFlag {
+		var proceed bool
+		all, proceed = install.ConfirmUninstall(baseDir, all, purge, installPrompter{})
+		if !proceed {
+			fmt.Println("Uninstall cancelled.")
+			return nil
+		}
+	}
+
+	exePath, _ := os.Executable()
+	var pendingExe string
+	remove := func(path string, dir bool) {
+		if exePath != "" && strings.EqualFold(filepath.Clean(path), filepath.Clean(exePath)) {
+			pendingExe = path
+			return
+		}
+		rel, _ := filepath.Rel(baseDir, path)
+		var err error
+		if dir {
+			err = os.RemoveAll(path)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			console.Warn("Warning: failed to remove %s: %v", rel, err)
+			return
+		}
+		fmt.Printf("Removed: %s\n", rel)
+	}
+
+	if link := desktopShortcutFor(baseDir); link != "" {
+		if err := os.Remove(link); err != nil {
+			console.Warn("Warning: failed to remove desktop shortcut %s: %v", link, err)
+		} else {
+			fmt.Printf("Removed: %s\n", link)
+		}
+	}
+
+	for _, p := range managedPaths {
+		for _, path := range findManaged(baseDir, p) {
+			remove(path, p.Dir)
+		}
+	}
+
+	var kept []string
+	if all {
+		var dirs []string
+		err := filepath.WalkDir(baseDir, func(path string, d os.DirEntry, err error) error {
+			if err != nil || path == baseDir {
+				return err
+			}
+			if d.IsDir() {
+				dirs = append(dirs, path)
+				return nil
+			}
+			rel, _ := filepath.Rel(baseDir, path)
+			if !purge && paths.IsUserConfig(rel) {
+				kept = append(kept, rel)
+				return nil
+			}
+			remove(path, false)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove installation files: %w", err)
+		}
+		// Deepest first, so each folder is empty by the time it's reached
+		for i := len(dirs) - 1; i >= 0; i-- {
+			os.Remove(dirs[i])
+		}
+	} else {
+		path := filepath.Join(baseDir, "update.exe")
+		if _, err := os.Stat(path); err == nil {
+			remove(path, false)
+		}
+	}
+
+	removeDir := ""
+	if all && len(kept) == 0 {
+		removeDir = baseDir
+	}
+	if pendingExe != "" || removeDir != "" {
+		if err := scheduleRemoval(pendingExe, removeDir); err != nil {
+			console.Warn("Warning: %v", err)
+		} else {
+			if pendingExe != "" {
+				fmt.Printf("Removed after exit: %s\n", filepath.Base(pendingExe))
+			}
+			if removeDir != "" {
+				fmt.Printf("Removed after exit: %s\n", removeDir)
+			}
+		}
+	}
+
+	for _, rel := range kept {
+		fmt.Printf("Kept: %s\n", rel)
+	}
+	console.Success("Uninstall complete.")
+	return nil
+}
+
+// scheduleRemoval deletes file and then the (empty) dir from a detached
+// command, after a short delay that lets this process exit first. Either
+// may be empty.
+func scheduleRemoval(file, dir string) error {
+	if dir != "" {
+		// The working directory can't be removed while it's in use
+		os.Chdir(filepath.Dir(dir))
+	}
+	script := "ping -n 3 127.0.0.1 >nul"
+	if file != "" {
+		script += fmt.Sprintf(` & del /f /q "%s"`, file)
+	}
+	if dir != "" {
+		script += fmt.Sprintf(` & rmdir "%s"`, dir)
+	}
+	cmd := exec.Command("cmd", "/C", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | DETACHED_PROCESS,
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to schedule removal of the updater: %w", err)
+	}
+	cmd.Process.Release()
+	return nil
+}
+
+func createUpdaterExcludes() error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
 	}
-	return install.HasWorldFiles(baseDir)
+	return install.WriteDefaultExcludes(baseDir, excludesFile, channelFlag)
 }
 
 // ============================================================================
-// SECTION 16: MISCELLANEOUS
+// SECTION 14: CHANGELOG/RELEASE NOTES
 // ============================================================================
 
-func needsMUSHClientRestart(updates []manifest.FileInfo) bool {
-	for _, file := range updates {
-		lowerName := strings.ToLower(file.Name)
-		if lowerName == "mushclient.exe" || strings.HasSuffix(lowerName, ".dll") {
-			return true
-		}
+// buildChangelog builds the changelog of an update. With online set it adds
+// the sections that need GitHub: release notes on stable, commits elsewhere.
+func buildChangelog(updates []manifest.FileInfo, deletedFiles []string, online bool) string {
+	cfg := changelog.BuildConfig{
+		Channel: channelFlag,
+		// Dev and branch users follow individual commits, so say who and when
+		CommitAuthors: channelFlag != "stable",
+		FlatCommits:   updaterConfig.ChangelogCommits == "flat",
 	}
-	return false
-}
-
-func isMUSHClientRunning() bool {
-	baseDir, err := os.Getwd()
-	if err != nil {
-		return false
+	if online && channelFlag == "stable" {
+		cfg.ReleaseNotes = stableReleaseNotes()
+	} else if online {
+		cfg.Commits = changelogCommits()
 	}
-	return process.IsMUSHClientRunningInDir(baseDir)
+	return changelog.Build(updates, deletedFiles, cfg)
 }
 
-func launchMUSHClient() error {
-	baseDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
-	}
+// changelogBase is the branch commit the install was at before this update
+// (version.json's head), or "" if it predates recording one
+var changelogBase string
 
-	// Check if MUSHclient is already running to prevent duplicate instances
-	if process.IsMUSHClientRunningInDir(baseDir) {
-		return fmt.Errorf("MUSHclient is already running")
+// changelogCommits returns the commits the update brought in on a dev or
+// branch channel, oldest first. It returns nil when the previous head isn't
+// known or GitHub can't be reached; the changelog is still useful without them.
+func changelogCommits() []github.Commit {
+	ref, err := channelRef(channelFlag)
+	if err != nil {
+		return nil
 	}
-
-	exePath := filepath.Join(baseDir, "MUSHclient.exe")
-	if _, err := os.Stat(exePath); err != nil {
-		return fmt.Errorf("MUSHclient.exe not found: %w", err)
+	head, err := getLatestCommit(ref)
+	if err == nil {
+		var commits []github.Commit
+		commits, err = changelog.CommitsSince(runCtx, ghClient, changelogBase, head.SHA)
+		if err == nil {
+			return commits
+		}
 	}
-
-	if err := exec.Command(exePath).Start(); err != nil {
-		return fmt.Errorf("failed to launch MUSHclient: %w", err)
+	if verboseFlag && !quietFlag {
+		log.Printf("Could not list the commits for the changelog: %v", err)
 	}
-
 	return nil
 }
 
-func loadExcludes() map[string]struct{} {
-	baseDir, err := os.Getwd()
+// stableReleaseNotes returns the notes of the GitHub release for the latest
+// tag, falling back to the bundled docs/changelog.txt when they can't be
+// fetched. It returns "" if neither is available.
+func stableReleaseNotes() string {
+	tag, err := getLatestTag()
+	if err == nil {
+		var release *github.Release
+		release, err = ghClient.GetReleaseByTag(runCtx, tag)
+		if err == nil {
+			return release.Body
+		}
+	}
+	if verboseFlag && !quietFlag {
+		log.Printf("Could not fetch the release notes, using %s: %v", releaseNotesFile, err)
+	}
+
+	data, err := os.ReadFile(releaseNotesFile)
 	if err != nil {
-		return make(map[string]struct{})
+		return ""
 	}
-	return paths.LoadExcludes(filepath.Join(baseDir, excludesFile))
+	return string(data)
 }
 
-// ------------------------
-// UTILITIES
-// ------------------------
+// showChangelog displays updated and deleted files and offers to show the
+// changelog, paged in the console or opened in Notepad as -changelog-viewer
+// says (asking when it's unset). content is built on demand if it wasn't
+// already (-changelog-out). It returns content, which is still "" if the user
+// didn't look and nothing had built it.
+func showChangelog(updates []manifest.FileInfo, deletedFiles []string, content string) string {
+	totalChanges := len(updates) + len(deletedFiles)
+	fmt.Println("\n" + i18n.T("files_were_changed", totalChanges, len(updates), len(deletedFiles)))
 
-// fatalError shows an error, plays a sound, and waits for user to acknowledge in interactive mode
-func fatalError(format string, args ...interface{}) {
-	// Play error sound to notify user
-	playSoundAsync(errorSound, 0.0)
+	// Ask if user wants to view changelog
+	if !nonInteractive && askChoice(i18n.T("confirm_view_changelog")) {
+		// Build the changelog only when asked - on dev this fetches commits from GitHub
+		if content == "" {
+			content = buildChangelog(updates, deletedFiles, true)
+		}
 
-	// Display the error message
-	if len(args) > 0 {
-		fmt.Fprintf(os.Stderr, format+"\n", args...)
-	} else {
-		fmt.Fprintln(os.Stderr, format)
-	}
+		viewer := changelogViewerFlag
+		if viewer == "" {
+			options := []string{i18n.T("changelog_in_console"), i18n.T("changelog_in_notepad")}
+			if prompt.Choose(i18n.T("choose_changelog_viewer"), options, promptConfig()) == 0 {
+				viewer = "console"
+			}
+		}
+		if viewer == "console" {
+			fmt.Println()
+			width, height := console.Size()
+			prompt.Page(content, width, height, promptConfig())
+			return content
+		}
 
-	// In interactive mode, wait for user to press Enter
-	if !nonInteractive {
-		waitForUser("\nPress Enter to exit...")
+		// Write to temp file
+		tmpFile := filepath.Join(os.TempDir(), "next-changelog.txt")
+		if err := os.WriteFile(tmpFile, []byte(content), 0644); err == nil {
+			openChangelogFile(tmpFile)
+		}
 	}
-
-	os.Exit(1)
+	return content
 }
 
-// moveToOldFolder moves a file to the .old directory instead of deleting it
-func moveToOldFolder(filePath string, relativePath string) error {
-	baseDir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	// Create .old directory if it doesn't exist
-	oldDir := filepath.Join(baseDir, ".old")
-	if err := os.MkdirAll(oldDir, 0755); err != nil {
-		return err
+// changelogHistoryLimit returns how many updates the changelog history keeps,
+// from .updater-config or the default; 0 turns the history off
+func changelogHistoryLimit() int {
+	if updaterConfig.ChangelogHistory != nil {
+		return *updaterConfig.ChangelogHistory
 	}
+	return changelog.DefaultHistoryEntries
+}
 
-	// Create subdirectories in .old if needed
-	oldFilePath := filepath.Join(oldDir, paths.Denormalize(relativePath))
-	if err := os.MkdirAll(filepath.Dir(oldFilePath), 0755); err != nil {
-		return err
+// runChangelogHistory handles the changelog subcommand, printing the
+// changelogs of the last N updates (args[0], default 5), newest first
+func runChangelogHistory(args []string) error {
+	count := 5
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("usage: update changelog [N], where N is a positive number")
+		}
+		count = n
 	}
 
-	// Move the file
-	return os.Rename(filePath, oldFilePath)
-}
-
-func cleanOldFolder() error {
 	baseDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	entries, err := changelog.LoadHistory(baseDir)
 	if err != nil {
 		return err
 	}
+	if len(entries) == 0 {
+		fmt.Printf("No changelog history yet; %s is written after each update.\n", changelog.HistoryFile)
+		return nil
+	}
 
-	oldDir := filepath.Join(baseDir, ".old")
-	if _, err := os.Stat(oldDir); err == nil {
-		if !quietFlag && verboseFlag {
-			fmt.Println("Cleaning up .old directory from previous run...")
+	for i, e := range changelog.Recent(entries, count) {
+		if i > 0 {
+			fmt.Println()
 		}
-		return os.RemoveAll(oldDir)
+		fmt.Print(e.String())
 	}
 	return nil
 }
 
-func createUpdaterExcludes() error {
-	baseDir, err := os.Getwd()
-	if err != nil {
-		return err
+// openChangelogFile opens the changelog with the user's default handler for
+// its extension, falling back to notepad if no association works
+func openChangelogFile(path string) {
+	err := process.OpenDefault(path)
+	if err == nil {
+		return
 	}
-
-	var content strings.Builder
-	content.WriteString("# Updater Exclusions\n")
-	content.WriteString("# This file lists paths that the updater will NEVER touch.\n")
-	content.WriteString("# These are typically user configuration files and data.\n")
-	content.WriteString("#\n")
-	content.WriteString("# Lines starting with # are comments.\n")
-	content.WriteString("# One path per line.\n")
-	content.WriteString("# Paths are relative to the installation directory.\n")
-	content.WriteString("#\n")
-	content.WriteString("# DO NOT delete this file unless you want the updater to\n")
-	content.WriteString("# potentially overwrite your configuration!\n")
-	content.WriteString("\n")
-	content.WriteString("# MUSHclient configuration files\n")
-	content.WriteString("mushclient.ini\n")
-	content.WriteString("mushclient_prefs.sqlite\n")
-	content.WriteString("\n")
-	content.WriteString("# World configuration files (*.mcl files in worlds directory)\n")
-	content.WriteString("worlds/*.mcl\n")
-	content.WriteString("\n")
-
-	excludesPath := filepath.Join(baseDir, excludesFile)
-	return os.WriteFile(excludesPath, []byte(content.String()), 0644)
-}
-
-// ============================================================================
-// SECTION 14: CHANGELOG/RELEASE NOTES
-// ============================================================================
-
-func buildChangelog(updates []manifest.FileInfo, deletedFiles []string) string {
-	return changelog.Build(updates, deletedFiles, changelog.BuildConfig{
-		Channel: channelFlag,
-	})
-}
-
-// showChangelog displays updated and deleted files and offers to open in notepad
-func showChangelog(updates []manifest.FileInfo, deletedFiles []string) {
-	totalChanges := len(updates) + len(deletedFiles)
-	fmt.Printf("\n%d files were changed (%d updated, %d deleted)\n", totalChanges, len(updates), len(deletedFiles))
-
-	// Build the changelog content
-	changelogContent := buildChangelog(updates, deletedFiles)
-
-	// Ask if user wants to view changelog
-	if !nonInteractive && confirmAction("Would you like to view the detailed changelog?") {
-		// Write to temp file
-		tmpFile := filepath.Join(os.TempDir(), "next-changelog.txt")
-		if err := os.WriteFile(tmpFile, []byte(changelogContent), 0644); err == nil {
-			// Open with notepad
-			exec.Command("notepad.exe", tmpFile).Start()
-		}
+	if verboseFlag {
+		fmt.Printf("Could not open changelog with the default application: %v\n", err)
 	}
+	exec.Command("notepad.exe", path).Start()
 }
 
 func waitForUser(p string) {
@@ -2511,6 +5415,12 @@ func confirmAction(p string) bool {
 	return prompt.Confirm(p, promptConfig())
 }
 
+// askChoice asks a yes/no question that picks what happens rather than
+// confirming it; -yes doesn't answer these
+func askChoice(p string) bool {
+	return prompt.Ask(p, promptConfig())
+}
+
 // ============================================================================
 // SECTION 10: CHANNEL MANAGEMENT
 // ============================================================================
@@ -2531,6 +5441,70 @@ func loadChannel() (string, error) {
 	return channel.Load(baseDir)
 }
 
+// channelRef resolves a channel to the git ref its files come from
+func channelRef(ch string) (string, error) {
+	switch ch {
+	case "stable":
+		return getLatestTag()
+	case "dev":
+		return "main", nil
+	default:
+		return ch, nil
+	}
+}
+
+// previewChannelSwitch reports how far apart two channels are and how many
+// files switching would change, without saving anything
+func previewChannelSwitch(fromChannel, toChannel string) error {
+	if fromChannel == toChannel {
+		fmt.Printf("Already on the %s channel; switching would change nothing.\n", toChannel)
+		return nil
+	}
+
+	fromRef, err := channelRef(fromChannel)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", fromChannel, err)
+	}
+	toRef, err := channelRef(toChannel)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", toChannel, err)
+	}
+
+	// Diff the local install against the target channel's manifest
+	previous := channelFlag
+	channelFlag = toChannel
+	updates, deletedFiles, err := getPendingUpdates()
+	channelFlag = previous
+	if err != nil {
+		return err
+	}
+
+	comparison, err := compareCommits(fromRef, toRef)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s with %s: %w", fromRef, toRef, err)
+	}
+
+	var position string
+	switch {
+	case comparison.AheadBy > 0 && comparison.BehindBy > 0:
+		position = fmt.Sprintf("%d commits ahead and %d behind", comparison.AheadBy, comparison.BehindBy)
+	case comparison.BehindBy > 0:
+		position = fmt.Sprintf("%d commits behind (a downgrade)", comparison.BehindBy)
+	case comparison.AheadBy > 0:
+		position = fmt.Sprintf("%d commits ahead", comparison.AheadBy)
+	default:
+		position = "at the same commit"
+	}
+
+	fmt.Printf("Switching from %s to %s would change %d files (%d updated, %d deleted) and is %s.\n",
+		fromChannel, toChannel, len(updates)+len(deletedFiles), len(updates), len(deletedFiles), position)
+	if needsMUSHClientRestart(updates) {
+		fmt.Println("The next update would require MUSHclient to be restarted.")
+	}
+	fmt.Println("Nothing was changed. Run without -preview to switch.")
+	return nil
+}
+
 func isValidChannel(channel string) bool {
 	// Always allow stable and dev
 	if channel == "stable" || channel == "dev" {
@@ -2538,7 +5512,7 @@ func isValidChannel(channel string) bool {
 	}
 
 	// Check if it's a valid branch name
-	branches, err := ghClient.GetBranches()
+	branches, err := ghClient.GetBranches(runCtx)
 	if err != nil {
 		// If we can't fetch branches, only allow stable/dev
 		return false
@@ -2557,6 +5531,115 @@ func promptInstallationMenu(existingInstallFound bool, detectedPath string, toas
 	return prompt.InstallationMenu(existingInstallFound, detectedPath, toastushPath, promptConfig())
 }
 
+// channelOption describes one update channel for the channel listings
+type channelOption struct {
+	Type        string `json:"type"`              // "stable", "dev" or "branch"
+	Name        string `json:"name"`              // Value for -channel / switch
+	DisplayName string `json:"display_name"`      // Label for a picker
+	Ref         string `json:"ref"`               // Tag (stable) or commit SHA
+	Updated     string `json:"updated,omitempty"` // Last commit date, RFC 3339
+	Active      bool   `json:"active"`            // Whether this install follows it
+}
+
+// gatherChannels collects stable (latest tag), dev (main) and every other
+// branch with its ref and last commit date. The branch list carries no dates,
+// so each other branch costs a request of its own; those dates are only
+// fetched with branchDates set. A missing date is left empty rather than
+// failing the whole listing.
+func gatherChannels(branchDates bool) ([]channelOption, error) {
+	var channels []channelOption
+
+	tag, err := getLatestTag()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest tag: %w", err)
+	}
+	stable := channelOption{Type: "stable", Name: "stable", DisplayName: "Stable", Ref: tag}
+	if commit, err := getLatestCommit(tag); err == nil {
+		stable.Updated = commit.Commit.Author.Date
+	}
+	channels = append(channels, stable)
+
+	dev := channelOption{Type: "dev", Name: "dev", DisplayName: "Dev"}
+	if commit, err := getLatestCommit("main"); err == nil {
+		dev.Ref = commit.SHA
+		dev.Updated = commit.Commit.Author.Date
+	}
+	channels = append(channels, dev)
+
+	branches, err := ghClient.GetBranches(runCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+	for _, b := range branches {
+		if b.Name == "main" {
+			continue
+		}
+		option := channelOption{Type: "branch", Name: b.Name, DisplayName: b.Name, Ref: b.Commit.SHA}
+		if branchDates {
+			if commit, err := getLatestCommit(b.Name); err == nil {
+				option.Updated = commit.Commit.Author.Date
+			}
+		}
+		channels = append(channels, option)
+	}
+
+	for i := range channels {
+		channels[i].Active = channels[i].Name == channelFlag
+	}
+	return channels, nil
+}
+
+// runChannelList prints gatherChannels as JSON for tooling, or as a table.
+// Non-interactive runs get one tab-separated "name ref updated" line per
+// channel instead, which scripts can split without a JSON parser. Branch
+// dates are only looked up for JSON and -verbose, to spare the API rate limit.
+func runChannelList(asJSON bool) error {
+	channels, err := gatherChannels(asJSON || verboseFlag)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(channels, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal channels: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if nonInteractive {
+		for _, ch := range channels {
+			fmt.Printf("%s\t%s\t%s\n", ch.Name, shortRef(ch), ch.Updated)
+		}
+		return nil
+	}
+
+	for _, ch := range channels {
+		marker := " "
+		if ch.Active {
+			marker = "*"
+		}
+		ref := shortRef(ch)
+		updated := ""
+		if t, err := time.Parse(time.RFC3339, ch.Updated); err == nil {
+			updated = t.Format("Jan 2, 2006")
+		}
+		fmt.Printf("%s %-24s %-10s %s\n", marker, ch.Name, ref, updated)
+	}
+	fmt.Println("\n* = current channel")
+	return nil
+}
+
+// shortRef is a channel's tag, or its commit SHA cut to seven characters
+func shortRef(ch channelOption) string {
+	if ch.Type != "stable" && len(ch.Ref) > 7 {
+		return ch.Ref[:7]
+	}
+	return ch.Ref
+}
+
 func promptForChannel() string {
 	return promptForChannelWithOptions(false)
 }
@@ -2573,7 +5656,8 @@ func promptForChannelWithOptions(forFutureUpdates bool) string {
 	if date, err := getLastCommitDate("main"); err == nil {
 		info.DevDate = date
 	}
-	return prompt.ChannelMenu(info, ghClient.GetBranches, promptConfig())
+	fetchBranches := func() ([]github.Branch, error) { return ghClient.GetBranches(runCtx) }
+	return prompt.ChannelMenu(info, fetchBranches, promptConfig())
 }
 
 // ============================================================================
@@ -2633,16 +5717,20 @@ func getLatestVersion() (*Version, error) {
 			ref = "main"
 		}
 
-		tree, err := getGitHubTree(ref)
+		// The version has always recorded the root tree SHA; the commit
+		// carries it, so the full recursive tree isn't needed here
+		commit, err := getLatestCommit(ref)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get commit SHA: %w", err)
 		}
+		treeSHA := commit.Commit.Tree.SHA
+		ver.Head = commit.SHA
 
-		// Store first 16 characters of commit SHA
-		if len(tree.SHA) >= 16 {
-			ver.Commit = tree.SHA[:16]
+		// Store first 16 characters of the tree SHA
+		if len(treeSHA) >= 16 {
+			ver.Commit = treeSHA[:16]
 		} else {
-			ver.Commit = tree.SHA
+			ver.Commit = treeSHA
 		}
 
 		if !quietFlag && verboseFlag {
@@ -2653,12 +5741,29 @@ func getLatestVersion() (*Version, error) {
 	return &ver, nil
 }
 
+// localVersionNoted makes getLocalVersion report a bad version file only once
+var localVersionNoted sync.Once
+
+// getLocalVersion loads version.json. Callers treat any error as "version
+// unknown"; a corrupt file is logged (distinctly from a missing one) and gets
+// rewritten after the next successful update.
 func getLocalVersion() (*Version, error) {
 	baseDir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
-	return version.LoadLocal(baseDir, versionFile)
+	v, err := version.LoadLocal(baseDir, versionFile)
+	if err != nil {
+		localVersionNoted.Do(func() {
+			if errors.Is(err, version.ErrCorrupt) {
+				console.Log("Warning: %s is corrupt, treating the installed version as unknown: %v", versionFile, err)
+				eventLog.Warn("%s is corrupt: %v", versionFile, err)
+			} else if verboseFlag && !quietFlag {
+				fmt.Printf("No %s found; installed version unknown\n", versionFile)
+			}
+		})
+	}
+	return v, err
 }
 
 // detectToastushInstallation attempts to find an existing Toastush installation
@@ -2680,6 +5785,34 @@ func detectToastushInstallation() string {
 	return ""
 }
 
+// knownInstallations lists other MUSHclient installs the updater can find:
+// the current directory, a Toastush install and the desktop shortcut targets
+func knownInstallations() []string {
+	var dirs []string
+	if cwd, err := os.Getwd(); err == nil && install.IsInstalled(cwd) {
+		dirs = append(dirs, cwd)
+	}
+	dirs = append(dirs, detectToastushInstallation(), checkDesktopShortcut("Miriani-Next"))
+	return dirs
+}
+
+// warnSharedPrefs warns when installDir's preferences database is the same
+// file as another install's, since updating one can then corrupt the other
+func warnSharedPrefs(installDir string) {
+	for _, other := range install.SharedPrefs(installDir, knownInstallations()) {
+		eventLog.Warn("%s shares its %s with %s", installDir, install.PrefsFile, other)
+		if nonInteractive {
+			console.Log("Warning: %s shares its %s with %s", installDir, install.PrefsFile, other)
+			continue
+		}
+		if !quietFlag {
+			fmt.Printf("\nWarning: this installation shares its settings database (%s) with:\n  %s\n", install.PrefsFile, other)
+			fmt.Println("Both installs read and write the same file, so changes in one will affect the other.")
+			fmt.Println("If that isn't intended, replace the link with a separate copy of the file.")
+		}
+	}
+}
+
 func getDesktopPath() (string, error) {
 	userProfile := os.Getenv("USERPROFILE")
 	if userProfile == "" {
@@ -2730,6 +5863,31 @@ func checkDesktopShortcut(name string) string {
 	return ""
 }
 
+// desktopShortcutFor returns the path of the Miriani-Next desktop shortcut if
+// it launches the install in installDir, or "" if there is none
+func desktopShortcutFor(installDir string) string {
+	userProfile := os.Getenv("USERPROFILE")
+	if userProfile == "" {
+		return ""
+	}
+
+	desktops := []string{
+		filepath.Join(userProfile, "Desktop"),
+		filepath.Join(userProfile, "OneDrive", "Desktop"),
+	}
+
+	for _, desktop := range desktops {
+		linkPath := filepath.Join(desktop, "Miriani-Next.lnk")
+		if _, err := os.Stat(linkPath); err != nil {
+			continue
+		}
+		if target := getShortcutTarget(linkPath); target != "" && strings.EqualFold(filepath.Clean(filepath.Dir(target)), filepath.Clean(installDir)) {
+			return linkPath
+		}
+	}
+	return ""
+}
+
 // getShortcutTarget reads the target path from a Windows shortcut (.lnk file)
 func getShortcutTarget(linkPath string) string {
 	if err := ole.CoInitialize(0); err != nil {
@@ -2769,6 +5927,26 @@ func getShortcutTarget(linkPath string) string {
 // SECTION 15: MIGRATION
 // ============================================================================
 
+// migrationTarget is where a migrated Toastush folder ends up: -install-dir
+// when given, otherwise a Miriani-Next folder beside it
+func migrationTarget(toastushDir string) string {
+	if installDirFlag != "" {
+		return filepath.Clean(installDirFlag)
+	}
+	return filepath.Join(filepath.Dir(toastushDir), "Miriani-Next")
+}
+
+// sameVolume reports whether two paths are on the same drive or network
+// share, so one can be renamed to the other
+func sameVolume(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return strings.EqualFold(filepath.VolumeName(absA), filepath.VolumeName(absB))
+}
+
 func handleToastushMigration(toastushDir string) error {
 	// If we didn't auto-detect an installation, prompt for the directory
 	if toastushDir == "" {
@@ -2828,14 +6006,14 @@ func handleToastushMigration(toastushDir string) error {
 				if err := exec.Command("taskkill", "/IM", "MUSHclient.exe", "/F").Run(); err != nil {
 					fmt.Printf("Error closing MUSHclient: %v\n", err)
 					fmt.Println("Please close MUSHclient manually before proceeding.")
-					playSound(errorSound)
-					waitForUser("\nPress Enter to exit...")
+					playSound(audio.CategoryError, errorSound)
+					waitForUser("\n" + i18n.T("press_enter_exit"))
 					return fmt.Errorf("failed to close MUSHclient: %w", err)
 				}
-				fmt.Println("MUSHclient closed successfully.")
+				console.Success("MUSHclient closed successfully.")
 				// Wait for process to fully terminate
 				if !process.WaitForTermination("MUSHclient.exe", 5*time.Second) {
-					fmt.Println("Warning: MUSHclient may not have fully terminated")
+					console.Warn("Warning: MUSHclient may not have fully terminated")
 				}
 			} else {
 				fmt.Println("Migration cancelled. Please close MUSHclient and run the migration again.")
@@ -2872,6 +6050,32 @@ func handleToastushMigration(toastushDir string) error {
 		}
 	}
 
+	warnSharedPrefs(toastushDir)
+
+	// The migrated folder is renamed to Miriani-Next (or moved to -install-dir).
+	// Settle what happens to an existing folder there before changing anything:
+	// it's moved to a timestamped backup, never deleted.
+	newDir := migrationTarget(toastushDir)
+	if !sameVolume(toastushDir, newDir) {
+		return fmt.Errorf("cannot migrate %s to %s: the folder is renamed in place, so -install-dir must be on the same drive as the Toastush installation", toastushDir, newDir)
+	}
+	var backupDir string
+	if toastushDir != newDir {
+		if _, err := os.Stat(newDir); err == nil {
+			if nonInteractive && !overwriteExistingFlag {
+				return fmt.Errorf("%s already exists; run with -overwrite-existing to move it to a backup and continue", newDir)
+			}
+			backupDir = newDir + ".backup-" + time.Now().Format("20060102-150405")
+			if !nonInteractive {
+				fmt.Printf("\nDirectory already exists: %s\n", newDir)
+				fmt.Printf("It will be moved to: %s\n", backupDir)
+				if !confirmAction("Move the existing Miriani-Next directory aside and continue?") {
+					return fmt.Errorf("migration cancelled by user")
+				}
+			}
+		}
+	}
+
 	if !quietFlag {
 		fmt.Printf("\nInstalling Miriani-Next files to: %s\n", toastushDir)
 	}
@@ -2888,25 +6092,24 @@ func handleToastushMigration(toastushDir string) error {
 	}
 
 	// Rename directory to Miriani-Next
-	newDir := filepath.Join(filepath.Dir(toastushDir), "Miriani-Next")
 	if toastushDir != newDir {
-		// Check if target already exists
-		if _, err := os.Stat(newDir); err == nil {
-			if !nonInteractive {
-				fmt.Printf("\nDirectory already exists: %s\n", newDir)
-				if !confirmAction("Remove existing Miriani-Next directory and continue?") {
-					return fmt.Errorf("migration cancelled by user")
-				}
+		if backupDir != "" {
+			if err := os.Rename(newDir, backupDir); err != nil {
+				return fmt.Errorf("failed to move existing directory aside: %w", err)
 			}
-			// Remove existing directory
-			if err := os.RemoveAll(newDir); err != nil {
-				return fmt.Errorf("failed to remove existing directory: %w", err)
+			if nonInteractive {
+				console.Log("Moved existing %s to %s", newDir, backupDir)
+			} else if !quietFlag {
+				fmt.Printf("Previous Miriani-Next directory saved as: %s\n", backupDir)
 			}
 		}
 
 		if !quietFlag {
 			fmt.Printf("\nRenaming directory to: %s\n", newDir)
 		}
+		if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(newDir), err)
+		}
 		if err := os.Rename(toastushDir, newDir); err != nil {
 			return fmt.Errorf("failed to rename directory: %w", err)
 		}
@@ -2920,19 +6123,19 @@ func handleToastushMigration(toastushDir string) error {
 
 	// Generate manifest
 	if err := saveManifest(); err != nil {
-		fmt.Printf("Warning: failed to generate manifest: %v\n", err)
+		console.Warn("Warning: failed to generate manifest: %v", err)
 	}
 
 	// Save channel preference
 	if err := saveChannel(channelFlag); err != nil {
-		fmt.Printf("Warning: failed to save channel preference: %v\n", err)
+		console.Warn("Warning: failed to save channel preference: %v", err)
 	}
 
 	// Save version.json with the installed version
 	if latestVer, err := getLatestVersion(); err == nil {
 		if versionData, err := json.MarshalIndent(latestVer, "", "  "); err == nil {
-			if err := os.WriteFile(versionFile, versionData, 0644); err != nil {
-				fmt.Printf("Warning: failed to save version file: %v\n", err)
+			if err := paths.WriteFileAtomic(versionFile, versionData, 0644); err != nil {
+				console.Warn("Warning: failed to save version file: %v", err)
 			} else if !quietFlag && verboseFlag {
 				fmt.Printf("Saved version: %s\n", latestVer.String())
 			}
@@ -2941,12 +6144,12 @@ func handleToastushMigration(toastushDir string) error {
 
 	// Create channel switching batch files
 	if err := install.CreateChannelSwitchBatchFiles(toastushDir); err != nil {
-		fmt.Printf("Warning: failed to create channel switch batch files: %v\n", err)
+		console.Warn("Warning: failed to create channel switch batch files: %v", err)
 	}
 
 	// Copy updater to installation
 	if err := copyUpdaterToInstallation(toastushDir); err != nil {
-		fmt.Printf("Warning: failed to copy updater: %v\n", err)
+		console.Warn("Warning: failed to copy updater: %v", err)
 	}
 
 	// Update desktop shortcut
@@ -2955,14 +6158,14 @@ func handleToastushMigration(toastushDir string) error {
 	}
 	if err := createDesktopIcon(toastushDir); err != nil {
 		if !quietFlag {
-			fmt.Printf("Warning: failed to update desktop shortcut: %v\n", err)
+			console.Warn("Warning: failed to update desktop shortcut: %v", err)
 		}
 	} else if !quietFlag {
 		fmt.Println("Desktop shortcut updated!")
 	}
 
 	if !quietFlag {
-		fmt.Println("\nMigration complete!")
+		console.Success("\nMigration complete!")
 		fmt.Println("Location:", toastushDir)
 	}
 
@@ -3044,7 +6247,7 @@ func createDesktopIcon(targetDir string) error {
 // The embedded ZIP should contain .manifest and version.json from the release.
 func installFromEmbedded(installDir string, embeddedVersion string) (string, error) {
 	// Play installation sound asynchronously so it doesn't block extraction
-	playSoundAsyncLoop(installingSound, -1.5, true)
+	playSoundAsyncLoop(audio.CategoryProgress, installingSound, -1.5, true)
 
 	if !quietFlag {
 		fmt.Println("Extracting files...")
@@ -3092,7 +6295,7 @@ func installFromEmbedded(installDir string, embeddedVersion string) (string, err
 			fmt.Println("Generating manifest...")
 		}
 		if err := saveManifest(); err != nil {
-			fmt.Printf("Warning: failed to generate manifest: %v\n", err)
+			console.Warn("Warning: failed to generate manifest: %v", err)
 		}
 	} else if !quietFlag && verboseFlag {
 		fmt.Println("Using embedded manifest")
@@ -3107,7 +6310,7 @@ func installFromEmbedded(installDir string, embeddedVersion string) (string, err
 			ver.Patch = patch
 		}
 		if data, err := json.MarshalIndent(ver, "", "  "); err == nil {
-			os.WriteFile(versionFile, data, 0644)
+			paths.WriteFileAtomic(versionFile, data, 0644)
 		}
 	}
 
@@ -3116,34 +6319,34 @@ func installFromEmbedded(installDir string, embeddedVersion string) (string, err
 		channelFlag = "stable"
 	}
 	if err := saveChannel(channelFlag); err != nil {
-		fmt.Printf("Warning: failed to save channel preference: %v\n", err)
+		console.Warn("Warning: failed to save channel preference: %v", err)
 	}
 
 	// Create .updater-excludes file if it doesn't exist
 	if _, err := os.Stat(excludesFile); os.IsNotExist(err) {
 		if err := createUpdaterExcludes(); err != nil {
-			fmt.Printf("Warning: failed to create .updater-excludes: %v\n", err)
+			console.Warn("Warning: failed to create .updater-excludes: %v", err)
 		}
 	}
 
 	// Create channel switching batch files
 	if err := install.CreateChannelSwitchBatchFiles(installDir); err != nil {
-		fmt.Printf("Warning: failed to create channel switch batch files: %v\n", err)
+		console.Warn("Warning: failed to create channel switch batch files: %v", err)
 	}
 
 	// Download slim updater to replace the fat offline installer
 	if err := downloadSlimUpdater(installDir); err != nil {
-		fmt.Printf("Warning: failed to download updater: %v\n", err)
+		console.Warn("Warning: failed to download updater: %v", err)
 		fmt.Println("You can manually download it from: https://github.com/distantorigin/next-launcher/releases")
 	}
 
 	if !quietFlag {
-		fmt.Println("\nInstallation complete!")
+		console.Success("%s", "\n"+i18n.T("installation_complete"))
 		fmt.Println("Location:", installDir)
 		fmt.Printf("Version: %s (offline installer)\n", embeddedVersion)
 	}
 
-	playSound(successSound)
+	playSound(audio.CategoryStatus, successSound)
 
 	return installDir, nil
 }
@@ -3156,7 +6359,11 @@ func downloadSlimUpdater(installDir string) error {
 	targetPath := filepath.Join(installDir, "update.exe")
 
 	// Download to temp file first
-	resp, err := http.Get(updaterURL)
+	req, err := http.NewRequestWithContext(runCtx, "GET", updaterURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}